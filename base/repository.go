@@ -49,7 +49,31 @@ func (base *BaseRepository[T]) Get(id uint) (*T, error) {
 // - error: Error if any occurred during the query.
 func (base *BaseRepository[T]) GetByCondition(condition any, args ...any) (*T, error) {
 	var entity T
-	if err := base.DB.First(&entity, condition, args).Error; err != nil {
+	if err := base.DB.Where(condition, args...).First(&entity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// GetByConditionWithRelations retrieves a single record matching the given condition
+// along with the given relations preloaded.
+// Parameters:
+// - relations ([]string): Related models to preload (GORM's eager loading).
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *T: Pointer to the found entity (or nil if not found).
+// - error: Error if any occurred during the query.
+func (base *BaseRepository[T]) GetByConditionWithRelations(relations []string, condition any, args ...any) (*T, error) {
+	var entity T
+	query := base.DB
+	for _, relation := range relations {
+		query = query.Preload(relation)
+	}
+	if err := query.Where(condition, args...).First(&entity).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -139,6 +163,67 @@ func (base *BaseRepository[T]) Delete(entity *T, isSoftDelete bool) error {
 
 }
 
+// FindPage retrieves one page of the records matching filters, with the total number of matching records.
+// The keyset condition (the rows after a cursor) is applied after counting, so the total covers every page.
+// Parameters:
+// - filters (*gorm.DB): A query builder with filter conditions.
+// - keyset (string): SQL WHERE clause selecting the rows after the cursor (can be empty).
+// - keysetArgs ([]any): Arguments for the keyset clause.
+// - orderBy (string): Order clause of the page.
+// - limit (int): Number of records per page.
+// - offset (int): Offset for pagination.
+// Returns:
+// - []T: Slice of found entities.
+// - int64: Total number of records found.
+// - error: Error if any occurred during the query.
+func (base *BaseRepository[T]) FindPage(filters *gorm.DB, keyset string, keysetArgs []any, orderBy string, limit, offset int) ([]T, int64, error) {
+	var entities []T
+	var total int64
+
+	query := base.DB.Model(new(T))
+	if filters != nil {
+		query = filters.Model(new(T))
+	}
+
+	// every step below starts from the filters, the count must not leak into the page query
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if keyset != "" {
+		query = query.Where(keyset, keysetArgs...)
+	}
+
+	if err := query.Order(orderBy).Limit(limit).Offset(offset).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entities, total, nil
+}
+
+// FindInBatches processes all the records matching filters in batches, ordered by the primary key,
+// so large result sets are never loaded into memory at once.
+// Parameters:
+// - filters (*gorm.DB): A query builder with filter conditions.
+// - batchSize (int): Number of records per batch.
+// - process (func([]T) error): Called with every batch; an error stops the processing.
+// Returns:
+// - error: Error if any occurred during the query or returned by process.
+func (base *BaseRepository[T]) FindInBatches(filters *gorm.DB, batchSize int, process func([]T) error) error {
+	var entities []T
+
+	query := base.DB.Model(new(T))
+	if filters != nil {
+		query = filters.Model(new(T))
+	}
+
+	return query.FindInBatches(&entities, batchSize, func(tx *gorm.DB, batch int) error {
+		return process(entities)
+	}).Error
+}
+
 // FindAllByCondition retrieves all records matching a given condition.
 // Parameters:
 // - condition (any): SQL WHERE clause.
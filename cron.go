@@ -0,0 +1,14 @@
+package main
+
+import (
+	inventoryService "e-commerce/modules/inventory/service"
+	userService "e-commerce/modules/user_management/service"
+	"e-commerce/services"
+	"time"
+)
+
+// registerCronJobs schedules the periodic jobs of the application
+func registerCronJobs() {
+	services.ScheduleJob("purge-deleted-accounts", time.Hour, userService.NewUserService().PurgeDeletedAccounts)
+	services.ScheduleJob("expire-stock-reservations", time.Minute, inventoryService.NewInventoryService().ExpireReservations)
+}
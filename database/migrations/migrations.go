@@ -3,7 +3,10 @@ package migrations
 import (
 	"e-commerce/database/connections"
 	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
 	"fmt"
+
+	"gorm.io/gorm"
 )
 
 func RunMigrations() error {
@@ -11,10 +14,29 @@ func RunMigrations() error {
 
 	modelsToMigrate := []interface{}{
 		&models.Role{},
+		&models.Permission{},
+		&models.RolePermission{},
 		&models.User{},
 		&models.UserPassword{},
+		&models.UserTwoFactor{},
+		&models.UserRecoveryCode{},
+		&models.UserIdentity{},
+		&models.LoginEvent{},
 		&models.AddressType{},
 		&models.Address{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductVariant{},
+		&models.VariantOption{},
+		&models.Attribute{},
+		&models.ProductAttributeValue{},
+		&models.ProductImage{},
+		&models.Warehouse{},
+		&models.StockLevel{},
+		&models.StockReservation{},
+		&models.StockMovement{},
+		&models.Cart{},
+		&models.CartItem{},
 	}
 
 	for _, model := range modelsToMigrate {
@@ -22,5 +44,23 @@ func RunMigrations() error {
 			return fmt.Errorf("migration failed for %T: %w", model, err)
 		}
 	}
+
+	return normalizePhones(db)
+}
+
+// normalizePhones converts the phone numbers stored before the E.164 format
+// (10 digits without country code) like helper.NormalizePhone does:
+// the default country code is prefixed and the trunk prefix 0 dropped.
+func normalizePhones(db *gorm.DB) error {
+	result := db.Unscoped().Model(&models.User{}).
+		Where("users.phone ~ ?", "^[0-9]{10}$").
+		Update("phone", gorm.Expr("? || LTRIM(users.phone, '0')", helper.DefaultPhoneCountryCode))
+	if result.Error != nil {
+		return fmt.Errorf("migration failed for the phone numbers: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		fmt.Printf("normalised %d phone numbers to E.164\n", result.RowsAffected)
+	}
 	return nil
 }
@@ -0,0 +1,14 @@
+package main
+
+import (
+	cartService "e-commerce/modules/cart/service"
+	mediaService "e-commerce/modules/media/service"
+	"e-commerce/services"
+)
+
+// registerHooks registers the hooks the modules run on the events of the other modules,
+// so they do not depend on each other
+func registerHooks() {
+	services.RegisterLoginHook(cartService.NewCartService().MergeGuestCartOnLogin)
+	services.RegisterProductDeletedHook(mediaService.NewMediaService().DeleteProductImages)
+}
@@ -36,6 +36,7 @@ import (
 	"e-commerce/middleware/requestlog"
 	"e-commerce/services"
 	configdata "e-commerce/utils/config_data"
+	"e-commerce/utils/constants"
 	"e-commerce/utils/helper"
 )
 
@@ -73,11 +74,29 @@ func main() {
 	// init email smtp connection & initialize a global smtp connection variable to use for email notification
 	services.InitSmtpServer(configData.SmtpServer)
 
+	// cache the users loaded by the auth middleware for a short time
+	services.InitUserCache(configData.UserCacheTTLSec)
+
+	services.InitOIDCProviders(configData.OIDCProviders)
+
+	// init the sms provider used for the phone verification
+	if err := services.InitSmsSender(configData.Sms); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to init the sms provider: %s\n", err)
+		os.Exit(1)
+	}
+
+	// init the object store of the product images
+	if err := services.InitObjectStore(configData.Media); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to init the object store: %s\n", err)
+		os.Exit(1)
+	}
+
 	router := gin.Default()
 
 	config := cors.DefaultConfig()
 	config.AllowOrigins = configData.AllowedOrigins
 	config.AllowMethods = configData.AllowedMethods
+	config.AddAllowHeaders(constants.CART_TOKEN_HEADER)
 
 	fmt.Printf("CORS configured for: %v\n", configData.AllowedOrigins)
 
@@ -85,6 +104,18 @@ func main() {
 
 	helper.InitiateHelper(*configData)
 
+	// load the JWT signing keys (generated on the first run in the local environment)
+	if err := helper.InitJwtKeys(configData.Jwt); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load JWT keys: %s\n", err)
+		os.Exit(1)
+	}
+
+	// load the key encrypting the TOTP secrets (generated on the first run in the local environment)
+	if err := helper.InitSecretEncryption(configData.TwoFactor.EncryptionKeyFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load the encryption key: %s\n", err)
+		os.Exit(1)
+	}
+
 	router.Use(requestlog.Logger(configData.Logger.Request.LogDir))
 
 	router.Use(compression.Compression())
@@ -99,6 +130,8 @@ func main() {
 
 	router.GET(auth.PublicRoute("/load-data"), configdata.PreLoadDataHandler)
 
+	router.GET(auth.PublicRoute("/.well-known/jwks.json"), auth.JWKSHandler)
+
 	router.Use(
 		ratelimiting.RateLimiter(
 			configData.RateLimit.MaxRequest,
@@ -115,6 +148,15 @@ func main() {
 	// Register all routes for the application
 	registerRoute(router)
 
+	// Register the modules holding personal data (GDPR export and erasure)
+	registerPersonalDataProviders()
+
+	// Register the hooks of the modules on the events of the other modules (e.g. login, product deletion)
+	registerHooks()
+
+	// Schedule the periodic jobs (e.g. purge of the deleted accounts)
+	registerCronJobs()
+
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", configData.Server.Port),
 		Handler: router,
@@ -138,6 +180,8 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	services.StopCronJobs()
+
 	if err := connections.DeInitDB(); err != nil {
 		fmt.Printf("\nClosing connections : %s\n", err)
 	}
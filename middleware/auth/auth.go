@@ -2,23 +2,25 @@
 package auth
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
+	"e-commerce/services"
 	"e-commerce/shared/models"
 
 	"e-commerce/utils/constants"
 	"e-commerce/utils/helper"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 var publicRouteList = map[string]bool{}
 
+// routes open to the guests, authenticated when an access token is sent
+var optionalAuthRouteList = map[string]bool{}
+
 func Auth() gin.HandlerFunc {
 	return authenticate
 }
@@ -38,6 +40,11 @@ func authenticate(context *gin.Context) {
 
 	token := context.GetHeader("Authorization")
 
+	if _, ok := optionalAuthRouteList[path]; ok && token == "" {
+		context.Next()
+		return
+	}
+
 	if token == "" {
 		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
 		context.Abort()
@@ -51,53 +58,94 @@ func authenticate(context *gin.Context) {
 		return
 	}
 
-	jwtToken, err := jwt.ParseWithClaims(tokenParts[1], jwt.MapClaims{}, func(jwtToken *jwt.Token) (any, error) {
-		return []byte(os.Getenv(constants.SECRETE_KEY)), nil
-	})
+	jwtClaims, err := helper.ParseJwt(tokenParts[1])
+	if err != nil || jwtClaims.Scope != constants.ACCESS_TOKEN_SCOPE {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		context.Abort()
+		return
+	}
 
+	userID, err := uuid.Parse(jwtClaims.Subject)
 	if err != nil {
 		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
 		context.Abort()
 		return
 	}
 
-	jwtClaims, ok := jwtToken.Claims.(jwt.MapClaims)
-	if !ok {
-		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+	if jwtClaims.Id == "" || jwtClaims.SessionID == "" || helper.IsAccessTokenDenied(jwtClaims.Id) || helper.IsTokenRevoked(userID.String(), jwtClaims.IssuedAt) {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Session expired, please login again")
 		context.Abort()
 		return
 	}
 
-	if data, ok := jwtClaims[constants.USER_JWT_CLAIM_KEY].(map[string]any); ok {
-		// Convert map to JSON bytes
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			helper.ResponseWriter(context, http.StatusUnauthorized, "User data conversion failed")
-			context.Abort()
-			return
-		}
-
-		// Decode JSON bytes to User struct
-		var userDetails models.User
-		err = json.Unmarshal(jsonData, &userDetails)
-		if err != nil {
-			helper.ResponseWriter(context, http.StatusUnauthorized, "User data conversion failed")
-			context.Abort()
-			return
-		}
-
-		fmt.Printf("Converted to User: %+v\n", userDetails)
-		context.Set(constants.USER_DATA_CONTEXT_KEY, userDetails)
-		context.Next()
-	} else {
-		fmt.Println("Conversion to map[string]any failed.")
-		helper.ResponseWriter(context, http.StatusUnauthorized, "Conversion to map[string]any failed.")
+	// the session (device) must still be active, revoking it logs the device out immediately
+	session, err := services.GetSession(jwtClaims.SessionID)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, "Something went wrong, please try again.")
+		context.Abort()
+		return
+	}
+
+	if session == nil || session.UserID != userID {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Session expired, please login again")
+		context.Abort()
+		return
+	}
+
+	// the user is loaded from the current state, so role changes and deletions apply immediately
+	userDetails, err := services.LoadUser(userID)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, "Something went wrong, please try again.")
+		context.Abort()
+		return
+	}
+
+	if userDetails == nil {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
 		context.Abort()
 		return
 	}
+
+	services.TouchSession(session, context.ClientIP())
+
+	context.Set(constants.USER_DATA_CONTEXT_KEY, *userDetails)
+	context.Set(constants.TOKEN_DATA_CONTEXT_KEY, models.AccessTokenData{
+		TokenID:   jwtClaims.Id,
+		SessionID: jwtClaims.SessionID,
+		ExpiresAt: time.Unix(jwtClaims.ExpiresAt, 0),
+	})
+	context.Next()
 }
 
 func PublicRoute(route string) string {
 	publicRouteList[route] = true
 	return route
 }
+
+// PublicGroupRoute marks a route registered on a router group as public.
+// The full path (group base path + route) is what gin reports for the request,
+// so that is the one stored; the relative route is returned for registration.
+func PublicGroupRoute(group *gin.RouterGroup, route string) string {
+	publicRouteList[group.BasePath()+route] = true
+	return route
+}
+
+// OptionalAuthGroupRoute marks a route registered on a router group as open to the guests.
+// The request is authenticated when it carries an access token (an invalid token is still refused),
+// so the handler finds the logged in user, if any, with helper.GetLoggedInUser.
+func OptionalAuthGroupRoute(group *gin.RouterGroup, route string) string {
+	optionalAuthRouteList[group.BasePath()+route] = true
+	return route
+}
+
+// JWKSHandler godoc
+// @Summary      JSON Web Key Set
+// @Description  Returns the public keys used to verify the JWTs issued by this service, identified by their kid.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  helper.JWKSet  "Public signing keys"
+// @Router       /.well-known/jwks.json [get]
+func JWKSHandler(context *gin.Context) {
+	context.Header("Cache-Control", "public, max-age=300")
+	context.JSON(http.StatusOK, helper.GetJWKS())
+}
@@ -0,0 +1,84 @@
+// Role and permission based access control
+package auth
+
+import (
+	"net/http"
+	"slices"
+
+	"e-commerce/services"
+	"e-commerce/utils/helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole allows the request only if the logged in user has one of the given role codes.
+func RequireRole(roleCodes ...string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		user, ok := helper.GetLoggedInUser(context)
+		if !ok {
+			helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+			context.Abort()
+			return
+		}
+
+		if !slices.Contains(roleCodes, user.Role.Code) {
+			helper.ResponseWriter(context, http.StatusForbidden, "You do not have access to this resource")
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// RequirePermission allows the request only if the role of the logged in user
+// is granted all the given permissions.
+func RequirePermission(permissionCodes ...string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		for _, permissionCode := range permissionCodes {
+			if !HasPermission(context, permissionCode) {
+				helper.ResponseWriter(context, http.StatusForbidden, "You do not have access to this resource")
+				context.Abort()
+				return
+			}
+		}
+
+		context.Next()
+	}
+}
+
+// RequireSelfOrPermission allows the request if the path parameter holds the id of the
+// logged in user (acting on their own record) or if the user is granted the permission.
+func RequireSelfOrPermission(param string, permissionCode string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		user, ok := helper.GetLoggedInUser(context)
+		if !ok {
+			helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+			context.Abort()
+			return
+		}
+
+		if context.Param(param) == user.UserID.String() || HasPermission(context, permissionCode) {
+			context.Next()
+			return
+		}
+
+		helper.ResponseWriter(context, http.StatusForbidden, "You do not have access to this resource")
+		context.Abort()
+	}
+}
+
+// HasPermission reports whether the role of the logged in user is granted the permission.
+func HasPermission(context *gin.Context, permissionCode string) bool {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		return false
+	}
+
+	permissions, err := services.LoadRolePermissions(user.RoleID)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(permissions, permissionCode)
+}
@@ -22,8 +22,10 @@ func RateLimiter(maxRequests int, timeWindow time.Duration, redisClient *redis.C
 		// userIP := context.ClientIP()
 		path := context.FullPath()
 
+		// these endpoints have their own brute force protection (failure counters with
+		// exponential backoff, account lockout and an OTP resend cooldown, see helper/bruteforce.go)
 		switch path {
-		case "/login":
+		case "/login", "/login/2fa":
 			context.Next()
 			return
 		case "/user/verification":
@@ -32,20 +34,25 @@ func RateLimiter(maxRequests int, timeWindow time.Duration, redisClient *redis.C
 		case "/user/resend-verification":
 			context.Next()
 			return
+		case "/user/email-change/confirm":
+			context.Next()
+			return
+		case "/user/me/phone/verification", "/user/me/phone/resend-verification":
+			context.Next()
+			return
+		case "/user/me/reauthentication":
+			context.Next()
+			return
 		}
 
 		var key string
 
-		if path == "/user/register" {
+		userDetails, exists := context.Get(constants.USER_DATA_CONTEXT_KEY)
+		if !exists {
+			// public routes (register, set password etc.) have no logged in user,
+			// so they are limited per client IP
 			key = constants.RATE_LIMIT_PREFIX + context.ClientIP()
 		} else {
-			userDetails, exists := context.Get(constants.USER_DATA_CONTEXT_KEY)
-			if !exists {
-				helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
-				context.Abort()
-				return
-			}
-
 			user, ok := userDetails.(models.User)
 			if !ok {
 				helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
@@ -1,12 +1,37 @@
 package validator
 
 import (
+	"e-commerce/utils/helper"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// the errors name the fields like the clients do (json names)
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// "phone" accepts the numbers helper.NormalizePhone can turn into the E.164 format
+	_ = v.RegisterValidation("phone", func(field validator.FieldLevel) bool {
+		_, err := helper.NormalizePhone(field.Field().String())
+		return err == nil
+	})
+
+	return v
+}
 
 // Generic validation function
 func ValidateStruct(s any) error {
@@ -20,3 +45,18 @@ func ValidateStruct(s any) error {
 	}
 	return nil
 }
+
+// ErrorMessage describes the fields rejected by ValidateStruct, e.g. "invalid email (email), phone (phone)".
+func ErrorMessage(err error) string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err.Error()
+	}
+
+	fields := make([]string, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields = append(fields, fmt.Sprintf("%s (%s)", fieldError.Field(), fieldError.ActualTag()))
+	}
+
+	return "invalid " + strings.Join(fields, ", ")
+}
@@ -0,0 +1,183 @@
+package dbAccess
+
+import (
+	"e-commerce/database/connections"
+
+	"e-commerce/base"
+	"e-commerce/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repo defines a concrete implementation of address-specific repository
+// using the generic BaseRepository from the shared layer.
+type Repo struct {
+	base            base.BaseRepository[models.Address]
+	addressTypeBase base.BaseRepository[models.AddressType]
+}
+
+// NewAddressRepository creates a new instance of the Address repository.
+// Returns:
+// - *Repo: Pointer to a new Repo with injected DB and Redis client.
+func NewAddressRepository() *Repo {
+	return &Repo{
+		base:            *base.NewBaseRepository[models.Address](connections.GetDB(), connections.GetRedisClient()),
+		addressTypeBase: *base.NewBaseRepository[models.AddressType](connections.GetDB(), connections.GetRedisClient()),
+	}
+}
+
+// FindAddressTypes retrieves all the address types ordered by name.
+// Returns:
+// - []models.AddressType: Slice of address types.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindAddressTypes() ([]models.AddressType, error) {
+	addressTypes, _, err := repo.addressTypeBase.FindAll(nil, "name", 0, 0)
+	return addressTypes, err
+}
+
+// GetAddressType retrieves an address type by its id.
+// Parameters:
+// - addressTypeID (uuid.UUID): The address type id.
+// Returns:
+// - *models.AddressType: Pointer to the address type, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetAddressType(addressTypeID uuid.UUID) (*models.AddressType, error) {
+	return repo.addressTypeBase.GetByCondition("address_types.address_type_id = ?", addressTypeID)
+}
+
+// FindByUser retrieves the addresses of the user with their address type,
+// the primary address of every type first.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - []models.Address: The addresses of the user.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindByUser(userID uuid.UUID) ([]models.Address, error) {
+	var addresses []models.Address
+	err := repo.base.DB.Preload("AddressType").
+		Where("addresses.user_id = ?", userID).
+		Order("addresses.address_type_id, addresses.is_primary DESC, addresses.created_at").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// GetUserAddress retrieves an address of the user with its address type.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// - addressID (uuid.UUID): The address id.
+// Returns:
+// - *models.Address: Pointer to the address, or nil if the user has no such address.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetUserAddress(userID uuid.UUID, addressID uuid.UUID) (*models.Address, error) {
+	var addresses []models.Address
+	err := repo.base.DB.Preload("AddressType").
+		Where("addresses.address_id = ? AND addresses.user_id = ?", addressID, userID).
+		Limit(1).
+		Find(&addresses).Error
+	if err != nil || len(addresses) == 0 {
+		return nil, err
+	}
+	return &addresses[0], nil
+}
+
+// Save creates or updates the address, keeping exactly one primary address per type for the user:
+// an address set as primary replaces the previous primary of its type, the first address of a type
+// becomes primary, and when an address moves to another type the type it left gets a new primary.
+// Parameters:
+// - address (*models.Address): Pointer to the address; IsPrimary is updated to the saved value.
+// - previousTypeID (uuid.UUID): The type of the address before the update (uuid.Nil for a new address).
+// Returns:
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) Save(address *models.Address, previousTypeID uuid.UUID) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAddressBook(tx, address.UserID); err != nil {
+			return err
+		}
+
+		if address.IsPrimary {
+			err := tx.Model(&models.Address{}).
+				Where("addresses.user_id = ? AND addresses.address_type_id = ? AND addresses.address_id <> ? AND addresses.is_primary = true",
+					address.UserID, address.AddressTypeID, address.AddressID).
+				Update("is_primary", false).Error
+			if err != nil {
+				return err
+			}
+		} else {
+			var primaries int64
+			err := tx.Model(&models.Address{}).
+				Where("addresses.user_id = ? AND addresses.address_type_id = ? AND addresses.address_id <> ? AND addresses.is_primary = true",
+					address.UserID, address.AddressTypeID, address.AddressID).
+				Count(&primaries).Error
+			if err != nil {
+				return err
+			}
+			address.IsPrimary = primaries == 0
+		}
+
+		if err := tx.Omit(clause.Associations).Save(address).Error; err != nil {
+			return err
+		}
+
+		if previousTypeID != uuid.Nil && previousTypeID != address.AddressTypeID {
+			return ensurePrimary(tx, address.UserID, previousTypeID)
+		}
+
+		return nil
+	})
+}
+
+// Delete soft-deletes the address; if it was the primary address of its type,
+// the most recent remaining address of the type becomes primary.
+// Parameters:
+// - address (*models.Address): Pointer to the address to delete.
+// Returns:
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) Delete(address *models.Address) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAddressBook(tx, address.UserID); err != nil {
+			return err
+		}
+
+		// the primary flag is cleared so it does not count against the unique primary index
+		err := tx.Model(&models.Address{}).
+			Where("addresses.address_id = ?", address.AddressID).
+			Updates(map[string]any{"is_primary": false, "deleted_at": gorm.Expr("NOW()")}).Error
+		if err != nil {
+			return err
+		}
+
+		if address.IsPrimary {
+			return ensurePrimary(tx, address.UserID, address.AddressTypeID)
+		}
+
+		return nil
+	})
+}
+
+// lockAddressBook locks the user row until the end of the transaction,
+// so concurrent changes of the addresses of the user are applied one after the other.
+func lockAddressBook(tx *gorm.DB, userID uuid.UUID) error {
+	var users []models.User
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("users.user_id").
+		Where("users.user_id = ?", userID).
+		Find(&users).Error
+}
+
+// ensurePrimary makes the most recent address of the type primary if the type has addresses but no primary one.
+func ensurePrimary(tx *gorm.DB, userID uuid.UUID, addressTypeID uuid.UUID) error {
+	var addresses []models.Address
+	err := tx.Where("addresses.user_id = ? AND addresses.address_type_id = ?", userID, addressTypeID).
+		Order("addresses.is_primary DESC, addresses.created_at DESC").
+		Limit(1).
+		Find(&addresses).Error
+	if err != nil || len(addresses) == 0 || addresses[0].IsPrimary {
+		return err
+	}
+
+	return tx.Model(&models.Address{}).
+		Where("addresses.address_id = ?", addresses[0].AddressID).
+		Update("is_primary", true).Error
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/modules/address_management/service"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewAddressHandler() *Handler {
+	service := service.NewAddressService()
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetAddressTypes godoc
+// @Summary      Get Address Types
+// @Description  Returns the types an address can be saved as (e.g. home, billing, shipping).
+// @Tags         Addresses
+// @Produce      json
+// @Success      200  {array}   models.AddressTypeResponse  "List of address types"
+// @Failure      401  {object}  models.UnauthorizedError    "Unauthorized access attempt"
+// @Failure      500  {object}  models.InternalServerError  "Internal server error"
+// @Router       /address/types [get]
+func (handler *Handler) GetAddressTypes(context *gin.Context) {
+	addressTypes, err := handler.service.GetAddressTypes()
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, addressTypes)
+}
+
+// GetAddresses godoc
+// @Summary      Get My Addresses
+// @Description  Returns the address book of the logged in user, the primary address of every type first.
+// @Tags         Addresses
+// @Produce      json
+// @Success      200  {array}   models.AddressResponse      "List of addresses"
+// @Failure      401  {object}  models.UnauthorizedError    "Unauthorized access attempt"
+// @Failure      500  {object}  models.InternalServerError  "Internal server error"
+// @Router       /address [get]
+func (handler *Handler) GetAddresses(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	addresses, err := handler.service.GetAddresses(user)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, addresses)
+}
+
+// GetAddress godoc
+// @Summary      Get an Address
+// @Description  Returns an address of the logged in user.
+// @Tags         Addresses
+// @Produce      json
+// @Param        id   path      string                    true  "Address ID"
+// @Success      200  {object}  models.AddressResponse    "Address"
+// @Failure      400  {object}  models.BadRequestError    "Invalid ID or address not found"
+// @Failure      401  {object}  models.UnauthorizedError  "Unauthorized access attempt"
+// @Router       /address/{id} [get]
+func (handler *Handler) GetAddress(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	address, err := handler.service.GetAddress(user, context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, address)
+}
+
+// AddAddress godoc
+// @Summary      Add an Address
+// @Description  Adds an address to the address book of the logged in user. The country is an ISO 3166-1 alpha-2 code and the postal code must match the format of the country; it is stored in its canonical form. The first address of a type becomes primary, and an address added as primary replaces the previous primary address of its type.
+// @Tags         Addresses
+// @Accept       json
+// @Produce      json
+// @Param        address  body      models.AddressRequest     true  "Address details"
+// @Success      200      {object}  models.AddressResponse    "Address created"
+// @Failure      400      {object}  models.BadRequestError    "Invalid input, postal code or address type"
+// @Failure      401      {object}  models.UnauthorizedError  "Unauthorized access attempt"
+// @Router       /address [post]
+func (handler *Handler) AddAddress(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	request, ok := bindAddressRequest(context)
+	if !ok {
+		return
+	}
+
+	address, err := handler.service.AddAddress(user, request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, address)
+}
+
+// UpdateAddress godoc
+// @Summary      Update an Address
+// @Description  Replaces the details of an address of the logged in user. The primary address of a type can not be unset, set another address of the type as primary instead.
+// @Tags         Addresses
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "Address ID"
+// @Param        address  body      models.AddressRequest     true  "Address details"
+// @Success      200      {object}  models.AddressResponse    "Address updated"
+// @Failure      400      {object}  models.BadRequestError    "Invalid input or address not found"
+// @Failure      401      {object}  models.UnauthorizedError  "Unauthorized access attempt"
+// @Router       /address/{id} [put]
+func (handler *Handler) UpdateAddress(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	request, ok := bindAddressRequest(context)
+	if !ok {
+		return
+	}
+
+	address, err := handler.service.UpdateAddress(user, context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, address)
+}
+
+// SetPrimaryAddress godoc
+// @Summary      Set the Primary Address
+// @Description  Makes the address the primary address of its type for the logged in user.
+// @Tags         Addresses
+// @Produce      json
+// @Param        id   path      string                    true  "Address ID"
+// @Success      200  {object}  models.AddressResponse    "Address set as primary"
+// @Failure      400  {object}  models.BadRequestError    "Invalid ID or address not found"
+// @Failure      401  {object}  models.UnauthorizedError  "Unauthorized access attempt"
+// @Router       /address/{id}/primary [post]
+func (handler *Handler) SetPrimaryAddress(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	address, err := handler.service.SetPrimaryAddress(user, context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, address)
+}
+
+// DeleteAddress godoc
+// @Summary      Delete an Address
+// @Description  Deletes an address of the logged in user. If it was the primary address of its type, the most recent remaining address of the type becomes primary.
+// @Tags         Addresses
+// @Produce      json
+// @Param        id   path      string                          true  "Address ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Address deleted"
+// @Failure      400  {object}  models.BadRequestError          "Invalid ID or address not found"
+// @Failure      401  {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Router       /address/{id} [delete]
+func (handler *Handler) DeleteAddress(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	message, err := handler.service.DeleteAddress(user, context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// bindAddressRequest reads and validates the address of the request body, answering 400 if it is invalid.
+func bindAddressRequest(context *gin.Context) (models.AddressRequest, bool) {
+	var request models.AddressRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid address request data.")
+		return request, false
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, validator.ErrorMessage(err))
+		return request, false
+	}
+
+	return request, true
+}
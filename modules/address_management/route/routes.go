@@ -0,0 +1,30 @@
+package route
+
+import (
+	"e-commerce/modules/address_management/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AddressManagementRoutes(router *gin.Engine) {
+	handler := handler.NewAddressHandler()
+
+	{
+		address := router.Group("/address")
+
+		address.GET("/types", handler.GetAddressTypes)
+
+		address.GET("", handler.GetAddresses)
+
+		address.POST("", handler.AddAddress)
+
+		address.GET("/:id", handler.GetAddress)
+
+		address.PUT("/:id", handler.UpdateAddress)
+
+		address.DELETE("/:id", handler.DeleteAddress)
+
+		address.POST("/:id/primary", handler.SetPrimaryAddress)
+	}
+
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"e-commerce/modules/address_management/dbAccess"
+	"e-commerce/shared/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// addressData is an address in the "addresses" section of the personal data export.
+type addressData struct {
+	AddressID   uuid.UUID `json:"address_id"`
+	AddressType string    `json:"address_type"`
+	Street      string    `json:"street"`
+	Street2     string    `json:"street2,omitempty"`
+	City        string    `json:"city"`
+	State       string    `json:"state"`
+	PostalCode  string    `json:"postal_code"`
+	Country     string    `json:"country"`
+	IsPrimary   bool      `json:"is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AddressDataProvider contributes the addresses of the user to the personal data registry.
+type AddressDataProvider struct {
+	repo *dbAccess.Repo
+}
+
+// NewAddressDataProvider creates the personal data provider of the addresses.
+func NewAddressDataProvider() *AddressDataProvider {
+	return &AddressDataProvider{repo: dbAccess.NewAddressRepository()}
+}
+
+func (provider *AddressDataProvider) Name() string {
+	return "addresses"
+}
+
+func (provider *AddressDataProvider) Export(userID uuid.UUID) (any, error) {
+	addresses, err := provider.repo.FindByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]addressData, 0, len(addresses))
+	for _, address := range addresses {
+		data = append(data, addressData{
+			AddressID:   address.AddressID,
+			AddressType: address.AddressType.Name,
+			Street:      address.Street,
+			Street2:     address.Street2,
+			City:        address.City,
+			State:       address.State,
+			PostalCode:  address.PostalCode,
+			Country:     address.Country,
+			IsPrimary:   address.IsPrimary,
+			CreatedAt:   address.CreatedAt,
+		})
+	}
+
+	return data, nil
+}
+
+// Anonymise blanks the addresses of the user. The rows are kept (soft-deleted) because orders
+// may reference them; the country is kept for the tax records.
+func (provider *AddressDataProvider) Anonymise(tx *gorm.DB, userID uuid.UUID) error {
+	record := map[string]any{
+		"street":      "",
+		"street2":     "",
+		"city":        "",
+		"state":       "",
+		"postal_code": "",
+		"is_primary":  false,
+		"deleted_at":  gorm.Expr("COALESCE(addresses.deleted_at, NOW())"),
+	}
+
+	return tx.Unscoped().Model(&models.Address{}).Where("addresses.user_id = ?", userID).Updates(record).Error
+}
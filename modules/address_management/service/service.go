@@ -0,0 +1,291 @@
+package service
+
+import (
+	"e-commerce/modules/address_management/dbAccess"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Service provides the address book of the users: listing, creating, updating
+// and deleting addresses while keeping exactly one primary address per address type.
+type Service struct {
+	repo *dbAccess.Repo
+}
+
+// NewAddressService creates and returns a new Address Service instance by initializing the repository.
+// Returns:
+//
+//	*Service: A pointer to a new Service instance with its repository initialized.
+func NewAddressService() *Service {
+	repo := dbAccess.NewAddressRepository()
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetAddressTypes returns the address types an address can be saved as (e.g. home, billing, shipping).
+//
+// Returns:
+//
+//	[]models.AddressTypeResponse: The list of address types.
+//	error: An error if the query fails.
+func (service *Service) GetAddressTypes() ([]models.AddressTypeResponse, error) {
+	addressTypes, err := service.repo.FindAddressTypes()
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	response := make([]models.AddressTypeResponse, 0, len(addressTypes))
+	for _, addressType := range addressTypes {
+		response = append(response, addressType.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// GetAddresses returns the addresses of the user grouped by type, the primary address of every type first.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//
+// Returns:
+//
+//	[]models.AddressResponse: The addresses of the user.
+//	error: An error if the query fails.
+func (service *Service) GetAddresses(user models.User) ([]models.AddressResponse, error) {
+	addresses, err := service.repo.FindByUser(user.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	response := make([]models.AddressResponse, 0, len(addresses))
+	for _, address := range addresses {
+		response = append(response, address.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// GetAddress returns an address of the user.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	id (string): The address id (uuid).
+//
+// Returns:
+//
+//	models.AddressResponse: The address.
+//	error: An error if the user has no address with this id.
+func (service *Service) GetAddress(user models.User, id string) (models.AddressResponse, error) {
+	address, err := service.getAddress(user, id)
+	if err != nil {
+		return models.AddressResponse{}, err
+	}
+
+	return address.ResponseObj(), nil
+}
+
+// AddAddress adds an address to the address book of the user.
+// The first address of a type becomes the primary address of the type,
+// and an address added as primary replaces the previous primary address of its type.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	request (models.AddressRequest): The address details.
+//
+// Returns:
+//
+//	models.AddressResponse: The created address.
+//	error: An error if the address type is unknown, the postal code is invalid or the creation fails.
+func (service *Service) AddAddress(user models.User, request models.AddressRequest) (models.AddressResponse, error) {
+	address := models.Address{UserID: user.UserID}
+
+	if err := service.applyRequest(&address, request); err != nil {
+		return models.AddressResponse{}, err
+	}
+
+	if err := service.repo.Save(&address, uuid.Nil); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.AddressResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.AddressResponse{}, err
+	}
+
+	return address.ResponseObj(), nil
+}
+
+// UpdateAddress replaces the details of an address of the user.
+// The primary address of a type can only be changed by making another address primary,
+// so is_primary false is refused for the current primary address unless it moves to another type,
+// in which case the type it leaves gets a new primary address.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	id (string): The address id (uuid).
+//	request (models.AddressRequest): The new address details.
+//
+// Returns:
+//
+//	models.AddressResponse: The updated address.
+//	error: An error if the address is not found, the request is invalid or the update fails.
+func (service *Service) UpdateAddress(user models.User, id string, request models.AddressRequest) (models.AddressResponse, error) {
+	address, err := service.getAddress(user, id)
+	if err != nil {
+		return models.AddressResponse{}, err
+	}
+
+	previousTypeID := address.AddressTypeID
+
+	if address.IsPrimary && !request.IsPrimary && request.AddressTypeID == previousTypeID {
+		return models.AddressResponse{}, fmt.Errorf("the primary address can not be unset, set another address of the type as primary instead")
+	}
+
+	if err := service.applyRequest(address, request); err != nil {
+		return models.AddressResponse{}, err
+	}
+
+	if err := service.repo.Save(address, previousTypeID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.AddressResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.AddressResponse{}, err
+	}
+
+	return address.ResponseObj(), nil
+}
+
+// SetPrimaryAddress makes the address the primary address of its type.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	id (string): The address id (uuid).
+//
+// Returns:
+//
+//	models.AddressResponse: The address.
+//	error: An error if the address is not found or the update fails.
+func (service *Service) SetPrimaryAddress(user models.User, id string) (models.AddressResponse, error) {
+	address, err := service.getAddress(user, id)
+	if err != nil {
+		return models.AddressResponse{}, err
+	}
+
+	if address.IsPrimary {
+		return address.ResponseObj(), nil
+	}
+
+	address.IsPrimary = true
+
+	if err := service.repo.Save(address, address.AddressTypeID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.AddressResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.AddressResponse{}, err
+	}
+
+	return address.ResponseObj(), nil
+}
+
+// DeleteAddress deletes an address of the user.
+// If it was the primary address of its type, the most recent remaining address of the type becomes primary.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	id (string): The address id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the address is not found or the deletion fails.
+func (service *Service) DeleteAddress(user models.User, id string) (string, error) {
+	address, err := service.getAddress(user, id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.repo.Delete(address); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Address deleted successfully.", nil
+}
+
+// getAddress parses the id and loads the address of the user.
+func (service *Service) getAddress(user models.User, id string) (*models.Address, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	address, err := service.repo.GetUserAddress(user.UserID, parsedUUID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if address == nil {
+		return nil, fmt.Errorf("no address found with id = %s", parsedUUID)
+	}
+
+	return address, nil
+}
+
+// applyRequest copies the request to the address, checking the address type
+// and normalising the country and the postal code.
+func (service *Service) applyRequest(address *models.Address, request models.AddressRequest) error {
+	country := strings.ToUpper(strings.TrimSpace(request.Country))
+
+	postalCode, err := helper.NormalizePostalCode(country, request.PostalCode)
+	if err != nil {
+		return err
+	}
+
+	if address.AddressType.AddressTypeID != request.AddressTypeID {
+		addressType, err := service.repo.GetAddressType(request.AddressTypeID)
+		if err != nil {
+			if pgErr, ok := err.(*pq.Error); ok {
+				return fmt.Errorf(pgErr.Detail)
+			}
+			return err
+		}
+
+		if addressType == nil {
+			return fmt.Errorf("no address type found with id = %s", request.AddressTypeID)
+		}
+
+		address.AddressType = *addressType
+	}
+
+	address.AddressTypeID = request.AddressTypeID
+	address.Street = strings.TrimSpace(request.Street)
+	address.Street2 = strings.TrimSpace(request.Street2)
+	address.City = strings.TrimSpace(request.City)
+	address.State = strings.TrimSpace(request.State)
+	address.PostalCode = postalCode
+	address.Country = country
+	address.IsPrimary = request.IsPrimary
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package dbAccess
+
+import (
+	"context"
+	"e-commerce/database/connections"
+	"e-commerce/utils/constants"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"e-commerce/base"
+	"e-commerce/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// guestCartRetries is how many times a change of a guest cart is tried when the cart is changed concurrently.
+const guestCartRetries = 5
+
+// ErrGuestCartConflict is returned when a guest cart keeps being changed concurrently while it is updated.
+var ErrGuestCartConflict = errors.New("the cart is being changed by another request, please try again")
+
+// Repo defines a concrete implementation of cart-specific repository
+// using the generic BaseRepository from the shared layer.
+type Repo struct {
+	base        base.BaseRepository[models.Cart]
+	variantBase base.BaseRepository[models.ProductVariant]
+	productBase base.BaseRepository[models.Product]
+}
+
+// NewCartRepository creates a new instance of the Cart repository.
+// Returns:
+// - *Repo: Pointer to a new Repo with injected DB and Redis client.
+func NewCartRepository() *Repo {
+	return &Repo{
+		base:        *base.NewBaseRepository[models.Cart](connections.GetDB(), connections.GetRedisClient()),
+		variantBase: *base.NewBaseRepository[models.ProductVariant](connections.GetDB(), connections.GetRedisClient()),
+		productBase: *base.NewBaseRepository[models.Product](connections.GetDB(), connections.GetRedisClient()),
+	}
+}
+
+// GetCartByUser retrieves the cart of a user with its items in the order they were added.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - *models.Cart: Pointer to the cart, or nil if the user has no cart.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetCartByUser(userID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	err := repo.base.DB.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("cart_items.created_at, cart_items.variant_id")
+	}).Where("carts.user_id = ?", userID).First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &cart, err
+}
+
+// UpdateCartItems changes the items of the cart of a user, creating the cart if needed, in one transaction.
+// The cart row is locked while the change is computed and saved, so the concurrent changes of the cart
+// (e.g. an item added while the guest cart is merged at login) are applied one after the other.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// - change (func([]models.CartItem) ([]models.CartItem, error)): Returns the new items from the current ones,
+// in the order they were added.
+// Returns:
+// - error: The error of the change, or any other error that occurred, in which case the cart is not changed.
+func (repo Repo) UpdateCartItems(userID uuid.UUID, change func(items []models.CartItem) ([]models.CartItem, error)) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		cart := models.Cart{UserID: userID}
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at"}),
+		}).Create(&cart).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("carts.user_id = ?", userID).
+			Take(&cart).Error; err != nil {
+			return err
+		}
+
+		var items []models.CartItem
+		if err := tx.Where("cart_items.cart_id = ?", cart.CartID).
+			Order("cart_items.created_at, cart_items.variant_id").
+			Find(&items).Error; err != nil {
+			return err
+		}
+
+		items, err := change(items)
+		if err != nil {
+			return err
+		}
+
+		variantIDs := make([]uuid.UUID, 0, len(items))
+		for i := range items {
+			items[i].CartID = cart.CartID
+			variantIDs = append(variantIDs, items[i].VariantID)
+		}
+
+		removed := tx.Where("cart_items.cart_id = ?", cart.CartID)
+		if len(variantIDs) > 0 {
+			removed = removed.Where("cart_items.variant_id NOT IN ?", variantIDs)
+		}
+		if err := removed.Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cart_id"}, {Name: "variant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(&items).Error
+	})
+}
+
+// DeleteCart permanently deletes the cart of a user, its items being deleted in cascade.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - error: Error if any occurred during deletion.
+func (repo Repo) DeleteCart(userID uuid.UUID) error {
+	return repo.base.DB.Where("carts.user_id = ?", userID).Delete(&models.Cart{}).Error
+}
+
+// GetGuestCartItems retrieves the items of a guest cart from Redis.
+// Parameters:
+// - tokenHash (string): The hash of the cart token.
+// Returns:
+// - []models.CartItem: The items of the cart, nil if the cart does not exist or has expired.
+// - error: Error if any occurred while reading the cart.
+func (repo Repo) GetGuestCartItems(tokenHash string) ([]models.CartItem, error) {
+	return decodeGuestCart(repo.base.RedisClient.Get(context.Background(), constants.GUEST_CART_PREFIX+tokenHash))
+}
+
+// UpdateGuestCartItems changes the items of a guest cart in a Redis optimistic transaction (WATCH/MULTI):
+// the cart is watched while the change is computed and only stored if it has not changed meanwhile,
+// otherwise the change is computed again on the new items, so no concurrent change is lost.
+// Parameters:
+// - tokenHash (string): The hash of the cart token.
+// - ttl (time.Duration): How long the cart is kept from now.
+// - change (func([]models.CartItem) ([]models.CartItem, error)): Returns the new items from the current ones
+// (nil if the cart does not exist); it may be called more than once.
+// Returns:
+// - error: The error of the change, ErrGuestCartConflict if the cart kept changing, or any other error
+// that occurred, in which case the cart is not changed.
+func (repo Repo) UpdateGuestCartItems(tokenHash string, ttl time.Duration, change func(items []models.CartItem) ([]models.CartItem, error)) error {
+	ctx := context.Background()
+	key := constants.GUEST_CART_PREFIX + tokenHash
+
+	update := func(tx *redis.Tx) error {
+		items, err := decodeGuestCart(tx.Get(ctx, key))
+		if err != nil {
+			return err
+		}
+
+		items, err = change(items)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < guestCartRetries; attempt++ {
+		err := repo.base.RedisClient.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return ErrGuestCartConflict
+}
+
+// TakeGuestCartItems reads and deletes a guest cart at once (GETDEL), so the items can be moved
+// to another cart without a concurrent change of the guest cart being lost in between.
+// Parameters:
+// - tokenHash (string): The hash of the cart token.
+// Returns:
+// - []models.CartItem: The items of the cart, nil if the cart does not exist or has expired.
+// - error: Error if any occurred while reading the cart.
+func (repo Repo) TakeGuestCartItems(tokenHash string) ([]models.CartItem, error) {
+	return decodeGuestCart(repo.base.RedisClient.GetDel(context.Background(), constants.GUEST_CART_PREFIX+tokenHash))
+}
+
+// SaveGuestCartItems stores the items of a guest cart in Redis, for ttl from now.
+// Parameters:
+// - tokenHash (string): The hash of the cart token.
+// - items ([]models.CartItem): The items of the cart.
+// - ttl (time.Duration): How long the cart is kept.
+// Returns:
+// - error: Error if any occurred while storing the cart.
+func (repo Repo) SaveGuestCartItems(tokenHash string, items []models.CartItem, ttl time.Duration) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return repo.base.RedisClient.Set(context.Background(), constants.GUEST_CART_PREFIX+tokenHash, data, ttl).Err()
+}
+
+// decodeGuestCart decodes the items of a guest cart read from Redis, nil if the cart does not exist.
+func decodeGuestCart(cmd *redis.StringCmd) ([]models.CartItem, error) {
+	data, err := cmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.CartItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteGuestCart deletes a guest cart from Redis.
+// Parameters:
+// - tokenHash (string): The hash of the cart token.
+// Returns:
+// - error: Error if any occurred during deletion.
+func (repo Repo) DeleteGuestCart(tokenHash string) error {
+	return repo.base.RedisClient.Del(context.Background(), constants.GUEST_CART_PREFIX+tokenHash).Err()
+}
+
+// FindVariants retrieves the variants with the given ids, the deleted ones excluded.
+// Parameters:
+// - variantIDs ([]uuid.UUID): The variant ids.
+// Returns:
+// - []models.ProductVariant: Slice of the variants found.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindVariants(variantIDs []uuid.UUID) ([]models.ProductVariant, error) {
+	return repo.variantBase.FindAllByCondition("product_variants.variant_id IN ?", variantIDs)
+}
+
+// FindProducts retrieves the products with the given ids, the deleted ones excluded.
+// Parameters:
+// - productIDs ([]uuid.UUID): The product ids.
+// Returns:
+// - []models.Product: Slice of the products found.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindProducts(productIDs []uuid.UUID) ([]models.Product, error) {
+	return repo.productBase.FindAllByCondition("products.product_id IN ?", productIDs)
+}
+
+// FindAvailableStock sums the available stock (on hand minus reserved) of the variants over the active warehouses.
+// Parameters:
+// - variantIDs ([]uuid.UUID): The variant ids.
+// Returns:
+// - map[uuid.UUID]int64: The available stock by variant id, missing for the variants without stock.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindAvailableStock(variantIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		VariantID uuid.UUID
+		Available int64
+	}
+
+	err := repo.base.DB.Model(&models.StockLevel{}).
+		Select("stock_levels.variant_id, SUM(stock_levels.on_hand - stock_levels.reserved) AS available").
+		Joins("JOIN warehouses ON warehouses.warehouse_id = stock_levels.warehouse_id AND warehouses.is_active AND warehouses.deleted_at IS NULL").
+		Where("stock_levels.variant_id IN ?", variantIDs).
+		Group("stock_levels.variant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		available[row.VariantID] = row.Available
+	}
+	return available, nil
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/modules/cart/service"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewCartHandler() *Handler {
+	service := service.NewCartService()
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetCart godoc
+// @Summary      Get the Cart
+// @Description  Returns the cart of the logged-in user, or the guest cart of the X-Cart-Token header, priced with the current prices and checked against the current stock. Only the available lines count in the subtotal.
+// @Tags         Cart
+// @Produce      json
+// @Param        X-Cart-Token  header    string                                       false  "Token of the guest cart"
+// @Success      200           {object}  models.SuccessResponse[models.CartResponse]  "Cart"
+// @Failure      400           {object}  models.BadRequestError                       "Cart can not be loaded"
+// @Failure      401           {object}  models.UnauthorizedError                     "Invalid access token"
+// @Router       /cart [get]
+func (handler *Handler) GetCart(context *gin.Context) {
+	userID, token := cartOwner(context)
+
+	cart, err := handler.service.GetCart(userID, token)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, cart)
+}
+
+// AddItem godoc
+// @Summary      Add a Product to the Cart
+// @Description  Adds a quantity of a variant to the cart, checking the product is on sale and in stock. A guest without a cart gets a new cart: keep its cart_token and send it in the X-Cart-Token header, and in the cart_token field of the login to move the items to the cart of the account.
+// @Tags         Cart
+// @Accept       json
+// @Produce      json
+// @Param        X-Cart-Token  header    string                                       false  "Token of the guest cart"
+// @Param        request       body      models.CartItemRequest                       true   "Variant and quantity"
+// @Success      200           {object}  models.SuccessResponse[models.CartResponse]  "Cart after the change"
+// @Failure      400           {object}  models.BadRequestError                       "Invalid request data, product not available, insufficient stock or cart full"
+// @Failure      401           {object}  models.UnauthorizedError                     "Invalid access token"
+// @Router       /cart/items [post]
+func (handler *Handler) AddItem(context *gin.Context) {
+	request := models.CartItemRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	userID, token := cartOwner(context)
+
+	cart, err := handler.service.AddItem(userID, token, request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, cart)
+}
+
+// UpdateItem godoc
+// @Summary      Change the Quantity of a Cart Line
+// @Description  Sets the quantity of a variant in the cart, checking the product is on sale and in stock.
+// @Tags         Cart
+// @Accept       json
+// @Produce      json
+// @Param        X-Cart-Token  header    string                                       false  "Token of the guest cart"
+// @Param        variantId     path      string                                       true   "Variant ID"
+// @Param        request       body      models.CartQuantityRequest                   true   "New quantity"
+// @Success      200           {object}  models.SuccessResponse[models.CartResponse]  "Cart after the change"
+// @Failure      400           {object}  models.BadRequestError                       "Invalid request data, product not in the cart, not available or insufficient stock"
+// @Failure      401           {object}  models.UnauthorizedError                     "Invalid access token"
+// @Router       /cart/items/{variantId} [put]
+func (handler *Handler) UpdateItem(context *gin.Context) {
+	request := models.CartQuantityRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	userID, token := cartOwner(context)
+
+	cart, err := handler.service.UpdateItem(userID, token, context.Param("variantId"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, cart)
+}
+
+// RemoveItem godoc
+// @Summary      Remove a Product from the Cart
+// @Description  Removes the line of a variant from the cart.
+// @Tags         Cart
+// @Produce      json
+// @Param        X-Cart-Token  header    string                                       false  "Token of the guest cart"
+// @Param        variantId     path      string                                       true   "Variant ID"
+// @Success      200           {object}  models.SuccessResponse[models.CartResponse]  "Cart after the change"
+// @Failure      400           {object}  models.BadRequestError                       "Invalid ID or product not in the cart"
+// @Failure      401           {object}  models.UnauthorizedError                     "Invalid access token"
+// @Router       /cart/items/{variantId} [delete]
+func (handler *Handler) RemoveItem(context *gin.Context) {
+	userID, token := cartOwner(context)
+
+	cart, err := handler.service.RemoveItem(userID, token, context.Param("variantId"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, cart)
+}
+
+// ClearCart godoc
+// @Summary      Empty the Cart
+// @Description  Deletes all the lines of the cart.
+// @Tags         Cart
+// @Produce      json
+// @Param        X-Cart-Token  header    string                          false  "Token of the guest cart"
+// @Success      200           {object}  models.SuccessResponse[string]  "Cart cleared"
+// @Failure      400           {object}  models.BadRequestError          "Cart can not be deleted"
+// @Failure      401           {object}  models.UnauthorizedError        "Invalid access token"
+// @Router       /cart [delete]
+func (handler *Handler) ClearCart(context *gin.Context) {
+	userID, token := cartOwner(context)
+
+	message, err := handler.service.ClearCart(userID, token)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// cartOwner returns the logged-in user, or nil and the token of the guest cart for a guest.
+func cartOwner(context *gin.Context) (*uuid.UUID, string) {
+	if user, ok := helper.GetLoggedInUser(context); ok {
+		return &user.UserID, ""
+	}
+	return nil, context.GetHeader(constants.CART_TOKEN_HEADER)
+}
+
+// bindRequest reads and validates the JSON body of the request, answering 400 if it is invalid.
+func bindRequest(context *gin.Context, request any) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return false
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, validator.ErrorMessage(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,26 @@
+package route
+
+import (
+	"e-commerce/middleware/auth"
+	"e-commerce/modules/cart/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CartRoutes(router *gin.Engine) {
+	handler := handler.NewCartHandler()
+
+	// the guests use the cart of their X-Cart-Token header, the logged-in users their own cart
+	cart := router.Group("/cart")
+
+	cart.GET(auth.OptionalAuthGroupRoute(cart, ""), handler.GetCart)
+
+	cart.DELETE(auth.OptionalAuthGroupRoute(cart, ""), handler.ClearCart)
+
+	cart.POST(auth.OptionalAuthGroupRoute(cart, "/items"), handler.AddItem)
+
+	cart.PUT(auth.OptionalAuthGroupRoute(cart, "/items/:variantId"), handler.UpdateItem)
+
+	cart.DELETE(auth.OptionalAuthGroupRoute(cart, "/items/:variantId"), handler.RemoveItem)
+
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"e-commerce/modules/cart/dbAccess"
+	"e-commerce/shared/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// cartItemData is a line in the "cart" section of the personal data export.
+type cartItemData struct {
+	VariantID uuid.UUID `json:"variant_id"`
+	SKU       string    `json:"sku,omitempty"`
+	Quantity  int64     `json:"quantity"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// CartDataProvider contributes the cart of the user to the personal data registry.
+type CartDataProvider struct {
+	repo *dbAccess.Repo
+}
+
+// NewCartDataProvider creates the personal data provider of the carts.
+func NewCartDataProvider() *CartDataProvider {
+	return &CartDataProvider{repo: dbAccess.NewCartRepository()}
+}
+
+func (provider *CartDataProvider) Name() string {
+	return "cart"
+}
+
+func (provider *CartDataProvider) Export(userID uuid.UUID) (any, error) {
+	cart, err := provider.repo.GetCartByUser(userID)
+	if err != nil || cart == nil || len(cart.Items) == 0 {
+		return nil, err
+	}
+
+	variantIDs := make([]uuid.UUID, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+
+	variants, err := provider.repo.FindVariants(variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	skus := make(map[uuid.UUID]string, len(variants))
+	for _, variant := range variants {
+		skus[variant.VariantID] = variant.SKU
+	}
+
+	data := make([]cartItemData, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		data = append(data, cartItemData{
+			VariantID: item.VariantID,
+			SKU:       skus[item.VariantID],
+			Quantity:  item.Quantity,
+			AddedAt:   item.CreatedAt,
+		})
+	}
+
+	return data, nil
+}
+
+// Anonymise deletes the cart of the user, nothing else refers to it.
+func (provider *CartDataProvider) Anonymise(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Where("carts.user_id = ?", userID).Delete(&models.Cart{}).Error
+}
@@ -0,0 +1,528 @@
+package service
+
+import (
+	"e-commerce/modules/cart/dbAccess"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Service provides the shopping carts: the guest carts, kept in Redis under a cart token, and the carts
+// of the logged-in users, kept in Postgres. The carts hold quantities of variants only; the prices and the
+// stock are read from the catalog and the inventory whenever a cart is loaded, so the totals are always current.
+type Service struct {
+	repo *dbAccess.Repo
+}
+
+// cartOwner identifies a cart: the cart of a logged-in user, or else the guest cart of a cart token.
+type cartOwner struct {
+	userID *uuid.UUID
+	token  string
+}
+
+// catalogLine is the current state of a variant in the catalog and in the inventory.
+type catalogLine struct {
+	variant   models.ProductVariant
+	product   models.Product
+	available int64
+}
+
+// NewCartService creates and returns a new Cart Service instance by initializing the repository.
+// Returns:
+//
+//	*Service: A pointer to a new Service instance with its repository initialized.
+func NewCartService() *Service {
+	repo := dbAccess.NewCartRepository()
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetCart returns the cart of the logged-in user, or the guest cart of the cart token,
+// priced with the current prices and checked against the current stock.
+//
+// Parameters:
+//
+//	userID (*uuid.UUID): The logged-in user, nil for a guest.
+//	token (string): The token of the guest cart, ignored for a logged-in user.
+//
+// Returns:
+//
+//	models.CartResponse: The cart, empty if there is none yet.
+//	error: An error if the cart can not be loaded.
+func (service *Service) GetCart(userID *uuid.UUID, token string) (models.CartResponse, error) {
+	owner := cartOwner{userID: userID, token: token}
+
+	items, err := service.loadItems(owner)
+	if err != nil {
+		return models.CartResponse{}, err
+	}
+
+	// an unknown or expired token is not returned, a new one is issued with the first item
+	if items == nil {
+		owner.token = ""
+	}
+
+	return service.cartResponse(owner, items)
+}
+
+// AddItem adds a quantity of a variant to the cart, the quantity being added to the line of the variant if any.
+// A guest without a cart gets a new cart, whose token is returned in the response.
+//
+// Parameters:
+//
+//	userID (*uuid.UUID): The logged-in user, nil for a guest.
+//	token (string): The token of the guest cart, ignored for a logged-in user.
+//	request (models.CartItemRequest): The variant and the quantity.
+//
+// Returns:
+//
+//	models.CartResponse: The cart after the change.
+//	error: An error if the product is not available, the stock is insufficient or the cart is full.
+func (service *Service) AddItem(userID *uuid.UUID, token string, request models.CartItemRequest) (models.CartResponse, error) {
+	owner := cartOwner{userID: userID, token: token}
+
+	// a guest with an unknown or expired token gets a new cart
+	if owner.userID == nil {
+		items, err := service.loadItems(owner)
+		if err != nil {
+			return models.CartResponse{}, err
+		}
+
+		if items == nil {
+			if owner.token, err = helper.GenerateSecureToken(); err != nil {
+				return models.CartResponse{}, err
+			}
+		}
+	}
+
+	var response models.CartResponse
+
+	err := service.updateItems(owner, func(items []models.CartItem) ([]models.CartItem, error) {
+		index := slices.IndexFunc(items, func(item models.CartItem) bool { return item.VariantID == request.VariantID })
+		if index < 0 {
+			if len(items) >= helper.MaxCartItems {
+				return nil, fmt.Errorf("the cart can not hold more than %d products", helper.MaxCartItems)
+			}
+			items = append(items, models.CartItem{VariantID: request.VariantID})
+			index = len(items) - 1
+		}
+		items[index].Quantity += request.Quantity
+
+		var err error
+		response, err = service.checkItem(owner, items, index)
+		return items, err
+	})
+	if err != nil {
+		return models.CartResponse{}, err
+	}
+
+	return response, nil
+}
+
+// UpdateItem sets the quantity of a line of the cart.
+//
+// Parameters:
+//
+//	userID (*uuid.UUID): The logged-in user, nil for a guest.
+//	token (string): The token of the guest cart, ignored for a logged-in user.
+//	variantID (string): The variant of the line (uuid).
+//	request (models.CartQuantityRequest): The new quantity.
+//
+// Returns:
+//
+//	models.CartResponse: The cart after the change.
+//	error: An error if the line is not found, the product is not available or the stock is insufficient.
+func (service *Service) UpdateItem(userID *uuid.UUID, token string, variantID string, request models.CartQuantityRequest) (models.CartResponse, error) {
+	owner := cartOwner{userID: userID, token: token}
+
+	parsedID, err := uuid.Parse(variantID)
+	if err != nil {
+		return models.CartResponse{}, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	var response models.CartResponse
+
+	err = service.updateItems(owner, func(items []models.CartItem) ([]models.CartItem, error) {
+		index, err := findLine(items, parsedID)
+		if err != nil {
+			return nil, err
+		}
+
+		items[index].Quantity = request.Quantity
+
+		response, err = service.checkItem(owner, items, index)
+		return items, err
+	})
+	if err != nil {
+		return models.CartResponse{}, err
+	}
+
+	return response, nil
+}
+
+// RemoveItem removes a line from the cart.
+//
+// Parameters:
+//
+//	userID (*uuid.UUID): The logged-in user, nil for a guest.
+//	token (string): The token of the guest cart, ignored for a logged-in user.
+//	variantID (string): The variant of the line (uuid).
+//
+// Returns:
+//
+//	models.CartResponse: The cart after the change.
+//	error: An error if the line is not found or the cart can not be saved.
+func (service *Service) RemoveItem(userID *uuid.UUID, token string, variantID string) (models.CartResponse, error) {
+	owner := cartOwner{userID: userID, token: token}
+
+	parsedID, err := uuid.Parse(variantID)
+	if err != nil {
+		return models.CartResponse{}, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	var items []models.CartItem
+
+	err = service.updateItems(owner, func(current []models.CartItem) ([]models.CartItem, error) {
+		index, err := findLine(current, parsedID)
+		if err != nil {
+			return nil, err
+		}
+
+		items = slices.Delete(current, index, index+1)
+		return items, nil
+	})
+	if err != nil {
+		return models.CartResponse{}, err
+	}
+
+	return service.cartResponse(owner, items)
+}
+
+// ClearCart empties the cart.
+//
+// Parameters:
+//
+//	userID (*uuid.UUID): The logged-in user, nil for a guest.
+//	token (string): The token of the guest cart, ignored for a logged-in user.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the cart can not be deleted.
+func (service *Service) ClearCart(userID *uuid.UUID, token string) (string, error) {
+	var err error
+	if userID != nil {
+		err = service.repo.DeleteCart(*userID)
+	} else if token != "" {
+		err = service.repo.DeleteGuestCart(helper.HashToken(token))
+	}
+
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Cart cleared successfully.", nil
+}
+
+// MergeGuestCartOnLogin is the login hook of the carts, registered with services.RegisterLoginHook:
+// the guest cart sent with the login is merged into the cart of the user.
+//
+// Parameters:
+//
+//	userID (uuid.UUID): The user who logged in.
+//	cartToken (string): The token of the guest cart, empty if the login has none.
+//
+// Returns:
+//
+//	error: An error if the carts can not be loaded or saved.
+func (service *Service) MergeGuestCartOnLogin(userID uuid.UUID, cartToken string) error {
+	if cartToken == "" {
+		return nil
+	}
+
+	return service.MergeGuestCart(cartToken, userID)
+}
+
+// MergeGuestCart moves the items of a guest cart into the cart of a user who just logged in. The guest cart
+// is read and deleted at once, so an item added to it concurrently is either merged or left in a new guest cart,
+// and it is given back if the merge fails. The quantities of the variants in both carts are added up,
+// within the size limits of the carts and the available stock; the products no longer available are left out.
+//
+// Parameters:
+//
+//	token (string): The token of the guest cart.
+//	userID (uuid.UUID): The user who logged in.
+//
+// Returns:
+//
+//	error: An error if the carts can not be loaded or saved.
+func (service *Service) MergeGuestCart(token string, userID uuid.UUID) error {
+	tokenHash := helper.HashToken(token)
+
+	guestItems, err := service.repo.TakeGuestCartItems(tokenHash)
+	if err != nil || len(guestItems) == 0 {
+		return err
+	}
+
+	restore := func(err error) error {
+		if restoreErr := service.repo.SaveGuestCartItems(tokenHash, guestItems, helper.GuestCartTTL); restoreErr != nil {
+			fmt.Printf("failed to restore the guest cart after a failed merge: %v\n", restoreErr)
+		}
+		return err
+	}
+
+	owner := cartOwner{userID: &userID}
+
+	err = service.updateItems(owner, func(items []models.CartItem) ([]models.CartItem, error) {
+		variantIDs := make([]uuid.UUID, 0, len(items)+len(guestItems))
+		for _, item := range append(slices.Clone(items), guestItems...) {
+			variantIDs = append(variantIDs, item.VariantID)
+		}
+
+		lines, err := service.catalogLines(variantIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, guestItem := range guestItems {
+			line, ok := lines[guestItem.VariantID]
+			if !ok || line.product.Status != constants.PRODUCT_STATUS_ACTIVE {
+				continue
+			}
+
+			index := slices.IndexFunc(items, func(item models.CartItem) bool { return item.VariantID == guestItem.VariantID })
+			if index < 0 {
+				if len(items) >= helper.MaxCartItems || currencyConflict(items, lines, line.variant) {
+					continue
+				}
+				items = append(items, models.CartItem{VariantID: guestItem.VariantID, CreatedAt: guestItem.CreatedAt})
+				index = len(items) - 1
+			}
+
+			items[index].Quantity = min(items[index].Quantity+guestItem.Quantity, helper.MaxCartItemQuantity, max(line.available, items[index].Quantity))
+			if items[index].Quantity <= 0 {
+				items = slices.Delete(items, index, index+1)
+			}
+		}
+
+		return items, nil
+	})
+	if err != nil {
+		return restore(err)
+	}
+
+	return nil
+}
+
+// checkItem checks the line at index of the items against the catalog and the stock,
+// and builds the response of the cart holding the items.
+func (service *Service) checkItem(owner cartOwner, items []models.CartItem, index int) (models.CartResponse, error) {
+	item := items[index]
+
+	if item.Quantity > helper.MaxCartItemQuantity {
+		return models.CartResponse{}, fmt.Errorf("the quantity of a product can not exceed %d", helper.MaxCartItemQuantity)
+	}
+
+	variantIDs := make([]uuid.UUID, 0, len(items))
+	for _, cartItem := range items {
+		variantIDs = append(variantIDs, cartItem.VariantID)
+	}
+
+	lines, err := service.catalogLines(variantIDs)
+	if err != nil {
+		return models.CartResponse{}, err
+	}
+
+	line, ok := lines[item.VariantID]
+	if !ok || line.product.Status != constants.PRODUCT_STATUS_ACTIVE {
+		return models.CartResponse{}, fmt.Errorf("this product is not available")
+	}
+
+	if item.Quantity > line.available {
+		if line.available <= 0 {
+			return models.CartResponse{}, fmt.Errorf("%s is out of stock", line.variant.SKU)
+		}
+		return models.CartResponse{}, fmt.Errorf("only %d of %s left in stock", line.available, line.variant.SKU)
+	}
+
+	if currencyConflict(slices.Delete(slices.Clone(items), index, index+1), lines, line.variant) {
+		return models.CartResponse{}, fmt.Errorf("the cart can only hold products priced in the same currency")
+	}
+
+	return service.buildResponse(owner, items, lines), nil
+}
+
+// findLine finds the line of a variant among the items of the cart.
+func findLine(items []models.CartItem, variantID uuid.UUID) (int, error) {
+	index := slices.IndexFunc(items, func(item models.CartItem) bool { return item.VariantID == variantID })
+	if index < 0 {
+		return 0, fmt.Errorf("this product is not in the cart")
+	}
+
+	return index, nil
+}
+
+// loadItems loads the items of a cart, nil if the cart does not exist.
+func (service *Service) loadItems(owner cartOwner) ([]models.CartItem, error) {
+	if owner.userID == nil {
+		if owner.token == "" {
+			return nil, nil
+		}
+		return service.repo.GetGuestCartItems(helper.HashToken(owner.token))
+	}
+
+	cart, err := service.repo.GetCartByUser(*owner.userID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+	if cart == nil {
+		return nil, nil
+	}
+
+	return cart.Items, nil
+}
+
+// updateItems applies a change to the items of a cart and stores them. A guest cart is changed in a Redis
+// optimistic transaction, the change being computed again on the new items if the cart changes meanwhile,
+// and storing it extends its lifetime; the cart of a user is locked in a transaction while it is changed.
+func (service *Service) updateItems(owner cartOwner, change func(items []models.CartItem) ([]models.CartItem, error)) error {
+	if owner.userID == nil {
+		return service.repo.UpdateGuestCartItems(helper.HashToken(owner.token), helper.GuestCartTTL, change)
+	}
+
+	if err := service.repo.UpdateCartItems(*owner.userID, change); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return fmt.Errorf(pgErr.Detail)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// cartResponse loads the current state of the items and builds the response of the cart.
+func (service *Service) cartResponse(owner cartOwner, items []models.CartItem) (models.CartResponse, error) {
+	variantIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+
+	lines, err := service.catalogLines(variantIDs)
+	if err != nil {
+		return models.CartResponse{}, err
+	}
+
+	return service.buildResponse(owner, items, lines), nil
+}
+
+// buildResponse prices the items with the current prices and checks them against the current stock.
+// Only the available lines count in the subtotal.
+func (service *Service) buildResponse(owner cartOwner, items []models.CartItem, lines map[uuid.UUID]catalogLine) models.CartResponse {
+	response := models.CartResponse{Items: make([]models.CartItemResponse, 0, len(items))}
+	if owner.userID == nil {
+		response.CartToken = owner.token
+	}
+
+	for _, item := range items {
+		itemResponse := models.CartItemResponse{
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Status:    constants.CART_ITEM_STATUS_UNAVAILABLE,
+		}
+		response.ItemCount += item.Quantity
+
+		line, ok := lines[item.VariantID]
+		if ok {
+			itemResponse.ProductID = line.product.ProductID
+			itemResponse.SKU = line.variant.SKU
+			itemResponse.ProductName = line.product.Name
+			itemResponse.VariantName = line.variant.Name
+			itemResponse.UnitPrice = line.variant.Price
+			itemResponse.Currency = line.variant.Currency
+			itemResponse.LineTotal = line.variant.Price * item.Quantity
+			itemResponse.Available = max(line.available, 0)
+
+			switch {
+			case line.product.Status != constants.PRODUCT_STATUS_ACTIVE:
+				itemResponse.Status = constants.CART_ITEM_STATUS_UNAVAILABLE
+			case line.available < item.Quantity:
+				itemResponse.Status = constants.CART_ITEM_STATUS_INSUFFICIENT_STOCK
+			default:
+				itemResponse.Status = constants.CART_ITEM_STATUS_AVAILABLE
+				response.Subtotal += itemResponse.LineTotal
+				response.Currency = itemResponse.Currency
+			}
+		}
+
+		response.Items = append(response.Items, itemResponse)
+	}
+
+	return response
+}
+
+// catalogLines loads the variants with their product and their available stock, by variant id.
+// The deleted variants and the variants of deleted products are missing.
+func (service *Service) catalogLines(variantIDs []uuid.UUID) (map[uuid.UUID]catalogLine, error) {
+	lines := make(map[uuid.UUID]catalogLine, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return lines, nil
+	}
+
+	variants, err := service.repo.FindVariants(variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(variants))
+	for _, variant := range variants {
+		productIDs = append(productIDs, variant.ProductID)
+	}
+
+	products, err := service.repo.FindProducts(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := service.repo.FindAvailableStock(variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productsByID := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productsByID[product.ProductID] = product
+	}
+
+	for _, variant := range variants {
+		product, ok := productsByID[variant.ProductID]
+		if !ok {
+			continue
+		}
+		lines[variant.VariantID] = catalogLine{variant: variant, product: product, available: available[variant.VariantID]}
+	}
+
+	return lines, nil
+}
+
+// currencyConflict tells if the variant is priced in another currency than the available lines of the items.
+func currencyConflict(items []models.CartItem, lines map[uuid.UUID]catalogLine, variant models.ProductVariant) bool {
+	for _, item := range items {
+		line, ok := lines[item.VariantID]
+		if ok && line.product.Status == constants.PRODUCT_STATUS_ACTIVE && line.variant.Currency != variant.Currency {
+			return true
+		}
+	}
+	return false
+}
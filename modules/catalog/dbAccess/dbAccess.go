@@ -0,0 +1,468 @@
+package dbAccess
+
+import (
+	"e-commerce/database/connections"
+
+	"e-commerce/base"
+	"e-commerce/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repo defines a concrete implementation of catalog-specific repository
+// using the generic BaseRepository from the shared layer.
+type Repo struct {
+	categoryBase  base.BaseRepository[models.Category]
+	productBase   base.BaseRepository[models.Product]
+	variantBase   base.BaseRepository[models.ProductVariant]
+	attributeBase base.BaseRepository[models.Attribute]
+}
+
+// ValueCount is the number of products having a value of an attribute.
+type ValueCount struct {
+	AttributeID uuid.UUID
+	Value       string
+	Count       int64
+}
+
+// ValueRange is the range of the values of a number attribute (or of the prices) of a list of products.
+type ValueRange struct {
+	AttributeID uuid.UUID
+	Min         *float64
+	Max         *float64
+}
+
+// PriceRange is the range of the variant prices in a currency of a list of products.
+type PriceRange struct {
+	Currency string
+	Min      *float64
+	Max      *float64
+}
+
+// NewCatalogRepository creates a new instance of the Catalog repository.
+// Returns:
+// - *Repo: Pointer to a new Repo with injected DB and Redis client.
+func NewCatalogRepository() *Repo {
+	return &Repo{
+		categoryBase:  *base.NewBaseRepository[models.Category](connections.GetDB(), connections.GetRedisClient()),
+		productBase:   *base.NewBaseRepository[models.Product](connections.GetDB(), connections.GetRedisClient()),
+		variantBase:   *base.NewBaseRepository[models.ProductVariant](connections.GetDB(), connections.GetRedisClient()),
+		attributeBase: *base.NewBaseRepository[models.Attribute](connections.GetDB(), connections.GetRedisClient()),
+	}
+}
+
+// FindCategories retrieves all the categories ordered by position and name.
+// Returns:
+// - []models.Category: Slice of categories.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindCategories() ([]models.Category, error) {
+	categories, _, err := repo.categoryBase.FindAll(nil, "position, name", 0, 0)
+	return categories, err
+}
+
+// GetCategoryByCondition retrieves a category matching the given condition.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.Category: Pointer to the category, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetCategoryByCondition(condition any, args ...any) (*models.Category, error) {
+	return repo.categoryBase.GetByCondition(condition, args...)
+}
+
+// CreateCategory inserts a new category.
+// Parameters:
+// - category (*models.Category): Pointer to the category to create.
+// Returns:
+// - error: Error if any occurred during insertion.
+func (repo Repo) CreateCategory(category *models.Category) error {
+	return repo.categoryBase.DB.Omit(clause.Associations).Create(category).Error
+}
+
+// UpdateCategory saves the category.
+// Parameters:
+// - category (*models.Category): Pointer to the category to update.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) UpdateCategory(category *models.Category) error {
+	return repo.categoryBase.DB.Omit(clause.Associations).Save(category).Error
+}
+
+// DeleteCategory soft-deletes the category.
+// Parameters:
+// - category (*models.Category): Pointer to the category to delete.
+// Returns:
+// - error: Error if any occurred during deletion.
+func (repo Repo) DeleteCategory(category *models.Category) error {
+	return repo.categoryBase.Delete(category, true)
+}
+
+// CountCategoryUsage counts the subcategories and the products of the category.
+// Parameters:
+// - categoryID (uuid.UUID): The category id.
+// Returns:
+// - int64: The number of subcategories.
+// - int64: The number of products.
+// - error: Error if any occurred during the query.
+func (repo Repo) CountCategoryUsage(categoryID uuid.UUID) (int64, int64, error) {
+	var children, products int64
+
+	if err := repo.categoryBase.DB.Model(&models.Category{}).Where("categories.parent_id = ?", categoryID).Count(&children).Error; err != nil {
+		return 0, 0, err
+	}
+
+	if err := repo.productBase.DB.Model(&models.Product{}).Where("products.category_id = ?", categoryID).Count(&products).Error; err != nil {
+		return 0, 0, err
+	}
+
+	return children, products, nil
+}
+
+// GetProductFilter returns a GORM query builder for the Product model.
+// Returns:
+// - *gorm.DB: GORM DB model scoped to Product.
+func (repo Repo) GetProductFilter() *gorm.DB {
+	return repo.productBase.DB.Model(&models.Product{})
+}
+
+// FindProductPage retrieves one page of the products matching filters with their variants,
+// and the total number of matching products.
+// Parameters:
+// - filters (*gorm.DB): A query builder with filter conditions.
+// - keyset (string): SQL WHERE clause selecting the rows after the cursor (can be empty).
+// - keysetArgs ([]any): Arguments for the keyset clause.
+// - orderBy (string): Order clause of the page.
+// - limit (int): Number of records per page.
+// - offset (int): Offset for pagination.
+// Returns:
+// - []models.Product: Slice of products.
+// - int64: Total number of products found.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindProductPage(filters *gorm.DB, keyset string, keysetArgs []any, orderBy string, limit, offset int) ([]models.Product, int64, error) {
+	products, total, err := repo.productBase.FindPage(filters, keyset, keysetArgs, orderBy, limit, offset)
+	if err != nil || len(products) == 0 {
+		return products, total, err
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ProductID)
+	}
+
+	variants, err := repo.findVariants("product_variants.product_id IN ?", productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byProduct := make(map[uuid.UUID][]models.ProductVariant, len(products))
+	for _, variant := range variants {
+		byProduct[variant.ProductID] = append(byProduct[variant.ProductID], variant)
+	}
+
+	values, err := repo.findAttributeValues(productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	valuesByProduct := make(map[uuid.UUID][]models.ProductAttributeValue, len(products))
+	for _, value := range values {
+		valuesByProduct[value.ProductID] = append(valuesByProduct[value.ProductID], value)
+	}
+
+	for i := range products {
+		products[i].Variants = byProduct[products[i].ProductID]
+		products[i].Attributes = valuesByProduct[products[i].ProductID]
+	}
+
+	return products, total, nil
+}
+
+// GetProductByCondition retrieves a product matching the given condition with its variants.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.Product: Pointer to the product, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetProductByCondition(condition any, args ...any) (*models.Product, error) {
+	product, err := repo.productBase.GetByCondition(condition, args...)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	product.Variants, err = repo.findVariants("product_variants.product_id = ?", product.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	product.Attributes, err = repo.findAttributeValues([]uuid.UUID{product.ProductID})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// CreateProduct inserts a new product.
+// Parameters:
+// - product (*models.Product): Pointer to the product to create.
+// Returns:
+// - error: Error if any occurred during insertion.
+func (repo Repo) CreateProduct(product *models.Product) error {
+	return repo.productBase.DB.Omit(clause.Associations).Create(product).Error
+}
+
+// UpdateProduct saves the product without touching its variants, and deletes the values
+// of the attributes that no longer apply to the product (after a move to another category).
+// Parameters:
+// - product (*models.Product): Pointer to the product to update.
+// - attributeIDs ([]uuid.UUID): The attributes of the category of the product.
+// Returns:
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) UpdateProduct(product *models.Product, attributeIDs []uuid.UUID) error {
+	return repo.productBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(product).Error; err != nil {
+			return err
+		}
+
+		stale := tx.Where("product_attribute_values.product_id = ?", product.ProductID)
+		if len(attributeIDs) > 0 {
+			stale = stale.Where("product_attribute_values.attribute_id NOT IN ?", attributeIDs)
+		}
+
+		return stale.Delete(&models.ProductAttributeValue{}).Error
+	})
+}
+
+// DeleteProduct soft-deletes the product and its variants.
+// Parameters:
+// - product (*models.Product): Pointer to the product to delete.
+// Returns:
+// - error: Error if any occurred, in which case nothing is deleted.
+func (repo Repo) DeleteProduct(product *models.Product) error {
+	return repo.productBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_variants.product_id = ?", product.ProductID).Delete(&models.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(product).Error
+	})
+}
+
+// GetVariantByCondition retrieves a variant matching the given condition with its options.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.ProductVariant: Pointer to the variant, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetVariantByCondition(condition any, args ...any) (*models.ProductVariant, error) {
+	return repo.variantBase.GetByConditionWithRelations([]string{"Options"}, condition, args...)
+}
+
+// SaveVariant creates or updates the variant and replaces its options.
+// Parameters:
+// - variant (*models.ProductVariant): Pointer to the variant with its options.
+// Returns:
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) SaveVariant(variant *models.ProductVariant) error {
+	return repo.variantBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(variant).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("variant_options.variant_id = ?", variant.VariantID).Delete(&models.VariantOption{}).Error; err != nil {
+			return err
+		}
+
+		for i := range variant.Options {
+			variant.Options[i].VariantID = variant.VariantID
+		}
+
+		if len(variant.Options) == 0 {
+			return nil
+		}
+
+		return tx.Create(&variant.Options).Error
+	})
+}
+
+// DeleteVariant soft-deletes the variant.
+// Parameters:
+// - variant (*models.ProductVariant): Pointer to the variant to delete.
+// Returns:
+// - error: Error if any occurred during deletion.
+func (repo Repo) DeleteVariant(variant *models.ProductVariant) error {
+	return repo.variantBase.Delete(variant, true)
+}
+
+// FindAttributes retrieves the attributes matching the condition, ordered by position and name.
+// Parameters:
+// - condition (any): The WHERE clause condition (nil for all the attributes).
+// - args (...any): Arguments for the condition.
+// Returns:
+// - []models.Attribute: Slice of attributes.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindAttributes(condition any, args ...any) ([]models.Attribute, error) {
+	var attributes []models.Attribute
+	query := repo.attributeBase.DB.Order("attributes.position, attributes.name")
+	if condition != nil {
+		query = query.Where(condition, args...)
+	}
+	err := query.Find(&attributes).Error
+	return attributes, err
+}
+
+// GetAttributeByCondition retrieves an attribute matching the given condition.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.Attribute: Pointer to the attribute, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetAttributeByCondition(condition any, args ...any) (*models.Attribute, error) {
+	return repo.attributeBase.GetByCondition(condition, args...)
+}
+
+// CreateAttribute inserts a new attribute.
+// Parameters:
+// - attribute (*models.Attribute): Pointer to the attribute to create.
+// Returns:
+// - error: Error if any occurred during insertion.
+func (repo Repo) CreateAttribute(attribute *models.Attribute) error {
+	return repo.attributeBase.DB.Omit(clause.Associations).Create(attribute).Error
+}
+
+// UpdateAttribute saves the attribute.
+// Parameters:
+// - attribute (*models.Attribute): Pointer to the attribute to update.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) UpdateAttribute(attribute *models.Attribute) error {
+	return repo.attributeBase.DB.Omit(clause.Associations).Save(attribute).Error
+}
+
+// DeleteAttribute soft-deletes the attribute and deletes its values.
+// Parameters:
+// - attribute (*models.Attribute): Pointer to the attribute to delete.
+// Returns:
+// - error: Error if any occurred, in which case nothing is deleted.
+func (repo Repo) DeleteAttribute(attribute *models.Attribute) error {
+	return repo.attributeBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_attribute_values.attribute_id = ?", attribute.AttributeID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(attribute).Error
+	})
+}
+
+// CountAttributeValues counts the product attribute values matching the condition.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - int64: The number of values.
+// - error: Error if any occurred during the query.
+func (repo Repo) CountAttributeValues(condition any, args ...any) (int64, error) {
+	var count int64
+	err := repo.attributeBase.DB.Model(&models.ProductAttributeValue{}).Where(condition, args...).Count(&count).Error
+	return count, err
+}
+
+// SetProductAttributes replaces the attribute values of the product.
+// Parameters:
+// - productID (uuid.UUID): The product id.
+// - values ([]models.ProductAttributeValue): The new values.
+// Returns:
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) SetProductAttributes(productID uuid.UUID, values []models.ProductAttributeValue) error {
+	return repo.productBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_attribute_values.product_id = ?", productID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+
+		if len(values) == 0 {
+			return nil
+		}
+
+		return tx.Omit(clause.Associations).Create(&values).Error
+	})
+}
+
+// CountValues counts the products selected by the subquery by value, for the given attributes.
+// Parameters:
+// - products (*gorm.DB): Subquery selecting the product ids.
+// - attributeIDs ([]uuid.UUID): The attributes to count the values of.
+// Returns:
+// - []ValueCount: The number of products per attribute and value, most frequent values first.
+// - error: Error if any occurred during the query.
+func (repo Repo) CountValues(products *gorm.DB, attributeIDs []uuid.UUID) ([]ValueCount, error) {
+	var counts []ValueCount
+	err := repo.attributeBase.DB.Model(&models.ProductAttributeValue{}).
+		Select("product_attribute_values.attribute_id, product_attribute_values.value, COUNT(*) AS count").
+		Where("product_attribute_values.attribute_id IN ? AND product_attribute_values.product_id IN (?)", attributeIDs, products).
+		Group("product_attribute_values.attribute_id, product_attribute_values.value").
+		Order("count DESC, product_attribute_values.value").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// ValueRanges returns the smallest and largest value of the given number attributes among the products selected by the subquery.
+// Parameters:
+// - products (*gorm.DB): Subquery selecting the product ids.
+// - attributeIDs ([]uuid.UUID): The number attributes.
+// Returns:
+// - []ValueRange: The range of the values per attribute.
+// - error: Error if any occurred during the query.
+func (repo Repo) ValueRanges(products *gorm.DB, attributeIDs []uuid.UUID) ([]ValueRange, error) {
+	var ranges []ValueRange
+	err := repo.attributeBase.DB.Model(&models.ProductAttributeValue{}).
+		Select("product_attribute_values.attribute_id, MIN(product_attribute_values.number_value) AS min, MAX(product_attribute_values.number_value) AS max").
+		Where("product_attribute_values.attribute_id IN ? AND product_attribute_values.product_id IN (?)", attributeIDs, products).
+		Group("product_attribute_values.attribute_id").
+		Scan(&ranges).Error
+	return ranges, err
+}
+
+// PriceRanges returns the smallest and largest variant price of each currency among the products selected by the subquery.
+// Parameters:
+// - products (*gorm.DB): Subquery selecting the product ids.
+// - currency (string): The currency of the prices, or empty for all the currencies.
+// Returns:
+// - []PriceRange: The range of the prices of each currency, in minor units, ordered by currency.
+// - error: Error if any occurred during the query.
+func (repo Repo) PriceRanges(products *gorm.DB, currency string) ([]PriceRange, error) {
+	var priceRanges []PriceRange
+	query := repo.variantBase.DB.Model(&models.ProductVariant{}).
+		Select("product_variants.currency, MIN(product_variants.price) AS min, MAX(product_variants.price) AS max").
+		Where("product_variants.product_id IN (?)", products)
+	if currency != "" {
+		query = query.Where("product_variants.currency = ?", currency)
+	}
+	err := query.Group("product_variants.currency").
+		Order("product_variants.currency").
+		Scan(&priceRanges).Error
+	return priceRanges, err
+}
+
+// findAttributeValues retrieves the attribute values of the products with their attribute, ordered by attribute position.
+func (repo Repo) findAttributeValues(productIDs []uuid.UUID) ([]models.ProductAttributeValue, error) {
+	var values []models.ProductAttributeValue
+	err := repo.attributeBase.DB.Joins("Attribute").
+		Where("product_attribute_values.product_id IN ?", productIDs).
+		Order(`"Attribute".position, "Attribute".name`).
+		Find(&values).Error
+	return values, err
+}
+
+// findVariants retrieves the variants matching the condition with their options, ordered by position.
+func (repo Repo) findVariants(condition any, args ...any) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	err := repo.variantBase.DB.Preload("Options").
+		Where(condition, args...).
+		Order("product_variants.position, product_variants.sku").
+		Find(&variants).Error
+	return variants, err
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCategoryAttributes godoc
+// @Summary      Get the Attributes of a Category
+// @Description  Returns the attributes of the products of a category, the ones inherited from its parent categories included.
+// @Tags         Catalog
+// @Produce      json
+// @Param        id   path      string                                            true  "Category ID"
+// @Success      200  {object}  models.SuccessResponse[[]models.AttributeResponse]  "Attributes"
+// @Failure      400  {object}  models.BadRequestError                            "Invalid ID or category not found"
+// @Router       /catalog/categories/{id}/attributes [get]
+func (handler *Handler) GetCategoryAttributes(context *gin.Context) {
+	attributes, err := handler.service.GetCategoryAttributes(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, attributes)
+}
+
+// AddAttribute godoc
+// @Summary      Add an Attribute
+// @Description  Defines a typed attribute (enum, number, boolean or text) for the products of a category and of its subcategories. The code is used by the attr.<code> filters of the product lists.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.AttributeRequest                           true  "Attribute details"
+// @Success      201      {object}  models.SuccessResponse[models.AttributeResponse]  "Attribute created"
+// @Failure      400      {object}  models.BadRequestError                            "Invalid request data or code already used in this branch of the category tree"
+// @Failure      403      {object}  models.ForbiddenError                             "Missing catalog.manage permission"
+// @Router       /admin/catalog/attributes [post]
+func (handler *Handler) AddAttribute(context *gin.Context) {
+	request := models.AttributeRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	attribute, err := handler.service.AddAttribute(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusCreated, attribute)
+}
+
+// UpdateAttribute godoc
+// @Summary      Update an Attribute
+// @Description  Replaces the details of an attribute. The category and the type can not change once products have a value for the attribute, and the enum options in use can not be removed.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                            true  "Attribute ID"
+// @Param        request  body      models.AttributeRequest                           true  "New attribute details"
+// @Success      200      {object}  models.SuccessResponse[models.AttributeResponse]  "Attribute updated"
+// @Failure      400      {object}  models.BadRequestError                            "Invalid request data, attribute not found or change conflicting with the product values"
+// @Failure      403      {object}  models.ForbiddenError                             "Missing catalog.manage permission"
+// @Router       /admin/catalog/attributes/{id} [put]
+func (handler *Handler) UpdateAttribute(context *gin.Context) {
+	request := models.AttributeRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	attribute, err := handler.service.UpdateAttribute(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, attribute)
+}
+
+// DeleteAttribute godoc
+// @Summary      Delete an Attribute
+// @Description  Deletes an attribute with the values of the products for it.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id   path      string                          true  "Attribute ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Attribute deleted"
+// @Failure      400  {object}  models.BadRequestError          "Invalid ID or attribute not found"
+// @Failure      403  {object}  models.ForbiddenError           "Missing catalog.manage permission"
+// @Router       /admin/catalog/attributes/{id} [delete]
+func (handler *Handler) DeleteAttribute(context *gin.Context) {
+	message, err := handler.service.DeleteAttribute(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// SetProductAttributes godoc
+// @Summary      Set the Attributes of a Product
+// @Description  Replaces the attribute values of a product, by attribute code. Only the attributes of the category of the product (inherited ones included) can be set; enum values must be one of the options, number values a number and boolean values true or false.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                          true  "Product ID"
+// @Param        request  body      models.ProductAttributesRequest                 true  "Values by attribute code"
+// @Success      200      {object}  models.SuccessResponse[models.ProductResponse]  "Product with its new attribute values"
+// @Failure      400      {object}  models.BadRequestError                          "Invalid request data, unknown attribute or invalid value"
+// @Failure      403      {object}  models.ForbiddenError                           "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/attributes [put]
+func (handler *Handler) SetProductAttributes(context *gin.Context) {
+	request := models.ProductAttributesRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	product, err := handler.service.SetProductAttributes(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, product)
+}
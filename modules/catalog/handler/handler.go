@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/modules/catalog/service"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewCatalogHandler() *Handler {
+	service := service.NewCatalogService()
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetCategoryTree godoc
+// @Summary      Get the Category Tree
+// @Description  Returns the root categories with their subcategories nested in children, ordered by position and name.
+// @Tags         Catalog
+// @Produce      json
+// @Success      200  {array}   models.CategoryResponse    "Category tree"
+// @Failure      500  {object}  models.InternalServerError "Internal server error"
+// @Router       /catalog/categories [get]
+func (handler *Handler) GetCategoryTree(context *gin.Context) {
+	categories, err := handler.service.GetCategoryTree()
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary      Get a Category
+// @Description  Returns a category with its subcategories nested in children.
+// @Tags         Catalog
+// @Produce      json
+// @Param        id   path      string                   true  "Category ID"
+// @Success      200  {object}  models.CategoryResponse  "Category"
+// @Failure      400  {object}  models.BadRequestError   "Invalid ID or category not found"
+// @Router       /catalog/categories/{id} [get]
+func (handler *Handler) GetCategory(context *gin.Context) {
+	category, err := handler.service.GetCategory(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, category)
+}
+
+// AddCategory godoc
+// @Summary      Create a Category
+// @Description  Creates a root category, or a subcategory when parent_id is given. The slug is generated from the name when empty and must be unique.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        category  body      models.CategoryRequest   true  "Category details"
+// @Success      200       {object}  models.CategoryResponse  "Category created"
+// @Failure      400       {object}  models.BadRequestError   "Invalid input, unknown parent or slug already taken"
+// @Failure      403       {object}  models.ForbiddenError    "Missing catalog.manage permission"
+// @Router       /admin/catalog/categories [post]
+func (handler *Handler) AddCategory(context *gin.Context) {
+	var request models.CategoryRequest
+
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	category, err := handler.service.AddCategory(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, category)
+}
+
+// UpdateCategory godoc
+// @Summary      Update a Category
+// @Description  Replaces the details of a category. A category can be moved under another parent, but not under itself or one of its subcategories.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                   true  "Category ID"
+// @Param        category  body      models.CategoryRequest   true  "Category details"
+// @Success      200       {object}  models.CategoryResponse  "Category updated"
+// @Failure      400       {object}  models.BadRequestError   "Invalid input, category not found or invalid move"
+// @Failure      403       {object}  models.ForbiddenError    "Missing catalog.manage permission"
+// @Router       /admin/catalog/categories/{id} [put]
+func (handler *Handler) UpdateCategory(context *gin.Context) {
+	var request models.CategoryRequest
+
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	category, err := handler.service.UpdateCategory(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete a Category
+// @Description  Deletes a category. Categories with subcategories or products can not be deleted.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id   path      string                          true  "Category ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Category deleted"
+// @Failure      400  {object}  models.BadRequestError          "Category not found or still in use"
+// @Failure      403  {object}  models.ForbiddenError           "Missing catalog.manage permission"
+// @Router       /admin/catalog/categories/{id} [delete]
+func (handler *Handler) DeleteCategory(context *gin.Context) {
+	message, err := handler.service.DeleteCategory(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// bindRequest reads and validates the JSON body of the request, answering 400 if it is invalid.
+func bindRequest(context *gin.Context, request any) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return false
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, validator.ErrorMessage(err))
+		return false
+	}
+
+	return true
+}
+
+// bindProductQuery reads the query parameters of the product lists, the attr.<code> filters included,
+// answering 400 if they are invalid.
+func bindProductQuery(context *gin.Context) (*models.ProductQueryParams, bool) {
+	queryParams := &models.ProductQueryParams{Attributes: map[string]models.FilterExpr{}}
+
+	if err := context.ShouldBindQuery(queryParams); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	if queryParams.Currency != nil {
+		currency := strings.ToUpper(*queryParams.Currency)
+		queryParams.Currency = &currency
+	}
+
+	if err := validator.ValidateStruct(queryParams); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, validator.ErrorMessage(err))
+		return nil, false
+	}
+
+	for key, values := range context.Request.URL.Query() {
+		code, found := strings.CutPrefix(key, "attr.")
+		if !found || len(values) == 0 {
+			continue
+		}
+
+		expr, err := models.ParseFilterExpr(values[0])
+		if err != nil {
+			helper.ResponseWriter(context, http.StatusBadRequest, "attr."+code+": "+err.Error())
+			return nil, false
+		}
+
+		queryParams.Attributes[strings.ToLower(code)] = expr
+	}
+
+	return queryParams, true
+}
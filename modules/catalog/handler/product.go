@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProducts godoc
+// @Summary      Get Products
+// @Description  Returns one page of the active products with their variants and attributes, and the facets of the whole list (value counts of the filterable attributes, attribute ranges and the price range of each currency, in minor units). Filtering by category includes the products of its subcategories. Pages are selected by number (page/limit) or by the next_cursor of the previous page.
+// @Tags         Catalog
+// @Produce      json
+// @Param        category_id  query     string  false  "Filter by category, subcategories included"
+// @Param        name         query     string  false  "Filter by name (partial match)"
+// @Param        price        query     string  false  "Filter by the price of any variant in the currency, in minor units (e.g. between:1000,5000 for 10.00 to 50.00, gte:1000)"
+// @Param        currency     query     string  false  "ISO 4217 currency of the price filter and of the price facet, required with price (e.g. EUR)"
+// @Param        attr.code    query     string  false  "Filter by a filterable attribute, one parameter per attribute code (e.g. attr.colour=in:red,blue, attr.weight=lte:2)"
+// @Param        page         query     int     false  "Page number, starting from 1"
+// @Param        limit        query     int     false  "Page size"
+// @Param        cursor       query     string  false  "Cursor of the page (next_cursor of the previous page)"
+// @Param        sort         query     string  false  "Sort columns (name, created_at, updated_at, product_id), prefixed with - for descending order"
+// @Success      200          {object}  models.ResponseWithFacets      "Page of products with the facets of the list"
+// @Failure      400          {object}  models.BadRequestError         "Invalid query parameters"
+// @Router       /catalog/products [get]
+func (handler *Handler) GetProducts(context *gin.Context) {
+	queryParams, ok := bindProductQuery(context)
+	if !ok {
+		return
+	}
+
+	products, pagination, facets, err := handler.service.GetProducts(queryParams)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.FacetedResponseWriter(context, products, pagination, facets)
+}
+
+// GetProduct godoc
+// @Summary      Get a Product
+// @Description  Returns an active product with its variants, looked up by id or by slug.
+// @Tags         Catalog
+// @Produce      json
+// @Param        id   path      string                  true  "Product ID or slug"
+// @Success      200  {object}  models.ProductResponse  "Product"
+// @Failure      400  {object}  models.BadRequestError  "Product not found"
+// @Router       /catalog/products/{id} [get]
+func (handler *Handler) GetProduct(context *gin.Context) {
+	product, err := handler.service.GetProduct(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, product)
+}
+
+// GetProductsForAdmin godoc
+// @Summary      Get Products of any Status
+// @Description  Returns one page of the products with their variants and attributes, the drafts and the archived products included, and the facets of the whole list.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        category_id  query     string  false  "Filter by category, subcategories included"
+// @Param        name         query     string  false  "Filter by name (partial match)"
+// @Param        status       query     string  false  "Filter by status (draft, active, archived), e.g. in:draft,active"
+// @Param        price        query     string  false  "Filter by the price of any variant in the currency, in minor units (e.g. between:1000,5000 for 10.00 to 50.00, gte:1000)"
+// @Param        currency     query     string  false  "ISO 4217 currency of the price filter and of the price facet, required with price (e.g. EUR)"
+// @Param        attr.code    query     string  false  "Filter by a filterable attribute, one parameter per attribute code (e.g. attr.colour=in:red,blue, attr.weight=lte:2)"
+// @Param        page         query     int     false  "Page number, starting from 1"
+// @Param        limit        query     int     false  "Page size"
+// @Param        cursor       query     string  false  "Cursor of the page (next_cursor of the previous page)"
+// @Param        sort         query     string  false  "Sort columns (name, created_at, updated_at, product_id), prefixed with - for descending order"
+// @Success      200          {object}  models.ResponseWithFacets      "Page of products with the facets of the list"
+// @Failure      400          {object}  models.BadRequestError         "Invalid query parameters"
+// @Failure      403          {object}  models.ForbiddenError          "Missing catalog.manage permission"
+// @Router       /admin/catalog/products [get]
+func (handler *Handler) GetProductsForAdmin(context *gin.Context) {
+	queryParams, ok := bindProductQuery(context)
+	if !ok {
+		return
+	}
+
+	products, pagination, facets, err := handler.service.GetProductsForAdmin(queryParams)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.FacetedResponseWriter(context, products, pagination, facets)
+}
+
+// GetProductForAdmin godoc
+// @Summary      Get a Product of any Status
+// @Description  Returns a product with its variants, whatever its status.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id   path      string                  true  "Product ID"
+// @Success      200  {object}  models.ProductResponse  "Product"
+// @Failure      400  {object}  models.BadRequestError  "Invalid ID or product not found"
+// @Failure      403  {object}  models.ForbiddenError   "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id} [get]
+func (handler *Handler) GetProductForAdmin(context *gin.Context) {
+	product, err := handler.service.GetProductForAdmin(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, product)
+}
+
+// AddProduct godoc
+// @Summary      Create a Product
+// @Description  Creates a product, as a draft unless another status is given. A product needs at least one variant to be active. The slug is generated from the name when empty and must be unique.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        product  body      models.ProductRequest   true  "Product details"
+// @Success      200      {object}  models.ProductResponse  "Product created"
+// @Failure      400      {object}  models.BadRequestError  "Invalid input, unknown category or slug already taken"
+// @Failure      403      {object}  models.ForbiddenError   "Missing catalog.manage permission"
+// @Router       /admin/catalog/products [post]
+func (handler *Handler) AddProduct(context *gin.Context) {
+	var request models.ProductRequest
+
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	product, err := handler.service.AddProduct(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, product)
+}
+
+// UpdateProduct godoc
+// @Summary      Update a Product
+// @Description  Replaces the details and the status of a product (draft, active or archived); the variants are managed on their own endpoints.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "Product ID"
+// @Param        product  body      models.ProductRequest   true  "Product details"
+// @Success      200      {object}  models.ProductResponse  "Product updated"
+// @Failure      400      {object}  models.BadRequestError  "Invalid input or product not found"
+// @Failure      403      {object}  models.ForbiddenError   "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id} [put]
+func (handler *Handler) UpdateProduct(context *gin.Context) {
+	var request models.ProductRequest
+
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	product, err := handler.service.UpdateProduct(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, product)
+}
+
+// DeleteProduct godoc
+// @Summary      Delete a Product
+// @Description  Deletes a product with its variants.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id   path      string                          true  "Product ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Product deleted"
+// @Failure      400  {object}  models.BadRequestError          "Invalid ID or product not found"
+// @Failure      403  {object}  models.ForbiddenError           "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id} [delete]
+func (handler *Handler) DeleteProduct(context *gin.Context) {
+	message, err := handler.service.DeleteProduct(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// AddVariant godoc
+// @Summary      Add a Variant
+// @Description  Adds a variant to a product. The price is in the minor unit of the currency (e.g. 1999 for 19.99 EUR) and the SKU must be unique across all the products.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "Product ID"
+// @Param        variant  body      models.VariantRequest   true  "Variant details"
+// @Success      200      {object}  models.VariantResponse  "Variant created"
+// @Failure      400      {object}  models.BadRequestError  "Invalid input, product not found or SKU already taken"
+// @Failure      403      {object}  models.ForbiddenError   "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/variants [post]
+func (handler *Handler) AddVariant(context *gin.Context) {
+	var request models.VariantRequest
+
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	variant, err := handler.service.AddVariant(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, variant)
+}
+
+// UpdateVariant godoc
+// @Summary      Update a Variant
+// @Description  Replaces the details and the option values of a variant.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                  true  "Product ID"
+// @Param        variantId  path      string                  true  "Variant ID"
+// @Param        variant    body      models.VariantRequest   true  "Variant details"
+// @Success      200        {object}  models.VariantResponse  "Variant updated"
+// @Failure      400        {object}  models.BadRequestError  "Invalid input, variant not found or SKU already taken"
+// @Failure      403        {object}  models.ForbiddenError   "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/variants/{variantId} [put]
+func (handler *Handler) UpdateVariant(context *gin.Context) {
+	var request models.VariantRequest
+
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	variant, err := handler.service.UpdateVariant(context.Param("id"), context.Param("variantId"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, variant)
+}
+
+// DeleteVariant godoc
+// @Summary      Delete a Variant
+// @Description  Deletes a variant of a product. The last variant of an active product can not be deleted.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id         path      string                          true  "Product ID"
+// @Param        variantId  path      string                          true  "Variant ID"
+// @Success      200        {object}  models.SuccessResponse[string]  "Variant deleted"
+// @Failure      400        {object}  models.BadRequestError          "Variant not found or last variant of an active product"
+// @Failure      403        {object}  models.ForbiddenError           "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/variants/{variantId} [delete]
+func (handler *Handler) DeleteVariant(context *gin.Context) {
+	message, err := handler.service.DeleteVariant(context.Param("id"), context.Param("variantId"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
@@ -0,0 +1,63 @@
+package route
+
+import (
+	"e-commerce/middleware/auth"
+	"e-commerce/modules/catalog/handler"
+	"e-commerce/utils/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CatalogRoutes(router *gin.Engine) {
+	handler := handler.NewCatalogHandler()
+
+	// the public routes are matched by path only, so the writes live under /admin/catalog
+	{
+		catalog := router.Group("/catalog")
+
+		catalog.GET(auth.PublicGroupRoute(catalog, "/categories"), handler.GetCategoryTree)
+
+		catalog.GET(auth.PublicGroupRoute(catalog, "/categories/:id"), handler.GetCategory)
+
+		catalog.GET(auth.PublicGroupRoute(catalog, "/categories/:id/attributes"), handler.GetCategoryAttributes)
+
+		catalog.GET(auth.PublicGroupRoute(catalog, "/products"), handler.GetProducts)
+
+		catalog.GET(auth.PublicGroupRoute(catalog, "/products/:id"), handler.GetProduct)
+	}
+
+	{
+		admin := router.Group("/admin/catalog", auth.RequirePermission(constants.PERMISSION_CATALOG_MANAGE))
+
+		admin.POST("/categories", handler.AddCategory)
+
+		admin.PUT("/categories/:id", handler.UpdateCategory)
+
+		admin.DELETE("/categories/:id", handler.DeleteCategory)
+
+		admin.POST("/attributes", handler.AddAttribute)
+
+		admin.PUT("/attributes/:id", handler.UpdateAttribute)
+
+		admin.DELETE("/attributes/:id", handler.DeleteAttribute)
+
+		admin.GET("/products", handler.GetProductsForAdmin)
+
+		admin.POST("/products", handler.AddProduct)
+
+		admin.GET("/products/:id", handler.GetProductForAdmin)
+
+		admin.PUT("/products/:id", handler.UpdateProduct)
+
+		admin.DELETE("/products/:id", handler.DeleteProduct)
+
+		admin.PUT("/products/:id/attributes", handler.SetProductAttributes)
+
+		admin.POST("/products/:id/variants", handler.AddVariant)
+
+		admin.PUT("/products/:id/variants/:variantId", handler.UpdateVariant)
+
+		admin.DELETE("/products/:id/variants/:variantId", handler.DeleteVariant)
+	}
+
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetCategoryAttributes returns the attributes of the products of a category:
+// the attributes defined on the category and the ones inherited from its parents.
+//
+// Parameters:
+//
+//	id (string): The category id (uuid).
+//
+// Returns:
+//
+//	[]models.AttributeResponse: The attributes, ordered by position and name.
+//	error: An error if the category is not found or the query fails.
+func (service *Service) GetCategoryAttributes(id string) ([]models.AttributeResponse, error) {
+	category, err := service.getCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := service.categoryAttributes(category.CategoryID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	response := make([]models.AttributeResponse, 0, len(attributes))
+	for _, attribute := range attributes {
+		response = append(response, attribute.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// AddAttribute defines an attribute for the products of a category and of its subcategories.
+// The code must not be used by another attribute of the same branch of the category tree,
+// and must have the same type wherever it is used so the filters on it are consistent.
+//
+// Parameters:
+//
+//	request (models.AttributeRequest): The attribute details.
+//
+// Returns:
+//
+//	models.AttributeResponse: The created attribute.
+//	error: An error if the category is not found, the code is taken or the creation fails.
+func (service *Service) AddAttribute(request models.AttributeRequest) (models.AttributeResponse, error) {
+	attribute := models.Attribute{}
+
+	if err := service.applyAttributeRequest(&attribute, request); err != nil {
+		return models.AttributeResponse{}, err
+	}
+
+	if err := service.repo.CreateAttribute(&attribute); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.AttributeResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.AttributeResponse{}, err
+	}
+
+	return attribute.ResponseObj(), nil
+}
+
+// UpdateAttribute replaces the details of an attribute. Once products have a value for the attribute,
+// its category and type can no longer change and the enum options in use can not be removed.
+//
+// Parameters:
+//
+//	id (string): The attribute id (uuid).
+//	request (models.AttributeRequest): The new attribute details.
+//
+// Returns:
+//
+//	models.AttributeResponse: The updated attribute.
+//	error: An error if the attribute is not found, the change conflicts with the existing values or the update fails.
+func (service *Service) UpdateAttribute(id string, request models.AttributeRequest) (models.AttributeResponse, error) {
+	attribute, err := service.getAttribute(id)
+	if err != nil {
+		return models.AttributeResponse{}, err
+	}
+
+	if attribute.CategoryID != request.CategoryID || attribute.Type != request.Type {
+		used, err := service.repo.CountAttributeValues("product_attribute_values.attribute_id = ?", attribute.AttributeID)
+		if err != nil {
+			return models.AttributeResponse{}, err
+		}
+
+		if used > 0 {
+			return models.AttributeResponse{}, fmt.Errorf("%d products have a value for this attribute, its category and type can not be changed", used)
+		}
+	}
+
+	if err := service.applyAttributeRequest(attribute, request); err != nil {
+		return models.AttributeResponse{}, err
+	}
+
+	if attribute.Type == constants.ATTRIBUTE_TYPE_ENUM {
+		used, err := service.repo.CountAttributeValues("product_attribute_values.attribute_id = ? AND product_attribute_values.value NOT IN ?", attribute.AttributeID, []string(attribute.Options))
+		if err != nil {
+			return models.AttributeResponse{}, err
+		}
+
+		if used > 0 {
+			return models.AttributeResponse{}, fmt.Errorf("%d products use an option that is no longer in the options", used)
+		}
+	}
+
+	if err := service.repo.UpdateAttribute(attribute); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.AttributeResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.AttributeResponse{}, err
+	}
+
+	return attribute.ResponseObj(), nil
+}
+
+// DeleteAttribute deletes an attribute with the values of the products for it.
+//
+// Parameters:
+//
+//	id (string): The attribute id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the attribute is not found or the deletion fails.
+func (service *Service) DeleteAttribute(id string) (string, error) {
+	attribute, err := service.getAttribute(id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.repo.DeleteAttribute(attribute); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Attribute deleted successfully.", nil
+}
+
+// SetProductAttributes replaces the attribute values of a product. Only the attributes of the category
+// of the product (inherited ones included) can be set, with a value matching the type of the attribute.
+//
+// Parameters:
+//
+//	id (string): The product id (uuid).
+//	request (models.ProductAttributesRequest): The values by attribute code.
+//
+// Returns:
+//
+//	models.ProductResponse: The product with its new attribute values.
+//	error: An error if the product is not found, an attribute is unknown, a value is invalid or the update fails.
+func (service *Service) SetProductAttributes(id string, request models.ProductAttributesRequest) (models.ProductResponse, error) {
+	product, err := service.getProduct(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	attributes, err := service.categoryAttributes(product.CategoryID)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	values := make([]models.ProductAttributeValue, 0, len(request.Attributes))
+
+	for code, raw := range request.Attributes {
+		index := slices.IndexFunc(attributes, func(attribute models.Attribute) bool { return attribute.Code == code })
+		if index < 0 {
+			return models.ProductResponse{}, fmt.Errorf("the category of the product has no attribute %s", code)
+		}
+
+		value, err := attributeValue(attributes[index], raw)
+		if err != nil {
+			return models.ProductResponse{}, err
+		}
+
+		value.ProductID = product.ProductID
+		values = append(values, value)
+	}
+
+	if err := service.repo.SetProductAttributes(product.ProductID, values); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.ProductResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.ProductResponse{}, err
+	}
+
+	return service.GetProductForAdmin(id)
+}
+
+// getAttribute parses the id and loads the attribute.
+func (service *Service) getAttribute(id string) (*models.Attribute, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	attribute, err := service.repo.GetAttributeByCondition("attributes.attribute_id = ?", parsedUUID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if attribute == nil {
+		return nil, fmt.Errorf("no attribute found with id = %s", parsedUUID)
+	}
+
+	return attribute, nil
+}
+
+// categoryAttributes returns the attributes of the category and of its parents.
+func (service *Service) categoryAttributes(categoryID uuid.UUID) ([]models.Attribute, error) {
+	categories, err := service.repo.FindCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.repo.FindAttributes("attributes.category_id IN ?", ancestorIDs(categories, categoryID))
+}
+
+// applyAttributeRequest copies the request to the attribute, checking the category and the code.
+func (service *Service) applyAttributeRequest(attribute *models.Attribute, request models.AttributeRequest) error {
+	categories, err := service.repo.FindCategories()
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(categories, func(category models.Category) bool { return category.CategoryID == request.CategoryID }) {
+		return fmt.Errorf("no category found with id = %s", request.CategoryID)
+	}
+
+	// the codes are used as attr.<code> in the query string
+	code := strings.ReplaceAll(helper.Slugify(request.Code), "-", "_")
+	if code == "" {
+		return fmt.Errorf("please provide a code made of letters and digits")
+	}
+
+	if code == priceFacetCode {
+		return fmt.Errorf("the code %s is reserved for the price filter", code)
+	}
+
+	sameCode, err := service.repo.FindAttributes("attributes.code = ? AND attributes.attribute_id <> ?", code, attribute.AttributeID)
+	if err != nil {
+		return err
+	}
+
+	branch := append(ancestorIDs(categories, request.CategoryID), subtreeIDs(categories, request.CategoryID)...)
+
+	for _, other := range sameCode {
+		if slices.Contains(branch, other.CategoryID) {
+			return fmt.Errorf("the attribute %s is already defined for a parent or a subcategory of this category", code)
+		}
+
+		if other.Type != request.Type {
+			return fmt.Errorf("the attribute %s is already used with the type %s in another category", code, other.Type)
+		}
+	}
+
+	options := make(pq.StringArray, 0, len(request.Options))
+	if request.Type == constants.ATTRIBUTE_TYPE_ENUM {
+		for _, option := range request.Options {
+			option = strings.TrimSpace(option)
+			if !slices.Contains(options, option) {
+				options = append(options, option)
+			}
+		}
+	}
+
+	attribute.CategoryID = request.CategoryID
+	attribute.Code = code
+	attribute.Name = strings.TrimSpace(request.Name)
+	attribute.Type = request.Type
+	attribute.Options = options
+	attribute.Unit = strings.TrimSpace(request.Unit)
+	attribute.IsFilterable = request.IsFilterable
+	attribute.Position = request.Position
+
+	return nil
+}
+
+// attributeValue converts a value of the request into the stored value of the attribute, checking its type.
+func attributeValue(attribute models.Attribute, raw any) (models.ProductAttributeValue, error) {
+	value := models.ProductAttributeValue{AttributeID: attribute.AttributeID}
+
+	switch attribute.Type {
+	case constants.ATTRIBUTE_TYPE_NUMBER:
+		var number float64
+		switch typed := raw.(type) {
+		case float64:
+			number = typed
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+			if err != nil {
+				return value, fmt.Errorf("the attribute %s expects a number", attribute.Code)
+			}
+			number = parsed
+		default:
+			return value, fmt.Errorf("the attribute %s expects a number", attribute.Code)
+		}
+		value.Value = strconv.FormatFloat(number, 'f', -1, 64)
+		value.NumberValue = &number
+
+	case constants.ATTRIBUTE_TYPE_BOOLEAN:
+		flag, ok := raw.(bool)
+		if !ok {
+			return value, fmt.Errorf("the attribute %s expects true or false", attribute.Code)
+		}
+		value.Value = strconv.FormatBool(flag)
+
+	default:
+		text, ok := raw.(string)
+		if !ok || strings.TrimSpace(text) == "" || len(text) > 255 {
+			return value, fmt.Errorf("the attribute %s expects a text of at most 255 characters", attribute.Code)
+		}
+		value.Value = strings.TrimSpace(text)
+
+		if attribute.Type == constants.ATTRIBUTE_TYPE_ENUM && !slices.Contains(attribute.Options, value.Value) {
+			return value, fmt.Errorf("the attribute %s expects one of: %s", attribute.Code, strings.Join(attribute.Options, ", "))
+		}
+	}
+
+	return value, nil
+}
+
+// ancestorIDs returns the id of the category and of all its parents.
+func ancestorIDs(categories []models.Category, categoryID uuid.UUID) []uuid.UUID {
+	parents := make(map[uuid.UUID]*uuid.UUID, len(categories))
+	for _, category := range categories {
+		parents[category.CategoryID] = category.ParentID
+	}
+
+	ids := []uuid.UUID{categoryID}
+	for parent := parents[categoryID]; parent != nil && !slices.Contains(ids, *parent); parent = parents[*parent] {
+		ids = append(ids, *parent)
+	}
+
+	return ids
+}
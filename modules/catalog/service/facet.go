@@ -0,0 +1,359 @@
+package service
+
+import (
+	"e-commerce/modules/catalog/dbAccess"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// priceFacetCode is the code of the facet and of the filter on the variant prices.
+const priceFacetCode = "price"
+
+// attributeFilterOperators are the filter operators accepted by each attribute type (and by the price).
+var attributeFilterOperators = map[string][]string{
+	constants.ATTRIBUTE_TYPE_NUMBER:  {"eq", "ne", "gt", "gte", "lt", "lte", "between", "in"},
+	constants.ATTRIBUTE_TYPE_ENUM:    {"eq", "ne", "in"},
+	constants.ATTRIBUTE_TYPE_BOOLEAN: {"eq", "ne"},
+	constants.ATTRIBUTE_TYPE_TEXT:    {"eq", "ne", "in", "like"},
+	priceFacetCode:                   {"eq", "gt", "gte", "lt", "lte", "between", "in"},
+}
+
+// productFilters are the filters of a product list that depend on the catalog (category, attributes, price).
+type productFilters struct {
+	// categoryIDs are the category and its subcategories, nil when the list is not filtered by category
+	categoryIDs []uuid.UUID
+	// codes are the codes of the attributes that may apply to the products of the list, in position order
+	codes      []string
+	attributes map[string][]models.Attribute
+	conditions []filterCondition
+}
+
+// filterCondition is the WHERE clause of an attr.<code> or price filter.
+type filterCondition struct {
+	code  string
+	query string
+	args  []any
+}
+
+// parseProductFilters resolves the category and the attribute and price filters of the query parameters.
+// The attributes of the list are the ones of the category, of its parents and of its subcategories,
+// or all the attributes when the list is not filtered by category.
+func (service *Service) parseProductFilters(queryParams *models.ProductQueryParams) (*productFilters, error) {
+	filters := &productFilters{attributes: map[string][]models.Attribute{}}
+
+	var attributes []models.Attribute
+	var err error
+
+	if queryParams.CategoryID != nil {
+		var categories []models.Category
+		categories, err = service.repo.FindCategories()
+		if err != nil {
+			return nil, err
+		}
+
+		filters.categoryIDs = subtreeIDs(categories, *queryParams.CategoryID)
+		attributes, err = service.repo.FindAttributes("attributes.category_id IN ?", append(ancestorIDs(categories, *queryParams.CategoryID), filters.categoryIDs...))
+	} else {
+		attributes, err = service.repo.FindAttributes(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attribute := range attributes {
+		if _, ok := filters.attributes[attribute.Code]; !ok {
+			filters.codes = append(filters.codes, attribute.Code)
+		}
+		filters.attributes[attribute.Code] = append(filters.attributes[attribute.Code], attribute)
+	}
+
+	if queryParams.Price != nil {
+		if queryParams.Currency == nil {
+			return nil, fmt.Errorf("the price filter is in the minor unit of a currency, please give the currency too (e.g. price=between:1000,5000&currency=EUR)")
+		}
+
+		condition, err := priceCondition(*queryParams.Price, *queryParams.Currency)
+		if err != nil {
+			return nil, err
+		}
+		filters.conditions = append(filters.conditions, condition)
+	}
+
+	// sorted so the same filters always give the same query
+	codes := make([]string, 0, len(queryParams.Attributes))
+	for code := range queryParams.Attributes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		attributes := filters.attributes[code]
+		if len(attributes) == 0 || !attributes[0].IsFilterable {
+			return nil, fmt.Errorf("the products can not be filtered by attr.%s", code)
+		}
+
+		condition, err := attributeCondition(attributes, queryParams.Attributes[code])
+		if err != nil {
+			return nil, err
+		}
+		filters.conditions = append(filters.conditions, condition)
+	}
+
+	return filters, nil
+}
+
+// productFilter builds a fresh query of the products matching the query parameters and the filters,
+// leaving out the filter with the skipped code (used by the facet of that code).
+func (service *Service) productFilter(queryParams *models.ProductQueryParams, filters *productFilters, skip string) *gorm.DB {
+	filter := helper.BuildQuery(service.repo.GetProductFilter(), queryParams)
+
+	if filters.categoryIDs != nil {
+		filter = filter.Where("products.category_id IN ?", filters.categoryIDs)
+	}
+
+	for _, condition := range filters.conditions {
+		if condition.code != skip {
+			filter = filter.Where(condition.query, condition.args...)
+		}
+	}
+
+	return filter
+}
+
+// productFacets computes the facets of the product list: the value counts of the filterable enum and boolean
+// attributes, the range of the filterable number attributes and a price range per currency
+// (only the currency of the query parameters when given), as prices in different currencies do not compare.
+// The facet of a filtered attribute ignores its own filter, so the other values stay selectable.
+func (service *Service) productFacets(queryParams *models.ProductQueryParams, filters *productFilters) ([]models.Facet, error) {
+	filtered := map[string]bool{}
+	for _, condition := range filters.conditions {
+		filtered[condition.code] = true
+	}
+
+	// the facets of the codes without a filter share the same product list
+	var countIDs, rangeIDs []uuid.UUID
+	for _, code := range filters.codes {
+		attributes := filters.attributes[code]
+		if filtered[code] || !attributes[0].IsFilterable {
+			continue
+		}
+
+		switch attributes[0].Type {
+		case constants.ATTRIBUTE_TYPE_ENUM, constants.ATTRIBUTE_TYPE_BOOLEAN:
+			countIDs = append(countIDs, attributeIDs(attributes)...)
+		case constants.ATTRIBUTE_TYPE_NUMBER:
+			rangeIDs = append(rangeIDs, attributeIDs(attributes)...)
+		}
+	}
+
+	counts, ranges, err := service.facetValues(queryParams, filters, "", countIDs, rangeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := make([]models.Facet, 0, len(filters.codes)+1)
+
+	for _, code := range filters.codes {
+		attributes := filters.attributes[code]
+		if !attributes[0].IsFilterable || attributes[0].Type == constants.ATTRIBUTE_TYPE_TEXT {
+			continue
+		}
+
+		codeCounts, codeRanges := counts, ranges
+		if filtered[code] {
+			ids := attributeIDs(attributes)
+			if attributes[0].Type == constants.ATTRIBUTE_TYPE_NUMBER {
+				codeCounts, codeRanges, err = service.facetValues(queryParams, filters, code, nil, ids)
+			} else {
+				codeCounts, codeRanges, err = service.facetValues(queryParams, filters, code, ids, nil)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		facet := models.Facet{
+			Code: code,
+			Name: attributes[0].Name,
+			Type: attributes[0].Type,
+			Unit: attributes[0].Unit,
+		}
+
+		for _, attribute := range attributes {
+			facet.Values = addValueCounts(facet.Values, codeCounts, attribute.AttributeID)
+			facet.Min, facet.Max = widenRange(facet.Min, facet.Max, codeRanges, attribute.AttributeID)
+		}
+
+		if len(facet.Values) > 0 || facet.Min != nil {
+			sort.SliceStable(facet.Values, func(i, j int) bool { return facet.Values[i].Count > facet.Values[j].Count })
+			facets = append(facets, facet)
+		}
+	}
+
+	currency := ""
+	if queryParams.Currency != nil {
+		currency = *queryParams.Currency
+	}
+
+	priceRanges, err := service.repo.PriceRanges(service.productFilter(queryParams, filters, priceFacetCode).Select("products.product_id"), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, priceRange := range priceRanges {
+		facets = append(facets, models.Facet{
+			Code:     priceFacetCode,
+			Name:     "Price",
+			Type:     constants.ATTRIBUTE_TYPE_NUMBER,
+			Currency: priceRange.Currency,
+			Min:      priceRange.Min,
+			Max:      priceRange.Max,
+		})
+	}
+
+	return facets, nil
+}
+
+// facetValues counts the values and computes the ranges of the given attributes among the products of the list,
+// leaving out the filter with the skipped code.
+func (service *Service) facetValues(queryParams *models.ProductQueryParams, filters *productFilters, skip string, countIDs, rangeIDs []uuid.UUID) ([]dbAccess.ValueCount, []dbAccess.ValueRange, error) {
+	var counts []dbAccess.ValueCount
+	var ranges []dbAccess.ValueRange
+	var err error
+
+	if len(countIDs) > 0 {
+		counts, err = service.repo.CountValues(service.productFilter(queryParams, filters, skip).Select("products.product_id"), countIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(rangeIDs) > 0 {
+		ranges, err = service.repo.ValueRanges(service.productFilter(queryParams, filters, skip).Select("products.product_id"), rangeIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return counts, ranges, nil
+}
+
+// attributeCondition translates an attr.<code> filter into an EXISTS clause on the values of the attributes with the code.
+// A "ne" filter also matches the products without a value for the attribute.
+func attributeCondition(attributes []models.Attribute, expr models.FilterExpr) (filterCondition, error) {
+	attribute := attributes[0]
+
+	if !slices.Contains(attributeFilterOperators[attribute.Type], expr.Op) {
+		return filterCondition{}, fmt.Errorf("the %s filter is not supported by attr.%s", expr.Op, attribute.Code)
+	}
+
+	column := "product_attribute_values.value"
+	args := expr.Args()
+
+	switch attribute.Type {
+	case constants.ATTRIBUTE_TYPE_NUMBER:
+		column = "product_attribute_values.number_value"
+		numbers, err := expr.Numbers()
+		if err != nil {
+			return filterCondition{}, fmt.Errorf("attr.%s: %s", attribute.Code, err.Error())
+		}
+		args = numbers
+
+	case constants.ATTRIBUTE_TYPE_BOOLEAN:
+		for i, value := range expr.Values {
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return filterCondition{}, fmt.Errorf("attr.%s expects true or false", attribute.Code)
+			}
+			args[i] = strconv.FormatBool(flag)
+		}
+	}
+
+	exists := "EXISTS"
+	if expr.Op == "ne" {
+		exists = "NOT EXISTS"
+		expr.Op = "eq"
+	}
+
+	condition, conditionArgs := helper.FilterCondition(column, expr, args)
+
+	return filterCondition{
+		code: attribute.Code,
+		query: exists + " (SELECT 1 FROM product_attribute_values WHERE product_attribute_values.product_id = products.product_id" +
+			" AND product_attribute_values.attribute_id IN ? AND " + condition + ")",
+		args: append([]any{attributeIDs(attributes)}, conditionArgs...),
+	}, nil
+}
+
+// priceCondition translates a price filter into an EXISTS clause on the prices of the variants in the currency.
+// The amounts are in the minor unit of the currency, like the stored prices.
+func priceCondition(expr models.FilterExpr, currency string) (filterCondition, error) {
+	if !slices.Contains(attributeFilterOperators[priceFacetCode], expr.Op) {
+		return filterCondition{}, fmt.Errorf("the %s filter is not supported by price", expr.Op)
+	}
+
+	args, err := expr.Integers()
+	if err != nil {
+		return filterCondition{}, fmt.Errorf("price expects amounts in minor units: %s", err.Error())
+	}
+
+	condition, conditionArgs := helper.FilterCondition("product_variants.price", expr, args)
+
+	return filterCondition{
+		code: priceFacetCode,
+		query: "EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.product_id" +
+			" AND product_variants.deleted_at IS NULL AND product_variants.currency = ? AND " + condition + ")",
+		args: append([]any{currency}, conditionArgs...),
+	}, nil
+}
+
+// attributeIDs returns the ids of the attributes.
+func attributeIDs(attributes []models.Attribute) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(attributes))
+	for _, attribute := range attributes {
+		ids = append(ids, attribute.AttributeID)
+	}
+	return ids
+}
+
+// addValueCounts adds the value counts of the attribute to the values of a facet,
+// summing the counts of the attributes sharing the code.
+func addValueCounts(values []models.FacetValue, counts []dbAccess.ValueCount, attributeID uuid.UUID) []models.FacetValue {
+	for _, count := range counts {
+		if count.AttributeID != attributeID {
+			continue
+		}
+
+		if i := slices.IndexFunc(values, func(value models.FacetValue) bool { return value.Value == count.Value }); i >= 0 {
+			values[i].Count += count.Count
+		} else {
+			values = append(values, models.FacetValue{Value: count.Value, Count: count.Count})
+		}
+	}
+	return values
+}
+
+// widenRange widens the range of a facet to the value range of the attribute.
+func widenRange(low, high *float64, ranges []dbAccess.ValueRange, attributeID uuid.UUID) (*float64, *float64) {
+	for _, valueRange := range ranges {
+		if valueRange.AttributeID != attributeID || valueRange.Min == nil {
+			continue
+		}
+
+		if low == nil || *valueRange.Min < *low {
+			low = valueRange.Min
+		}
+		if high == nil || *valueRange.Max > *high {
+			high = valueRange.Max
+		}
+	}
+	return low, high
+}
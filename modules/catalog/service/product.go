@@ -0,0 +1,457 @@
+package service
+
+import (
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// productSortColumns are the columns the product list can be sorted by.
+var productSortColumns = map[string]string{
+	"name":       "products.name",
+	"created_at": "products.created_at",
+	"updated_at": "products.updated_at",
+	"product_id": "products.product_id",
+}
+
+// GetProducts retrieves one page of the active products matching the query parameters, with the facets of the list.
+// Filtering by category includes the products of its subcategories.
+//
+// Parameters:
+//
+//	queryParams (*models.ProductQueryParams): The filters (attributes and price included), pagination and sort.
+//
+// Returns:
+//
+//	[]models.ProductResponse: The products of the page with their variants and attributes.
+//	models.Pagination: The pagination details.
+//	[]models.Facet: The value counts and ranges of the filterable attributes and the price range.
+//	error: An error if the query parameters or the filters are invalid or the query fails.
+func (service *Service) GetProducts(queryParams *models.ProductQueryParams) ([]models.ProductResponse, models.Pagination, []models.Facet, error) {
+	queryParams.Status = &models.FilterExpr{Op: "eq", Values: []string{constants.PRODUCT_STATUS_ACTIVE}}
+
+	return service.findProducts(queryParams)
+}
+
+// GetProductsForAdmin retrieves one page of the products of any status matching the query parameters, with the facets of the list.
+//
+// Parameters:
+//
+//	queryParams (*models.ProductQueryParams): The filters (status, attributes and price included), pagination and sort.
+//
+// Returns:
+//
+//	[]models.ProductResponse: The products of the page with their variants and attributes.
+//	models.Pagination: The pagination details.
+//	[]models.Facet: The value counts and ranges of the filterable attributes and the price range.
+//	error: An error if the query parameters or the filters are invalid or the query fails.
+func (service *Service) GetProductsForAdmin(queryParams *models.ProductQueryParams) ([]models.ProductResponse, models.Pagination, []models.Facet, error) {
+	return service.findProducts(queryParams)
+}
+
+// GetProduct returns an active product with its variants, looked up by id or by slug.
+//
+// Parameters:
+//
+//	idOrSlug (string): The product id (uuid) or slug.
+//
+// Returns:
+//
+//	models.ProductResponse: The product.
+//	error: An error if no active product matches.
+func (service *Service) GetProduct(idOrSlug string) (models.ProductResponse, error) {
+	condition := "products.slug = ? AND products.status = ?"
+	if _, err := uuid.Parse(idOrSlug); err == nil {
+		condition = "products.product_id = ? AND products.status = ?"
+	}
+
+	product, err := service.repo.GetProductByCondition(condition, idOrSlug, constants.PRODUCT_STATUS_ACTIVE)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.ProductResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.ProductResponse{}, err
+	}
+
+	if product == nil {
+		return models.ProductResponse{}, fmt.Errorf("no product found with id or slug = %s", idOrSlug)
+	}
+
+	return product.ResponseObj(), nil
+}
+
+// GetProductForAdmin returns a product of any status with its variants.
+//
+// Parameters:
+//
+//	id (string): The product id (uuid).
+//
+// Returns:
+//
+//	models.ProductResponse: The product.
+//	error: An error if the product is not found.
+func (service *Service) GetProductForAdmin(id string) (models.ProductResponse, error) {
+	product, err := service.getProduct(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	return product.ResponseObj(), nil
+}
+
+// AddProduct creates a product, as a draft unless another status is given.
+// The slug is generated from the name when not given and must be unique.
+//
+// Parameters:
+//
+//	request (models.ProductRequest): The product details.
+//
+// Returns:
+//
+//	models.ProductResponse: The created product.
+//	error: An error if the category does not exist, the slug is taken, the product would be active without variants or the creation fails.
+func (service *Service) AddProduct(request models.ProductRequest) (models.ProductResponse, error) {
+	product := models.Product{}
+
+	if err := service.applyProductRequest(&product, request); err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	if err := service.repo.CreateProduct(&product); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.ProductResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.ProductResponse{}, err
+	}
+
+	return product.ResponseObj(), nil
+}
+
+// UpdateProduct replaces the details and the status of a product; the variants and the attributes are managed separately.
+// The values of the attributes that no longer apply after a move to another category are deleted.
+//
+// Parameters:
+//
+//	id (string): The product id (uuid).
+//	request (models.ProductRequest): The new product details.
+//
+// Returns:
+//
+//	models.ProductResponse: The updated product.
+//	error: An error if the product or the category is not found, the slug is taken or the product would be active without variants.
+func (service *Service) UpdateProduct(id string, request models.ProductRequest) (models.ProductResponse, error) {
+	product, err := service.getProduct(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	if err := service.applyProductRequest(product, request); err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	attributes, err := service.categoryAttributes(product.CategoryID)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	if err := service.repo.UpdateProduct(product, attributeIDs(attributes)); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.ProductResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.ProductResponse{}, err
+	}
+
+	return service.GetProductForAdmin(id)
+}
+
+// DeleteProduct deletes a product with its variants; the hooks registered with
+// services.RegisterProductDeletedHook then release what the other modules hold for it (e.g. its images).
+//
+// Parameters:
+//
+//	id (string): The product id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the product is not found or the deletion fails.
+func (service *Service) DeleteProduct(id string) (string, error) {
+	product, err := service.getProduct(id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.repo.DeleteProduct(product); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	services.ProductDeleted(product.ProductID)
+
+	return "Product deleted successfully.", nil
+}
+
+// AddVariant adds a variant to a product. The SKU must be unique across all the products.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//	request (models.VariantRequest): The variant details.
+//
+// Returns:
+//
+//	models.VariantResponse: The created variant.
+//	error: An error if the product is not found, the SKU is taken or the creation fails.
+func (service *Service) AddVariant(productID string, request models.VariantRequest) (models.VariantResponse, error) {
+	product, err := service.getProduct(productID)
+	if err != nil {
+		return models.VariantResponse{}, err
+	}
+
+	variant := models.ProductVariant{ProductID: product.ProductID}
+
+	if err := service.saveVariant(&variant, request); err != nil {
+		return models.VariantResponse{}, err
+	}
+
+	return variant.ResponseObj(), nil
+}
+
+// UpdateVariant replaces the details and the options of a variant of a product.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//	variantID (string): The variant id (uuid).
+//	request (models.VariantRequest): The new variant details.
+//
+// Returns:
+//
+//	models.VariantResponse: The updated variant.
+//	error: An error if the variant is not found, the SKU is taken or the update fails.
+func (service *Service) UpdateVariant(productID string, variantID string, request models.VariantRequest) (models.VariantResponse, error) {
+	_, variant, err := service.getVariant(productID, variantID)
+	if err != nil {
+		return models.VariantResponse{}, err
+	}
+
+	if err := service.saveVariant(variant, request); err != nil {
+		return models.VariantResponse{}, err
+	}
+
+	return variant.ResponseObj(), nil
+}
+
+// DeleteVariant deletes a variant of a product. The last variant of an active product can not be deleted.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//	variantID (string): The variant id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the variant is not found, is the last variant of an active product or the deletion fails.
+func (service *Service) DeleteVariant(productID string, variantID string) (string, error) {
+	product, variant, err := service.getVariant(productID, variantID)
+	if err != nil {
+		return "", err
+	}
+
+	if product.Status == constants.PRODUCT_STATUS_ACTIVE && len(product.Variants) == 1 {
+		return "", fmt.Errorf("an active product needs at least one variant, archive the product first")
+	}
+
+	if err := service.repo.DeleteVariant(variant); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Variant deleted successfully.", nil
+}
+
+// findProducts loads one page of the products matching the query parameters and the facets of the list.
+func (service *Service) findProducts(queryParams *models.ProductQueryParams) ([]models.ProductResponse, models.Pagination, []models.Facet, error) {
+	page, err := helper.ParsePageQuery(queryParams.PageQuery, productSortColumns, "product_id")
+	if err != nil {
+		return nil, models.Pagination{}, nil, err
+	}
+
+	filters, err := service.parseProductFilters(queryParams)
+	if err != nil {
+		return nil, models.Pagination{}, nil, err
+	}
+
+	keyset, keysetArgs := page.KeysetCondition()
+
+	products, total, err := service.repo.FindProductPage(service.productFilter(queryParams, filters, ""), keyset, keysetArgs, page.OrderBy(), page.Limit, page.Offset())
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, models.Pagination{}, nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, models.Pagination{}, nil, err
+	}
+
+	facets, err := service.productFacets(queryParams, filters)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, models.Pagination{}, nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, models.Pagination{}, nil, err
+	}
+
+	response := make([]models.ProductResponse, 0, len(products))
+	for _, product := range products {
+		response = append(response, product.ResponseObj())
+	}
+
+	var nextCursor string
+	if len(products) > 0 {
+		last := products[len(products)-1]
+		nextCursor = page.NextCursor(len(products), map[string]any{
+			"name":       last.Name,
+			"created_at": last.CreatedAt,
+			"updated_at": last.UpdatedAt,
+			"product_id": last.ProductID,
+		})
+	}
+
+	return response, page.Pagination(total, nextCursor), facets, nil
+}
+
+// getProduct parses the id and loads the product with its variants.
+func (service *Service) getProduct(id string) (*models.Product, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	product, err := service.repo.GetProductByCondition("products.product_id = ?", parsedUUID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, fmt.Errorf("no product found with id = %s", parsedUUID)
+	}
+
+	return product, nil
+}
+
+// getVariant loads the product and the variant, which must belong to the product.
+func (service *Service) getVariant(productID string, variantID string) (*models.Product, *models.ProductVariant, error) {
+	product, err := service.getProduct(productID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range product.Variants {
+		if product.Variants[i].VariantID.String() == strings.ToLower(variantID) {
+			return product, &product.Variants[i], nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no variant found with id = %s for this product", variantID)
+}
+
+// applyProductRequest copies the request to the product, checking the category, the slug and the status.
+func (service *Service) applyProductRequest(product *models.Product, request models.ProductRequest) error {
+	category, err := service.repo.GetCategoryByCondition("categories.category_id = ?", request.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	if category == nil {
+		return fmt.Errorf("no category found with id = %s", request.CategoryID)
+	}
+
+	slug := helper.Slugify(request.Slug)
+	if slug == "" {
+		slug = helper.Slugify(request.Name)
+	}
+
+	if slug == "" {
+		return fmt.Errorf("please provide a slug, the name has no letter or digit to generate it from")
+	}
+
+	existing, err := service.repo.GetProductByCondition("products.slug = ? AND products.product_id <> ?", slug, product.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("a product with slug %s already exists", slug)
+	}
+
+	status := request.Status
+	if status == "" {
+		status = constants.PRODUCT_STATUS_DRAFT
+	}
+
+	if status == constants.PRODUCT_STATUS_ACTIVE && len(product.Variants) == 0 {
+		return fmt.Errorf("a product needs at least one variant to be active, add the variants to the draft first")
+	}
+
+	product.CategoryID = category.CategoryID
+	product.Name = strings.TrimSpace(request.Name)
+	product.Slug = slug
+	product.Description = strings.TrimSpace(request.Description)
+	product.Status = status
+
+	return nil
+}
+
+// saveVariant copies the request to the variant, checks that the SKU is not taken and saves the variant with its options.
+func (service *Service) saveVariant(variant *models.ProductVariant, request models.VariantRequest) error {
+	sku := strings.ToUpper(strings.TrimSpace(request.SKU))
+
+	existing, err := service.repo.GetVariantByCondition("product_variants.sku = ? AND product_variants.variant_id <> ?", sku, variant.VariantID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("a variant with SKU %s already exists", sku)
+	}
+
+	variant.SKU = sku
+	variant.Name = strings.TrimSpace(request.Name)
+	variant.Price = request.Price
+	variant.Currency = strings.ToUpper(request.Currency)
+	variant.Position = request.Position
+	variant.Options = make([]models.VariantOption, 0, len(request.Options))
+
+	for name, value := range request.Options {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if slices.ContainsFunc(variant.Options, func(option models.VariantOption) bool { return option.Name == name }) {
+			return fmt.Errorf("the option %s is given more than once", name)
+		}
+
+		variant.Options = append(variant.Options, models.VariantOption{Name: name, Value: strings.TrimSpace(value)})
+	}
+
+	if err := service.repo.SaveVariant(variant); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return fmt.Errorf(pgErr.Detail)
+		}
+		return err
+	}
+
+	return nil
+}
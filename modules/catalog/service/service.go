@@ -0,0 +1,293 @@
+package service
+
+import (
+	"e-commerce/modules/catalog/dbAccess"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Service provides the product catalog: the category tree, the products and their variants.
+// The public operations only expose the active products; the admin operations see every status.
+type Service struct {
+	repo *dbAccess.Repo
+}
+
+// NewCatalogService creates and returns a new Catalog Service instance by initializing the repository.
+// Returns:
+//
+//	*Service: A pointer to a new Service instance with its repository initialized.
+func NewCatalogService() *Service {
+	repo := dbAccess.NewCatalogRepository()
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetCategoryTree returns the root categories with their subcategories nested in children,
+// ordered by position and name.
+//
+// Returns:
+//
+//	[]models.CategoryResponse: The category tree.
+//	error: An error if the query fails.
+func (service *Service) GetCategoryTree() ([]models.CategoryResponse, error) {
+	categories, err := service.repo.FindCategories()
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	return categoryTree(categories, nil), nil
+}
+
+// GetCategory returns a category with its subcategories nested in children.
+//
+// Parameters:
+//
+//	id (string): The category id (uuid).
+//
+// Returns:
+//
+//	models.CategoryResponse: The category and its subtree.
+//	error: An error if the category is not found.
+func (service *Service) GetCategory(id string) (models.CategoryResponse, error) {
+	category, err := service.getCategory(id)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	categories, err := service.repo.FindCategories()
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	response := category.ResponseObj()
+	response.Children = categoryTree(categories, &category.CategoryID)
+
+	return response, nil
+}
+
+// AddCategory creates a category, as a root category or under the given parent.
+// The slug is generated from the name when not given and must be unique.
+//
+// Parameters:
+//
+//	request (models.CategoryRequest): The category details.
+//
+// Returns:
+//
+//	models.CategoryResponse: The created category.
+//	error: An error if the parent does not exist, the slug is taken or the creation fails.
+func (service *Service) AddCategory(request models.CategoryRequest) (models.CategoryResponse, error) {
+	category := models.Category{}
+
+	if err := service.applyCategoryRequest(&category, request); err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	if err := service.repo.CreateCategory(&category); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.CategoryResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.CategoryResponse{}, err
+	}
+
+	return category.ResponseObj(), nil
+}
+
+// UpdateCategory replaces the details of a category. A category can be moved under another parent,
+// but not under itself or one of its own subcategories.
+//
+// Parameters:
+//
+//	id (string): The category id (uuid).
+//	request (models.CategoryRequest): The new category details.
+//
+// Returns:
+//
+//	models.CategoryResponse: The updated category.
+//	error: An error if the category or the parent is not found, the move would create a cycle, or the slug is taken.
+func (service *Service) UpdateCategory(id string, request models.CategoryRequest) (models.CategoryResponse, error) {
+	category, err := service.getCategory(id)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	if err := service.applyCategoryRequest(category, request); err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	if err := service.repo.UpdateCategory(category); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.CategoryResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.CategoryResponse{}, err
+	}
+
+	return category.ResponseObj(), nil
+}
+
+// DeleteCategory deletes a category. Categories with subcategories or products can not be deleted.
+//
+// Parameters:
+//
+//	id (string): The category id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the category is not found, still in use or the deletion fails.
+func (service *Service) DeleteCategory(id string) (string, error) {
+	category, err := service.getCategory(id)
+	if err != nil {
+		return "", err
+	}
+
+	children, products, err := service.repo.CountCategoryUsage(category.CategoryID)
+	if err != nil {
+		return "", err
+	}
+
+	if children > 0 || products > 0 {
+		return "", fmt.Errorf("the category still has %d subcategories and %d products, move or delete them first", children, products)
+	}
+
+	if err := service.repo.DeleteCategory(category); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Category deleted successfully.", nil
+}
+
+// getCategory parses the id and loads the category.
+func (service *Service) getCategory(id string) (*models.Category, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	category, err := service.repo.GetCategoryByCondition("categories.category_id = ?", parsedUUID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if category == nil {
+		return nil, fmt.Errorf("no category found with id = %s", parsedUUID)
+	}
+
+	return category, nil
+}
+
+// applyCategoryRequest copies the request to the category, checking the parent and the slug.
+func (service *Service) applyCategoryRequest(category *models.Category, request models.CategoryRequest) error {
+	if request.ParentID != nil {
+		categories, err := service.repo.FindCategories()
+		if err != nil {
+			return err
+		}
+
+		parents := make(map[uuid.UUID]*uuid.UUID, len(categories))
+		for _, existing := range categories {
+			parents[existing.CategoryID] = existing.ParentID
+		}
+
+		if _, ok := parents[*request.ParentID]; !ok {
+			return fmt.Errorf("no category found with id = %s", *request.ParentID)
+		}
+
+		// walking up from the new parent must not reach the category itself
+		for ancestor := request.ParentID; ancestor != nil; ancestor = parents[*ancestor] {
+			if *ancestor == category.CategoryID {
+				return fmt.Errorf("a category can not be moved under itself or one of its subcategories")
+			}
+		}
+	}
+
+	slug := helper.Slugify(request.Slug)
+	if slug == "" {
+		slug = helper.Slugify(request.Name)
+	}
+
+	if slug == "" {
+		return fmt.Errorf("please provide a slug, the name has no letter or digit to generate it from")
+	}
+
+	existing, err := service.repo.GetCategoryByCondition("categories.slug = ? AND categories.category_id <> ?", slug, category.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("a category with slug %s already exists", slug)
+	}
+
+	category.ParentID = request.ParentID
+	category.Name = strings.TrimSpace(request.Name)
+	category.Slug = slug
+	category.Description = strings.TrimSpace(request.Description)
+	category.Position = request.Position
+
+	return nil
+}
+
+// categoryTree nests the categories under their parent, starting from the children of root (nil for the root categories).
+// The categories are expected in display order.
+func categoryTree(categories []models.Category, root *uuid.UUID) []models.CategoryResponse {
+	children := make(map[uuid.UUID][]models.Category)
+	var roots []models.Category
+
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var build func(level []models.Category) []models.CategoryResponse
+	build = func(level []models.Category) []models.CategoryResponse {
+		response := make([]models.CategoryResponse, 0, len(level))
+		for _, category := range level {
+			node := category.ResponseObj()
+			node.Children = build(children[category.CategoryID])
+			response = append(response, node)
+		}
+		return response
+	}
+
+	if root != nil {
+		return build(children[*root])
+	}
+
+	return build(roots)
+}
+
+// subtreeIDs returns the id of the category and of all its subcategories.
+func subtreeIDs(categories []models.Category, root uuid.UUID) []uuid.UUID {
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.CategoryID)
+		}
+	}
+
+	ids := []uuid.UUID{root}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+
+	return ids
+}
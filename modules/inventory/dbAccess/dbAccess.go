@@ -0,0 +1,606 @@
+package dbAccess
+
+import (
+	"cmp"
+	"e-commerce/database/connections"
+	"e-commerce/utils/constants"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"e-commerce/base"
+	"e-commerce/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repo defines a concrete implementation of inventory-specific repository
+// using the generic BaseRepository from the shared layer.
+type Repo struct {
+	warehouseBase   base.BaseRepository[models.Warehouse]
+	levelBase       base.BaseRepository[models.StockLevel]
+	reservationBase base.BaseRepository[models.StockReservation]
+	movementBase    base.BaseRepository[models.StockMovement]
+	variantBase     base.BaseRepository[models.ProductVariant]
+}
+
+// StockChange is a change of the stock on hand of a variant in a warehouse, recorded in the ledger.
+type StockChange struct {
+	VariantID   uuid.UUID
+	WarehouseID uuid.UUID
+	Type        string
+	// signed change of the stock on hand
+	Quantity  int64
+	Reference string
+	Note      string
+	CreatedBy *uuid.UUID
+}
+
+// InsufficientStockError reports a variant whose available stock can not cover the requested quantity.
+type InsufficientStockError struct {
+	VariantID uuid.UUID
+	Available int64
+}
+
+func (err *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for the variant %s, %d available", err.VariantID, err.Available)
+}
+
+// ErrReservationExpired is returned when committing reservations which have expired.
+var ErrReservationExpired = errors.New("the reservation has expired")
+
+// ErrReservationExists is returned when reserving stock under a reference which already has active reservations.
+var ErrReservationExists = errors.New("the reference already has active reservations")
+
+// NewInventoryRepository creates a new instance of the Inventory repository.
+// Returns:
+// - *Repo: Pointer to a new Repo with injected DB and Redis client.
+func NewInventoryRepository() *Repo {
+	return &Repo{
+		warehouseBase:   *base.NewBaseRepository[models.Warehouse](connections.GetDB(), connections.GetRedisClient()),
+		levelBase:       *base.NewBaseRepository[models.StockLevel](connections.GetDB(), connections.GetRedisClient()),
+		reservationBase: *base.NewBaseRepository[models.StockReservation](connections.GetDB(), connections.GetRedisClient()),
+		movementBase:    *base.NewBaseRepository[models.StockMovement](connections.GetDB(), connections.GetRedisClient()),
+		variantBase:     *base.NewBaseRepository[models.ProductVariant](connections.GetDB(), connections.GetRedisClient()),
+	}
+}
+
+// FindWarehouses retrieves all the warehouses ordered by code.
+// Returns:
+// - []models.Warehouse: Slice of warehouses.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindWarehouses() ([]models.Warehouse, error) {
+	warehouses, _, err := repo.warehouseBase.FindAll(nil, "code", 0, 0)
+	return warehouses, err
+}
+
+// GetWarehouseByCondition retrieves a warehouse matching the given condition.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.Warehouse: Pointer to the warehouse, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetWarehouseByCondition(condition any, args ...any) (*models.Warehouse, error) {
+	return repo.warehouseBase.GetByCondition(condition, args...)
+}
+
+// CreateWarehouse inserts a new warehouse.
+// Parameters:
+// - warehouse (*models.Warehouse): Pointer to the warehouse to create.
+// Returns:
+// - error: Error if any occurred during insertion.
+func (repo Repo) CreateWarehouse(warehouse *models.Warehouse) error {
+	return repo.warehouseBase.Create(warehouse)
+}
+
+// UpdateWarehouse saves the warehouse.
+// Parameters:
+// - warehouse (*models.Warehouse): Pointer to the warehouse to update.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) UpdateWarehouse(warehouse *models.Warehouse) error {
+	return repo.warehouseBase.Update(warehouse)
+}
+
+// GetVariantBySKU retrieves the variant with the given SKU.
+// Parameters:
+// - sku (string): The SKU of the variant.
+// Returns:
+// - *models.ProductVariant: Pointer to the variant, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetVariantBySKU(sku string) (*models.ProductVariant, error) {
+	return repo.variantBase.GetByCondition("product_variants.sku = ?", sku)
+}
+
+// FindVariantsBySKU retrieves the variants with the given SKUs.
+// Parameters:
+// - skus ([]string): The SKUs of the variants.
+// Returns:
+// - []models.ProductVariant: Slice of the variants found.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindVariantsBySKU(skus []string) ([]models.ProductVariant, error) {
+	return repo.variantBase.FindAllByCondition("product_variants.sku IN ?", skus)
+}
+
+// FindStockLevels retrieves the stock levels of a variant with their warehouse, ordered by warehouse code.
+// Parameters:
+// - variantID (uuid.UUID): The variant id.
+// Returns:
+// - []models.StockLevel: Slice of stock levels.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindStockLevels(variantID uuid.UUID) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	err := repo.levelBase.DB.Joins("Variant").Joins("Warehouse").
+		Where("stock_levels.variant_id = ?", variantID).
+		Order(`"Warehouse".code`).
+		Find(&levels).Error
+	return levels, err
+}
+
+// GetStockLevel retrieves the stock level of a variant in a warehouse with the variant and the warehouse.
+// Parameters:
+// - variantID (uuid.UUID): The variant id.
+// - warehouseID (uuid.UUID): The warehouse id.
+// Returns:
+// - *models.StockLevel: Pointer to the stock level, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetStockLevel(variantID uuid.UUID, warehouseID uuid.UUID) (*models.StockLevel, error) {
+	var level models.StockLevel
+	err := repo.levelBase.DB.Joins("Variant").Joins("Warehouse").
+		Where("stock_levels.variant_id = ? AND stock_levels.warehouse_id = ?", variantID, warehouseID).
+		First(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &level, err
+}
+
+// GetLowStockFilter returns a GORM query builder selecting the stock levels whose available quantity
+// is at or below their threshold, the default threshold applying to the levels without their own.
+// The levels of the deleted variants and warehouses are left out.
+// Parameters:
+// - defaultThreshold (int64): The threshold of the levels without their own threshold.
+// Returns:
+// - *gorm.DB: GORM DB model scoped to StockLevel.
+func (repo Repo) GetLowStockFilter(defaultThreshold int64) *gorm.DB {
+	return repo.levelBase.DB.Model(&models.StockLevel{}).Joins("Variant").Joins("Warehouse").
+		Where(`"Variant".variant_id IS NOT NULL AND "Warehouse".warehouse_id IS NOT NULL`).
+		Where("stock_levels.on_hand - stock_levels.reserved <= COALESCE(stock_levels.low_stock_threshold, ?)", defaultThreshold)
+}
+
+// FindStockLevelPage retrieves one page of the stock levels matching filters, and the total number of matching levels.
+// Parameters:
+// - filters (*gorm.DB): A query builder with filter conditions, joining the variant and the warehouse.
+// - keyset (string): SQL WHERE clause selecting the rows after the cursor (can be empty).
+// - keysetArgs ([]any): Arguments for the keyset clause.
+// - orderBy (string): Order clause of the page.
+// - limit (int): Number of records per page.
+// - offset (int): Offset for pagination.
+// Returns:
+// - []models.StockLevel: Slice of stock levels.
+// - int64: Total number of stock levels found.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindStockLevelPage(filters *gorm.DB, keyset string, keysetArgs []any, orderBy string, limit, offset int) ([]models.StockLevel, int64, error) {
+	return repo.levelBase.FindPage(filters, keyset, keysetArgs, orderBy, limit, offset)
+}
+
+// SetLowStockThreshold sets the low stock threshold of a variant in a warehouse, creating its
+// stock level if needed. A nil threshold restores the default threshold.
+// Parameters:
+// - variantID (uuid.UUID): The variant id.
+// - warehouseID (uuid.UUID): The warehouse id.
+// - threshold (*int64): The threshold, or nil.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) SetLowStockThreshold(variantID uuid.UUID, warehouseID uuid.UUID, threshold *int64) error {
+	level := models.StockLevel{VariantID: variantID, WarehouseID: warehouseID, LowStockThreshold: threshold}
+	return repo.levelBase.DB.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "variant_id"}, {Name: "warehouse_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"low_stock_threshold", "updated_at"}),
+	}).Create(&level).Error
+}
+
+// AdjustStock changes the stock on hand of a variant in a warehouse and records the change in the ledger,
+// in one transaction. The stock level is created if needed and locked while it changes; the stock on hand
+// can not drop below the reserved quantity.
+// Parameters:
+// - change (StockChange): The change of the stock on hand.
+// Returns:
+// - error: *InsufficientStockError if the stock would drop below the reserved quantity, or any other error
+// that occurred, in which case nothing is changed.
+func (repo Repo) AdjustStock(change StockChange) error {
+	return repo.levelBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "variant_id"}, {Name: "warehouse_id"}},
+			DoNothing: true,
+		}).Create(&models.StockLevel{VariantID: change.VariantID, WarehouseID: change.WarehouseID}).Error; err != nil {
+			return err
+		}
+
+		levels, err := lockStockLevels(tx, "stock_levels.variant_id = ? AND stock_levels.warehouse_id = ?", change.VariantID, change.WarehouseID)
+		if err != nil {
+			return err
+		}
+		if len(levels) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		level := levels[0]
+
+		if level.OnHand+change.Quantity < level.Reserved {
+			return &InsufficientStockError{VariantID: change.VariantID, Available: level.OnHand - level.Reserved}
+		}
+
+		if err := changeStockLevel(tx, level.StockLevelID, change.Quantity, 0); err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations).Create(&models.StockMovement{
+			VariantID:   change.VariantID,
+			WarehouseID: change.WarehouseID,
+			Type:        change.Type,
+			Quantity:    change.Quantity,
+			OnHandAfter: level.OnHand + change.Quantity,
+			Reference:   change.Reference,
+			Note:        change.Note,
+			CreatedBy:   change.CreatedBy,
+		}).Error
+	})
+}
+
+// ReserveStock reserves quantities of variants under a reference, in one transaction: either all the
+// quantities are reserved or none. The stock levels of the variants in the active warehouses are locked
+// and each quantity is taken from the warehouses with the most available stock first, so it is split
+// across warehouses only when no single warehouse can cover it. The reference is locked for the
+// transaction (advisory lock), so the concurrent reservations under the same reference wait for each other.
+// Parameters:
+// - reference (string): The reference grouping the reservations (e.g. the checkout id).
+// - quantities (map[uuid.UUID]int64): The quantity to reserve, by variant id.
+// - expiresAt (time.Time): The expiry of the reservations.
+// Returns:
+// - []models.StockReservation: The reservations created.
+// - error: ErrReservationExists if the reference already has active reservations, *InsufficientStockError
+// for the first variant without enough available stock, or any other error that occurred, in which case
+// nothing is reserved.
+func (repo Repo) ReserveStock(reference string, quantities map[uuid.UUID]int64, expiresAt time.Time) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+
+	variantIDs := make([]uuid.UUID, 0, len(quantities))
+	for variantID := range quantities {
+		variantIDs = append(variantIDs, variantID)
+	}
+	slices.SortFunc(variantIDs, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+
+	err := repo.levelBase.DB.Transaction(func(tx *gorm.DB) error {
+		// the reservations of a reference are made one at a time, so two concurrent requests can not both
+		// find no active reservation; a reference is split across warehouses, so a unique index can not do it
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", reference).Error; err != nil {
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&models.StockReservation{}).
+			Where("stock_reservations.reference = ? AND stock_reservations.status = ?", reference, constants.RESERVATION_STATUS_ACTIVE).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrReservationExists
+		}
+
+		levels, err := lockStockLevels(tx, "stock_levels.variant_id IN ? AND stock_levels.warehouse_id IN (?)", variantIDs,
+			tx.Model(&models.Warehouse{}).Select("warehouse_id").Where("warehouses.is_active"))
+		if err != nil {
+			return err
+		}
+
+		byVariant := make(map[uuid.UUID][]models.StockLevel, len(variantIDs))
+		for _, level := range levels {
+			byVariant[level.VariantID] = append(byVariant[level.VariantID], level)
+		}
+
+		for _, variantID := range variantIDs {
+			candidates := byVariant[variantID]
+			slices.SortStableFunc(candidates, func(a, b models.StockLevel) int {
+				return cmp.Compare(b.OnHand-b.Reserved, a.OnHand-a.Reserved)
+			})
+
+			remaining := quantities[variantID]
+			var available int64
+			for _, level := range candidates {
+				available += level.OnHand - level.Reserved
+			}
+			if available < remaining {
+				return &InsufficientStockError{VariantID: variantID, Available: available}
+			}
+
+			for _, level := range candidates {
+				if remaining == 0 {
+					break
+				}
+
+				taken := min(remaining, level.OnHand-level.Reserved)
+				if taken <= 0 {
+					continue
+				}
+
+				if err := changeStockLevel(tx, level.StockLevelID, 0, taken); err != nil {
+					return err
+				}
+
+				reservations = append(reservations, models.StockReservation{
+					Reference:   reference,
+					VariantID:   variantID,
+					WarehouseID: level.WarehouseID,
+					Quantity:    taken,
+					Status:      constants.RESERVATION_STATUS_ACTIVE,
+					ExpiresAt:   expiresAt,
+				})
+				remaining -= taken
+			}
+		}
+
+		return tx.Omit(clause.Associations).Create(&reservations).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// CommitReservations turns the active reservations of a reference into sales, in one transaction: the
+// reserved quantities leave the stock on hand, a sale is recorded in the ledger for each reservation and
+// the reservations are committed. The reservations and their stock levels are locked while they change.
+// Parameters:
+// - reference (string): The reference of the reservations.
+// Returns:
+// - []models.StockReservation: The committed reservations, empty if the reference has no active reservation.
+// - error: ErrReservationExpired if a reservation has expired, or any other error that occurred,
+// in which case nothing is changed.
+func (repo Repo) CommitReservations(reference string) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+
+	err := repo.reservationBase.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservations, err = lockReservations(tx, clause.Locking{Strength: "UPDATE"}, 0,
+			"stock_reservations.reference = ?", reference)
+		if err != nil || len(reservations) == 0 {
+			return err
+		}
+
+		for _, reservation := range reservations {
+			if !reservation.ExpiresAt.After(time.Now()) {
+				return ErrReservationExpired
+			}
+		}
+
+		levels, err := lockReservedLevels(tx, reservations)
+		if err != nil {
+			return err
+		}
+
+		movements := make([]models.StockMovement, 0, len(reservations))
+		for _, reservation := range reservations {
+			level := levels[[2]uuid.UUID{reservation.VariantID, reservation.WarehouseID}]
+			if err := changeStockLevel(tx, level.StockLevelID, -reservation.Quantity, -reservation.Quantity); err != nil {
+				return err
+			}
+			level.OnHand -= reservation.Quantity
+
+			movements = append(movements, models.StockMovement{
+				VariantID:   reservation.VariantID,
+				WarehouseID: reservation.WarehouseID,
+				Type:        constants.STOCK_MOVEMENT_SALE,
+				Quantity:    -reservation.Quantity,
+				OnHandAfter: level.OnHand,
+				Reference:   reference,
+			})
+		}
+
+		if err := tx.Omit(clause.Associations).Create(&movements).Error; err != nil {
+			return err
+		}
+
+		return setReservationStatus(tx, reservations, constants.RESERVATION_STATUS_COMMITTED)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// ReleaseReservations releases the active reservations of a reference, giving their stock back, in one transaction.
+// Parameters:
+// - reference (string): The reference of the reservations.
+// Returns:
+// - int64: The number of reservations released.
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) ReleaseReservations(reference string) (int64, error) {
+	return repo.releaseReservations(clause.Locking{Strength: "UPDATE"}, 0, constants.RESERVATION_STATUS_RELEASED,
+		"stock_reservations.reference = ?", reference)
+}
+
+// ExpireReservations expires a batch of the active reservations past their expiry, giving their stock back.
+// The reservations locked by another transaction (e.g. being committed) are skipped.
+// Parameters:
+// - batchSize (int): The largest number of reservations expired.
+// Returns:
+// - int64: The number of reservations expired.
+// - error: Error if any occurred, in which case nothing is changed.
+func (repo Repo) ExpireReservations(batchSize int) (int64, error) {
+	return repo.releaseReservations(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}, batchSize, constants.RESERVATION_STATUS_EXPIRED,
+		"stock_reservations.expires_at <= ?", time.Now())
+}
+
+// FindReservations retrieves the reservations of a reference with their variant, in creation order.
+// Parameters:
+// - reference (string): The reference of the reservations.
+// Returns:
+// - []models.StockReservation: Slice of reservations.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindReservations(reference string) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	err := repo.reservationBase.DB.Preload("Variant", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("stock_reservations.reference = ?", reference).
+		Order("stock_reservations.created_at, stock_reservations.reservation_id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// GetMovementFilter returns a GORM query builder for the StockMovement model.
+// Returns:
+// - *gorm.DB: GORM DB model scoped to StockMovement.
+func (repo Repo) GetMovementFilter() *gorm.DB {
+	return repo.movementBase.DB.Model(&models.StockMovement{})
+}
+
+// FindMovementPage retrieves one page of the stock movements matching filters with their variant
+// (even deleted, the ledger keeping the history), and the total number of matching movements.
+// Parameters:
+// - filters (*gorm.DB): A query builder with filter conditions.
+// - keyset (string): SQL WHERE clause selecting the rows after the cursor (can be empty).
+// - keysetArgs ([]any): Arguments for the keyset clause.
+// - orderBy (string): Order clause of the page.
+// - limit (int): Number of records per page.
+// - offset (int): Offset for pagination.
+// Returns:
+// - []models.StockMovement: Slice of stock movements.
+// - int64: Total number of stock movements found.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindMovementPage(filters *gorm.DB, keyset string, keysetArgs []any, orderBy string, limit, offset int) ([]models.StockMovement, int64, error) {
+	movements, total, err := repo.movementBase.FindPage(filters, keyset, keysetArgs, orderBy, limit, offset)
+	if err != nil || len(movements) == 0 {
+		return movements, total, err
+	}
+
+	variantIDs := make([]uuid.UUID, 0, len(movements))
+	for _, movement := range movements {
+		variantIDs = append(variantIDs, movement.VariantID)
+	}
+
+	var variants []models.ProductVariant
+	if err := repo.variantBase.DB.Unscoped().Where("product_variants.variant_id IN ?", variantIDs).Find(&variants).Error; err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[uuid.UUID]models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		byID[variant.VariantID] = variant
+	}
+
+	for i := range movements {
+		movements[i].Variant = byID[movements[i].VariantID]
+	}
+
+	return movements, total, nil
+}
+
+// releaseReservations gives the stock of the active reservations matching the condition back and sets their status.
+func (repo Repo) releaseReservations(locking clause.Locking, limit int, status string, condition string, args ...any) (int64, error) {
+	var released int64
+
+	err := repo.reservationBase.DB.Transaction(func(tx *gorm.DB) error {
+		reservations, err := lockReservations(tx, locking, limit, condition, args...)
+		if err != nil || len(reservations) == 0 {
+			return err
+		}
+
+		levels, err := lockReservedLevels(tx, reservations)
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range reservations {
+			level := levels[[2]uuid.UUID{reservation.VariantID, reservation.WarehouseID}]
+			if err := changeStockLevel(tx, level.StockLevelID, 0, -reservation.Quantity); err != nil {
+				return err
+			}
+		}
+
+		released = int64(len(reservations))
+		return setReservationStatus(tx, reservations, status)
+	})
+
+	return released, err
+}
+
+// lockStockLevels selects the stock levels matching the condition FOR UPDATE, in a stable order
+// so concurrent transactions lock them in the same order.
+func lockStockLevels(tx *gorm.DB, condition string, args ...any) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(condition, args...).
+		Order("stock_levels.stock_level_id").
+		Find(&levels).Error
+	return levels, err
+}
+
+// lockReservedLevels locks the stock levels holding the reservations, by variant and warehouse.
+func lockReservedLevels(tx *gorm.DB, reservations []models.StockReservation) (map[[2]uuid.UUID]*models.StockLevel, error) {
+	pairs := make([][]any, 0, len(reservations))
+	for _, reservation := range reservations {
+		pairs = append(pairs, []any{reservation.VariantID, reservation.WarehouseID})
+	}
+
+	levels, err := lockStockLevels(tx, "(stock_levels.variant_id, stock_levels.warehouse_id) IN ?", pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	byPair := make(map[[2]uuid.UUID]*models.StockLevel, len(levels))
+	for i := range levels {
+		byPair[[2]uuid.UUID{levels[i].VariantID, levels[i].WarehouseID}] = &levels[i]
+	}
+
+	for _, reservation := range reservations {
+		if byPair[[2]uuid.UUID{reservation.VariantID, reservation.WarehouseID}] == nil {
+			return nil, fmt.Errorf("no stock level for the reservation %s", reservation.ReservationID)
+		}
+	}
+
+	return byPair, nil
+}
+
+// lockReservations selects the active reservations matching the condition FOR UPDATE, at most limit when limit is positive.
+func lockReservations(tx *gorm.DB, locking clause.Locking, limit int, condition string, args ...any) ([]models.StockReservation, error) {
+	query := tx.Clauses(locking).
+		Where("stock_reservations.status = ?", constants.RESERVATION_STATUS_ACTIVE).
+		Where(condition, args...).
+		Order("stock_reservations.reservation_id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var reservations []models.StockReservation
+	err := query.Find(&reservations).Error
+	return reservations, err
+}
+
+// changeStockLevel adds the deltas to the stock on hand and to the reserved quantity of a stock level;
+// the checks of the table refuse a negative stock or a reserved quantity above the stock on hand.
+func changeStockLevel(tx *gorm.DB, levelID uuid.UUID, onHandDelta int64, reservedDelta int64) error {
+	return tx.Model(&models.StockLevel{}).Where("stock_levels.stock_level_id = ?", levelID).Updates(map[string]any{
+		"on_hand":    gorm.Expr("on_hand + ?", onHandDelta),
+		"reserved":   gorm.Expr("reserved + ?", reservedDelta),
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// setReservationStatus sets the status of the reservations.
+func setReservationStatus(tx *gorm.DB, reservations []models.StockReservation, status string) error {
+	reservationIDs := make([]uuid.UUID, 0, len(reservations))
+	for i := range reservations {
+		reservationIDs = append(reservationIDs, reservations[i].ReservationID)
+		reservations[i].Status = status
+	}
+
+	return tx.Model(&models.StockReservation{}).Where("stock_reservations.reservation_id IN ?", reservationIDs).Updates(map[string]any{
+		"status":     status,
+		"updated_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/modules/inventory/service"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewInventoryHandler() *Handler {
+	service := service.NewInventoryService()
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetWarehouses godoc
+// @Summary      List the Warehouses
+// @Description  Returns all the warehouses ordered by code.
+// @Tags         Inventory Administration
+// @Produce      json
+// @Success      200  {object}  models.SuccessResponse[[]models.WarehouseResponse]  "Warehouses"
+// @Failure      403  {object}  models.ForbiddenError                             "Missing inventory.manage permission"
+// @Router       /admin/inventory/warehouses [get]
+func (handler *Handler) GetWarehouses(context *gin.Context) {
+	warehouses, err := handler.service.GetWarehouses()
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, warehouses)
+}
+
+// AddWarehouse godoc
+// @Summary      Add a Warehouse
+// @Description  Creates a warehouse; its code is stored in upper case and must be unique. Only the active warehouses take reservations.
+// @Tags         Inventory Administration
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.WarehouseRequest                           true  "Warehouse details"
+// @Success      201      {object}  models.SuccessResponse[models.WarehouseResponse]  "Warehouse created"
+// @Failure      400      {object}  models.BadRequestError                            "Invalid request data or code already used"
+// @Failure      403      {object}  models.ForbiddenError                             "Missing inventory.manage permission"
+// @Router       /admin/inventory/warehouses [post]
+func (handler *Handler) AddWarehouse(context *gin.Context) {
+	request := models.WarehouseRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	warehouse, err := handler.service.AddWarehouse(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusCreated, warehouse)
+}
+
+// UpdateWarehouse godoc
+// @Summary      Update a Warehouse
+// @Description  Replaces the details of a warehouse. An inactive warehouse keeps its stock and its reservations but takes no new reservation.
+// @Tags         Inventory Administration
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                            true  "Warehouse ID"
+// @Param        request  body      models.WarehouseRequest                           true  "New warehouse details"
+// @Success      200      {object}  models.SuccessResponse[models.WarehouseResponse]  "Warehouse updated"
+// @Failure      400      {object}  models.BadRequestError                            "Invalid request data, warehouse not found or code already used"
+// @Failure      403      {object}  models.ForbiddenError                             "Missing inventory.manage permission"
+// @Router       /admin/inventory/warehouses/{id} [put]
+func (handler *Handler) UpdateWarehouse(context *gin.Context) {
+	request := models.WarehouseRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	warehouse, err := handler.service.UpdateWarehouse(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, warehouse)
+}
+
+// GetStock godoc
+// @Summary      Get the Stock of a SKU
+// @Description  Returns the stock on hand, the reserved and the available quantities of a SKU in each warehouse holding it.
+// @Tags         Inventory Administration
+// @Produce      json
+// @Param        sku  path      string                                               true  "SKU of the variant"
+// @Success      200  {object}  models.SuccessResponse[[]models.StockLevelResponse]  "Stock levels"
+// @Failure      400  {object}  models.BadRequestError                               "SKU not found"
+// @Failure      403  {object}  models.ForbiddenError                                "Missing inventory.manage permission"
+// @Router       /admin/inventory/stock/{sku} [get]
+func (handler *Handler) GetStock(context *gin.Context) {
+	levels, err := handler.service.GetStock(context.Param("sku"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, levels)
+}
+
+// AdjustStock godoc
+// @Summary      Adjust the Stock of a SKU
+// @Description  Records a receipt, a return (positive quantities) or an adjustment (signed quantity) of the stock of a SKU in a warehouse, in the stock movement ledger. The stock on hand can not drop below the reserved quantity.
+// @Tags         Inventory Administration
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.StockAdjustmentRequest                      true  "SKU, warehouse and quantity"
+// @Success      200      {object}  models.SuccessResponse[models.StockLevelResponse]  "Stock level after the change"
+// @Failure      400      {object}  models.BadRequestError                             "Invalid request data, SKU or warehouse not found, or insufficient stock"
+// @Failure      401      {object}  models.UnauthorizedError                           "Unauthorized"
+// @Failure      403      {object}  models.ForbiddenError                              "Missing inventory.manage permission"
+// @Router       /admin/inventory/adjustments [post]
+func (handler *Handler) AdjustStock(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	request := models.StockAdjustmentRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	level, err := handler.service.AdjustStock(request, user.UserID)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, level)
+}
+
+// SetLowStockThreshold godoc
+// @Summary      Set the Low Stock Threshold of a SKU
+// @Description  Sets the available quantity at or below which the stock of a SKU in a warehouse is low; null restores the configured default threshold.
+// @Tags         Inventory Administration
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.StockThresholdRequest                       true  "SKU, warehouse and threshold"
+// @Success      200      {object}  models.SuccessResponse[models.StockLevelResponse]  "Stock level with its threshold"
+// @Failure      400      {object}  models.BadRequestError                             "Invalid request data, SKU or warehouse not found"
+// @Failure      403      {object}  models.ForbiddenError                              "Missing inventory.manage permission"
+// @Router       /admin/inventory/thresholds [put]
+func (handler *Handler) SetLowStockThreshold(context *gin.Context) {
+	request := models.StockThresholdRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	level, err := handler.service.SetLowStockThreshold(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, level)
+}
+
+// GetLowStock godoc
+// @Summary      List the Low Stock
+// @Description  Returns one page of the stock levels whose available quantity (on hand minus reserved) is at or below their threshold, the lowest first by default.
+// @Tags         Inventory Administration
+// @Produce      json
+// @Param        warehouse_id  query     string  false  "Filter by warehouse id"
+// @Param        page          query     int     false  "Page number, starting from 1"
+// @Param        limit         query     int     false  "Page size"
+// @Param        cursor        query     string  false  "Cursor of the page (next_cursor of the previous page)"
+// @Param        sort          query     string  false  "Sort columns (available, updated_at), prefixed with - for descending order"
+// @Success      200           {object}  models.ResponseWithPagination  "Page of low stock levels"
+// @Failure      400           {object}  models.BadRequestError         "Invalid query parameters"
+// @Failure      403           {object}  models.ForbiddenError          "Missing inventory.manage permission"
+// @Router       /admin/inventory/low-stock [get]
+func (handler *Handler) GetLowStock(context *gin.Context) {
+	queryParams := &models.LowStockQueryParams{}
+
+	if err := context.ShouldBindQuery(queryParams); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	levels, pagination, err := handler.service.GetLowStock(queryParams)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.PaginatedResponseWriter(context, levels, pagination)
+}
+
+// GetMovements godoc
+// @Summary      List the Stock Movements
+// @Description  Returns one page of the stock movement ledger (receipts, adjustments, sales and returns), the latest first by default.
+// @Tags         Inventory Administration
+// @Produce      json
+// @Param        sku           query     string  false  "Filter by SKU"
+// @Param        warehouse_id  query     string  false  "Filter by warehouse id"
+// @Param        type          query     string  false  "Filter by type (receipt, adjustment, sale or return)"
+// @Param        reference     query     string  false  "Filter by reference"
+// @Param        page          query     int     false  "Page number, starting from 1"
+// @Param        limit         query     int     false  "Page size"
+// @Param        cursor        query     string  false  "Cursor of the page (next_cursor of the previous page)"
+// @Param        sort          query     string  false  "Sort columns (created_at), prefixed with - for descending order"
+// @Success      200           {object}  models.ResponseWithPagination  "Page of stock movements"
+// @Failure      400           {object}  models.BadRequestError         "Invalid query parameters or SKU not found"
+// @Failure      403           {object}  models.ForbiddenError          "Missing inventory.manage permission"
+// @Router       /admin/inventory/movements [get]
+func (handler *Handler) GetMovements(context *gin.Context) {
+	queryParams := &models.StockMovementQueryParams{}
+
+	if err := context.ShouldBindQuery(queryParams); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	movements, pagination, err := handler.service.GetMovements(queryParams)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.PaginatedResponseWriter(context, movements, pagination)
+}
+
+// bindRequest reads and validates the JSON body of the request, answering 400 if it is invalid.
+func bindRequest(context *gin.Context, request any) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return false
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, validator.ErrorMessage(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReserveStock godoc
+// @Summary      Reserve Stock for a Checkout
+// @Description  Holds the items of a checkout under its reference until the reservations expire (ttl_min, the configured default otherwise), are committed or are released. Either all the items are reserved or none.
+// @Tags         Inventory Administration
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ReservationRequest                             true  "Reference and items"
+// @Success      201      {object}  models.SuccessResponse[[]models.ReservationResponse]  "Reservations"
+// @Failure      400      {object}  models.BadRequestError                                "Invalid request data, SKU not found, insufficient stock or reference already reserved"
+// @Failure      403      {object}  models.ForbiddenError                                 "Missing inventory.manage permission"
+// @Router       /admin/inventory/reservations [post]
+func (handler *Handler) ReserveStock(context *gin.Context) {
+	request := models.ReservationRequest{}
+	if !bindRequest(context, &request) {
+		return
+	}
+
+	reservations, err := handler.service.ReserveStock(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusCreated, reservations)
+}
+
+// GetReservations godoc
+// @Summary      Get the Reservations of a Reference
+// @Description  Returns the reservations of a checkout reference, whatever their status (active, committed, released or expired).
+// @Tags         Inventory Administration
+// @Produce      json
+// @Param        reference  path      string                                                true  "Reference of the reservations"
+// @Success      200        {object}  models.SuccessResponse[[]models.ReservationResponse]  "Reservations"
+// @Failure      400        {object}  models.BadRequestError                                "No reservation with this reference"
+// @Failure      403        {object}  models.ForbiddenError                                 "Missing inventory.manage permission"
+// @Router       /admin/inventory/reservations/{reference} [get]
+func (handler *Handler) GetReservations(context *gin.Context) {
+	reservations, err := handler.service.GetReservations(context.Param("reference"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, reservations)
+}
+
+// CommitReservations godoc
+// @Summary      Commit the Reservations of a Reference
+// @Description  Called when the order is placed: the reserved quantities leave the stock on hand and are recorded as sales in the ledger, atomically.
+// @Tags         Inventory Administration
+// @Produce      json
+// @Param        reference  path      string                                                true  "Reference of the reservations"
+// @Success      200        {object}  models.SuccessResponse[[]models.ReservationResponse]  "Committed reservations"
+// @Failure      400        {object}  models.BadRequestError                                "No active reservation or reservation expired"
+// @Failure      403        {object}  models.ForbiddenError                                 "Missing inventory.manage permission"
+// @Router       /admin/inventory/reservations/{reference}/commit [post]
+func (handler *Handler) CommitReservations(context *gin.Context) {
+	reservations, err := handler.service.CommitReservations(context.Param("reference"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, reservations)
+}
+
+// ReleaseReservations godoc
+// @Summary      Release the Reservations of a Reference
+// @Description  Gives the stock of the active reservations of a checkout reference back, e.g. when the checkout is abandoned.
+// @Tags         Inventory Administration
+// @Produce      json
+// @Param        reference  path      string                          true  "Reference of the reservations"
+// @Success      200        {object}  models.SuccessResponse[string]  "Reservations released"
+// @Failure      400        {object}  models.BadRequestError          "No active reservation with this reference"
+// @Failure      403        {object}  models.ForbiddenError           "Missing inventory.manage permission"
+// @Router       /admin/inventory/reservations/{reference} [delete]
+func (handler *Handler) ReleaseReservations(context *gin.Context) {
+	message, err := handler.service.ReleaseReservations(context.Param("reference"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
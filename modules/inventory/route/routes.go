@@ -0,0 +1,46 @@
+package route
+
+import (
+	"e-commerce/middleware/auth"
+	"e-commerce/modules/inventory/handler"
+	"e-commerce/utils/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InventoryRoutes(router *gin.Engine) {
+	handler := handler.NewInventoryHandler()
+
+	admin := router.Group("/admin/inventory", auth.RequirePermission(constants.PERMISSION_INVENTORY_MANAGE))
+
+	{
+		admin.GET("/warehouses", handler.GetWarehouses)
+
+		admin.POST("/warehouses", handler.AddWarehouse)
+
+		admin.PUT("/warehouses/:id", handler.UpdateWarehouse)
+	}
+
+	{
+		admin.GET("/stock/:sku", handler.GetStock)
+
+		admin.POST("/adjustments", handler.AdjustStock)
+
+		admin.PUT("/thresholds", handler.SetLowStockThreshold)
+
+		admin.GET("/low-stock", handler.GetLowStock)
+
+		admin.GET("/movements", handler.GetMovements)
+	}
+
+	{
+		admin.POST("/reservations", handler.ReserveStock)
+
+		admin.GET("/reservations/:reference", handler.GetReservations)
+
+		admin.POST("/reservations/:reference/commit", handler.CommitReservations)
+
+		admin.DELETE("/reservations/:reference", handler.ReleaseReservations)
+	}
+
+}
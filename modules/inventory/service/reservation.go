@@ -0,0 +1,202 @@
+package service
+
+import (
+	"e-commerce/modules/inventory/dbAccess"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// expiryBatchSize is the number of reservations expired per transaction by ExpireReservations.
+const expiryBatchSize = 500
+
+// ReserveStock reserves the items of a checkout under its reference until the reservations expire,
+// are committed or are released. Either all the items are reserved or none; the quantities of a SKU
+// listed several times are added up.
+//
+// Parameters:
+//
+//	request (models.ReservationRequest): The reference, the items and the lifetime of the reservations.
+//
+// Returns:
+//
+//	[]models.ReservationResponse: The reservations, a SKU being split across warehouses when no single warehouse can cover it.
+//	error: An error if a SKU is not found, the stock of a SKU is insufficient or the reference already has active reservations.
+func (service *Service) ReserveStock(request models.ReservationRequest) ([]models.ReservationResponse, error) {
+	ttl := helper.ReservationTTL
+	if request.TTLMin > 0 {
+		ttl = time.Duration(request.TTLMin) * time.Minute
+	}
+	if ttl > helper.MaxReservationTTL {
+		return nil, fmt.Errorf("a reservation can not last more than %d minutes", int(helper.MaxReservationTTL.Minutes()))
+	}
+
+	skus := make([]string, 0, len(request.Items))
+	for _, item := range request.Items {
+		skus = append(skus, strings.TrimSpace(item.SKU))
+	}
+
+	variants, err := service.repo.FindVariantsBySKU(skus)
+	if err != nil {
+		return nil, err
+	}
+
+	bySKU := make(map[string]models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		bySKU[variant.SKU] = variant
+	}
+
+	quantities := make(map[uuid.UUID]int64, len(request.Items))
+	for i, item := range request.Items {
+		variant, ok := bySKU[skus[i]]
+		if !ok {
+			return nil, fmt.Errorf("no product variant with the SKU %s", item.SKU)
+		}
+		quantities[variant.VariantID] += item.Quantity
+	}
+
+	reference := strings.TrimSpace(request.Reference)
+
+	reservations, err := service.repo.ReserveStock(reference, quantities, time.Now().Add(ttl))
+	if err != nil {
+		var insufficient *dbAccess.InsufficientStockError
+		if errors.As(err, &insufficient) {
+			for _, variant := range variants {
+				if variant.VariantID == insufficient.VariantID {
+					return nil, fmt.Errorf("insufficient stock for %s, %d available", variant.SKU, max(insufficient.Available, 0))
+				}
+			}
+		}
+		if errors.Is(err, dbAccess.ErrReservationExists) {
+			return nil, fmt.Errorf("the reference %s already has active reservations, release them first", reference)
+		}
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	variantsByID := make(map[uuid.UUID]models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		variantsByID[variant.VariantID] = variant
+	}
+
+	response := make([]models.ReservationResponse, 0, len(reservations))
+	for _, reservation := range reservations {
+		reservation.Variant = variantsByID[reservation.VariantID]
+		response = append(response, reservation.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// GetReservations returns the reservations of a reference, whatever their status.
+//
+// Parameters:
+//
+//	reference (string): The reference of the reservations.
+//
+// Returns:
+//
+//	[]models.ReservationResponse: The reservations in creation order.
+//	error: An error if the reference has no reservation or the query fails.
+func (service *Service) GetReservations(reference string) ([]models.ReservationResponse, error) {
+	reservations, err := service.repo.FindReservations(reference)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if len(reservations) == 0 {
+		return nil, fmt.Errorf("no reservation with the reference %s", reference)
+	}
+
+	response := make([]models.ReservationResponse, 0, len(reservations))
+	for _, reservation := range reservations {
+		response = append(response, reservation.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// CommitReservations is called when the order of a checkout is placed: the reserved quantities leave
+// the stock on hand and are recorded as sales in the ledger, atomically.
+//
+// Parameters:
+//
+//	reference (string): The reference of the reservations.
+//
+// Returns:
+//
+//	[]models.ReservationResponse: The committed reservations.
+//	error: An error if the reference has no active reservation or a reservation has expired.
+func (service *Service) CommitReservations(reference string) ([]models.ReservationResponse, error) {
+	reservations, err := service.repo.CommitReservations(reference)
+	if err != nil {
+		if errors.Is(err, dbAccess.ErrReservationExpired) {
+			return nil, fmt.Errorf("the reservations of %s have expired, please reserve the stock again", reference)
+		}
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if len(reservations) == 0 {
+		return nil, fmt.Errorf("no active reservation with the reference %s", reference)
+	}
+
+	return service.GetReservations(reference)
+}
+
+// ReleaseReservations gives the stock of the active reservations of a reference back, e.g. when a checkout is abandoned.
+//
+// Parameters:
+//
+//	reference (string): The reference of the reservations.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the reference has no active reservation or the release fails.
+func (service *Service) ReleaseReservations(reference string) (string, error) {
+	released, err := service.repo.ReleaseReservations(reference)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if released == 0 {
+		return "", fmt.Errorf("no active reservation with the reference %s", reference)
+	}
+
+	return "Reservations released successfully.", nil
+}
+
+// ExpireReservations gives the stock of the active reservations past their expiry back.
+// It is run periodically by the cron jobs.
+//
+// Returns:
+//
+//	error: An error if a batch fails; the batches expired before are kept.
+func (service *Service) ExpireReservations() error {
+	for {
+		expired, err := service.repo.ExpireReservations(expiryBatchSize)
+		if err != nil {
+			return err
+		}
+		if expired < expiryBatchSize {
+			return nil
+		}
+	}
+}
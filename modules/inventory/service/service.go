@@ -0,0 +1,409 @@
+package service
+
+import (
+	"e-commerce/modules/inventory/dbAccess"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// lowStockSortColumns are the columns the low stock list can be sorted by.
+var lowStockSortColumns = map[string]string{
+	"available":      "(stock_levels.on_hand - stock_levels.reserved)",
+	"updated_at":     "stock_levels.updated_at",
+	"stock_level_id": "stock_levels.stock_level_id",
+}
+
+// movementSortColumns are the columns the stock movement list can be sorted by.
+var movementSortColumns = map[string]string{
+	"created_at":  "stock_movements.created_at",
+	"movement_id": "stock_movements.movement_id",
+}
+
+// Service provides the inventory: the warehouses, the stock levels of the variants (SKUs) in each warehouse,
+// the stock movement ledger and the reservations holding stock during the checkouts.
+type Service struct {
+	repo *dbAccess.Repo
+}
+
+// NewInventoryService creates and returns a new Inventory Service instance by initializing the repository.
+// Returns:
+//
+//	*Service: A pointer to a new Service instance with its repository initialized.
+func NewInventoryService() *Service {
+	repo := dbAccess.NewInventoryRepository()
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetWarehouses returns all the warehouses ordered by code.
+//
+// Returns:
+//
+//	[]models.WarehouseResponse: The warehouses.
+//	error: An error if the query fails.
+func (service *Service) GetWarehouses() ([]models.WarehouseResponse, error) {
+	warehouses, err := service.repo.FindWarehouses()
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	response := make([]models.WarehouseResponse, 0, len(warehouses))
+	for _, warehouse := range warehouses {
+		response = append(response, warehouse.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// AddWarehouse creates a warehouse; its code is stored in upper case and must be unique.
+//
+// Parameters:
+//
+//	request (models.WarehouseRequest): The warehouse details.
+//
+// Returns:
+//
+//	models.WarehouseResponse: The created warehouse.
+//	error: An error if the code is taken or the creation fails.
+func (service *Service) AddWarehouse(request models.WarehouseRequest) (models.WarehouseResponse, error) {
+	warehouse := models.Warehouse{}
+
+	if err := service.applyWarehouseRequest(&warehouse, request); err != nil {
+		return models.WarehouseResponse{}, err
+	}
+
+	if err := service.repo.CreateWarehouse(&warehouse); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.WarehouseResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.WarehouseResponse{}, err
+	}
+
+	return warehouse.ResponseObj(), nil
+}
+
+// UpdateWarehouse replaces the details of a warehouse. An inactive warehouse keeps its stock
+// and its reservations but takes no new reservation.
+//
+// Parameters:
+//
+//	id (string): The warehouse id (uuid).
+//	request (models.WarehouseRequest): The new warehouse details.
+//
+// Returns:
+//
+//	models.WarehouseResponse: The updated warehouse.
+//	error: An error if the warehouse is not found, the code is taken or the update fails.
+func (service *Service) UpdateWarehouse(id string, request models.WarehouseRequest) (models.WarehouseResponse, error) {
+	warehouseID, err := uuid.Parse(id)
+	if err != nil {
+		return models.WarehouseResponse{}, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	warehouse, err := service.getWarehouse(warehouseID)
+	if err != nil {
+		return models.WarehouseResponse{}, err
+	}
+
+	if err := service.applyWarehouseRequest(warehouse, request); err != nil {
+		return models.WarehouseResponse{}, err
+	}
+
+	if err := service.repo.UpdateWarehouse(warehouse); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.WarehouseResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.WarehouseResponse{}, err
+	}
+
+	return warehouse.ResponseObj(), nil
+}
+
+// GetStock returns the stock levels of a SKU in each warehouse holding it.
+//
+// Parameters:
+//
+//	sku (string): The SKU of the variant.
+//
+// Returns:
+//
+//	[]models.StockLevelResponse: The stock levels, ordered by warehouse code.
+//	error: An error if the SKU is not found or the query fails.
+func (service *Service) GetStock(sku string) ([]models.StockLevelResponse, error) {
+	variant, err := service.getVariant(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	levels, err := service.repo.FindStockLevels(variant.VariantID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	response := make([]models.StockLevelResponse, 0, len(levels))
+	for _, level := range levels {
+		response = append(response, level.ResponseObj())
+	}
+
+	return response, nil
+}
+
+// AdjustStock records a receipt, a return or an adjustment of the stock of a SKU in a warehouse.
+// The stock on hand can not drop below the quantity held by the active reservations.
+//
+// Parameters:
+//
+//	request (models.StockAdjustmentRequest): The SKU, the warehouse and the quantity.
+//	userID (uuid.UUID): The user recording the change, kept in the ledger.
+//
+// Returns:
+//
+//	models.StockLevelResponse: The stock level after the change.
+//	error: An error if the SKU or the warehouse is not found, the quantity is invalid or the stock is insufficient.
+func (service *Service) AdjustStock(request models.StockAdjustmentRequest, userID uuid.UUID) (models.StockLevelResponse, error) {
+	if request.Type != constants.STOCK_MOVEMENT_ADJUSTMENT && request.Quantity < 0 {
+		return models.StockLevelResponse{}, fmt.Errorf("the quantity of a %s must be positive", request.Type)
+	}
+
+	variant, err := service.getVariant(request.SKU)
+	if err != nil {
+		return models.StockLevelResponse{}, err
+	}
+
+	if _, err := service.getWarehouse(request.WarehouseID); err != nil {
+		return models.StockLevelResponse{}, err
+	}
+
+	err = service.repo.AdjustStock(dbAccess.StockChange{
+		VariantID:   variant.VariantID,
+		WarehouseID: request.WarehouseID,
+		Type:        request.Type,
+		Quantity:    request.Quantity,
+		Reference:   strings.TrimSpace(request.Reference),
+		Note:        strings.TrimSpace(request.Note),
+		CreatedBy:   &userID,
+	})
+	if err != nil {
+		var insufficient *dbAccess.InsufficientStockError
+		if errors.As(err, &insufficient) {
+			return models.StockLevelResponse{}, fmt.Errorf("the stock of %s can not drop below its reserved quantity, %d available", variant.SKU, insufficient.Available)
+		}
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.StockLevelResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.StockLevelResponse{}, err
+	}
+
+	return service.getStockLevel(variant.VariantID, request.WarehouseID)
+}
+
+// SetLowStockThreshold sets the low stock threshold of a SKU in a warehouse, or restores the default threshold.
+//
+// Parameters:
+//
+//	request (models.StockThresholdRequest): The SKU, the warehouse and the threshold.
+//
+// Returns:
+//
+//	models.StockLevelResponse: The stock level with its threshold.
+//	error: An error if the SKU or the warehouse is not found or the update fails.
+func (service *Service) SetLowStockThreshold(request models.StockThresholdRequest) (models.StockLevelResponse, error) {
+	variant, err := service.getVariant(request.SKU)
+	if err != nil {
+		return models.StockLevelResponse{}, err
+	}
+
+	if _, err := service.getWarehouse(request.WarehouseID); err != nil {
+		return models.StockLevelResponse{}, err
+	}
+
+	if err := service.repo.SetLowStockThreshold(variant.VariantID, request.WarehouseID, request.LowStockThreshold); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.StockLevelResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.StockLevelResponse{}, err
+	}
+
+	return service.getStockLevel(variant.VariantID, request.WarehouseID)
+}
+
+// GetLowStock retrieves one page of the stock levels whose available quantity (on hand minus reserved)
+// is at or below their threshold, the lowest first unless another sort is given.
+//
+// Parameters:
+//
+//	queryParams (*models.LowStockQueryParams): The pagination parameters and the warehouse filter.
+//
+// Returns:
+//
+//	[]models.StockLevelResponse: The low stock levels of the page.
+//	models.Pagination: The pagination metadata.
+//	error: An error if the query parameters are invalid or the query fails.
+func (service *Service) GetLowStock(queryParams *models.LowStockQueryParams) ([]models.StockLevelResponse, models.Pagination, error) {
+	if queryParams.Sort == "" {
+		queryParams.Sort = "available"
+	}
+
+	page, err := helper.ParsePageQuery(queryParams.PageQuery, lowStockSortColumns, "stock_level_id")
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
+
+	filters := service.repo.GetLowStockFilter(helper.LowStockThreshold)
+	if queryParams.WarehouseID != nil {
+		filters = filters.Where("stock_levels.warehouse_id = ?", *queryParams.WarehouseID)
+	}
+
+	keyset, keysetArgs := page.KeysetCondition()
+
+	levels, total, err := service.repo.FindStockLevelPage(filters, keyset, keysetArgs, page.OrderBy(), page.Limit, page.Offset())
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, models.Pagination{}, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, models.Pagination{}, err
+	}
+
+	response := make([]models.StockLevelResponse, 0, len(levels))
+	for _, level := range levels {
+		response = append(response, level.ResponseObj())
+	}
+
+	var nextCursor string
+	if len(levels) > 0 {
+		last := levels[len(levels)-1]
+		nextCursor = page.NextCursor(len(levels), map[string]any{
+			"available":      last.OnHand - last.Reserved,
+			"updated_at":     last.UpdatedAt,
+			"stock_level_id": last.StockLevelID,
+		})
+	}
+
+	return response, page.Pagination(total, nextCursor), nil
+}
+
+// GetMovements retrieves one page of the stock movement ledger, the latest first unless another sort is given.
+//
+// Parameters:
+//
+//	queryParams (*models.StockMovementQueryParams): The pagination parameters and the filters.
+//
+// Returns:
+//
+//	[]models.StockMovementResponse: The stock movements of the page.
+//	models.Pagination: The pagination metadata.
+//	error: An error if the query parameters are invalid or the query fails.
+func (service *Service) GetMovements(queryParams *models.StockMovementQueryParams) ([]models.StockMovementResponse, models.Pagination, error) {
+	if queryParams.Sort == "" {
+		queryParams.Sort = "-created_at"
+	}
+
+	page, err := helper.ParsePageQuery(queryParams.PageQuery, movementSortColumns, "movement_id")
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
+
+	filters := helper.BuildQuery(service.repo.GetMovementFilter(), queryParams)
+	if queryParams.SKU != nil {
+		variant, err := service.getVariant(*queryParams.SKU)
+		if err != nil {
+			return nil, models.Pagination{}, err
+		}
+		filters = filters.Where("stock_movements.variant_id = ?", variant.VariantID)
+	}
+
+	keyset, keysetArgs := page.KeysetCondition()
+
+	movements, total, err := service.repo.FindMovementPage(filters, keyset, keysetArgs, page.OrderBy(), page.Limit, page.Offset())
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, models.Pagination{}, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, models.Pagination{}, err
+	}
+
+	response := make([]models.StockMovementResponse, 0, len(movements))
+	for _, movement := range movements {
+		response = append(response, movement.ResponseObj())
+	}
+
+	var nextCursor string
+	if len(movements) > 0 {
+		last := movements[len(movements)-1]
+		nextCursor = page.NextCursor(len(movements), map[string]any{
+			"created_at":  last.CreatedAt,
+			"movement_id": last.MovementID,
+		})
+	}
+
+	return response, page.Pagination(total, nextCursor), nil
+}
+
+// applyWarehouseRequest copies the request to the warehouse, checking the code is not taken by another warehouse.
+func (service *Service) applyWarehouseRequest(warehouse *models.Warehouse, request models.WarehouseRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+
+	existing, err := service.repo.GetWarehouseByCondition("warehouses.code = ?", code)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.WarehouseID != warehouse.WarehouseID {
+		return fmt.Errorf("a warehouse with the code %s already exists", code)
+	}
+
+	warehouse.Code = code
+	warehouse.Name = strings.TrimSpace(request.Name)
+	warehouse.IsActive = request.IsActive
+
+	return nil
+}
+
+// getWarehouse loads a warehouse.
+func (service *Service) getWarehouse(warehouseID uuid.UUID) (*models.Warehouse, error) {
+	warehouse, err := service.repo.GetWarehouseByCondition("warehouses.warehouse_id = ?", warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if warehouse == nil {
+		return nil, fmt.Errorf("warehouse not found")
+	}
+	return warehouse, nil
+}
+
+// getVariant loads the variant of a SKU.
+func (service *Service) getVariant(sku string) (*models.ProductVariant, error) {
+	variant, err := service.repo.GetVariantBySKU(strings.TrimSpace(sku))
+	if err != nil {
+		return nil, err
+	}
+	if variant == nil {
+		return nil, fmt.Errorf("no product variant with the SKU %s", sku)
+	}
+	return variant, nil
+}
+
+// getStockLevel loads the stock level of a variant in a warehouse for the response.
+func (service *Service) getStockLevel(variantID uuid.UUID, warehouseID uuid.UUID) (models.StockLevelResponse, error) {
+	level, err := service.repo.GetStockLevel(variantID, warehouseID)
+	if err != nil {
+		return models.StockLevelResponse{}, err
+	}
+	if level == nil {
+		return models.StockLevelResponse{}, fmt.Errorf("stock level not found")
+	}
+	return level.ResponseObj(), nil
+}
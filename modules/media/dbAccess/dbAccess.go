@@ -0,0 +1,121 @@
+package dbAccess
+
+import (
+	"e-commerce/database/connections"
+
+	"e-commerce/base"
+	"e-commerce/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repo defines a concrete implementation of media-specific repository
+// using the generic BaseRepository from the shared layer.
+type Repo struct {
+	base        base.BaseRepository[models.ProductImage]
+	productBase base.BaseRepository[models.Product]
+}
+
+// NewMediaRepository creates a new instance of the Media repository.
+// Returns:
+// - *Repo: Pointer to a new Repo with injected DB and Redis client.
+func NewMediaRepository() *Repo {
+	return &Repo{
+		base:        *base.NewBaseRepository[models.ProductImage](connections.GetDB(), connections.GetRedisClient()),
+		productBase: *base.NewBaseRepository[models.Product](connections.GetDB(), connections.GetRedisClient()),
+	}
+}
+
+// GetProductByCondition retrieves a product matching the given condition, without its variants.
+// Parameters:
+// - condition (any): The WHERE clause condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.Product: Pointer to the product, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetProductByCondition(condition any, args ...any) (*models.Product, error) {
+	return repo.productBase.GetByCondition(condition, args...)
+}
+
+// FindImages retrieves the images of a product ordered by position.
+// Parameters:
+// - productID (uuid.UUID): The product id.
+// Returns:
+// - []models.ProductImage: Slice of images.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindImages(productID uuid.UUID) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := repo.base.DB.Where("product_images.product_id = ?", productID).
+		Order("product_images.position, product_images.created_at").
+		Find(&images).Error
+	return images, err
+}
+
+// CreateImage inserts a new image after the images of its product. The position is computed in the
+// insert transaction, the product row being locked so concurrent uploads get distinct positions.
+// Parameters:
+// - image (*models.ProductImage): Pointer to the image to create, its position is set.
+// Returns:
+// - error: Error if any occurred during insertion.
+func (repo Repo) CreateImage(image *models.ProductImage) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("products.product_id").
+			Where("products.product_id = ?", image.ProductID).
+			Take(&models.Product{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ProductImage{}).
+			Where("product_images.product_id = ?", image.ProductID).
+			Select("COALESCE(MAX(product_images.position) + 1, 0)").
+			Scan(&image.Position).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(image).Error
+	})
+}
+
+// DeleteImage permanently deletes the image, its files being deleted from the object store.
+// Parameters:
+// - image (*models.ProductImage): Pointer to the image to delete.
+// Returns:
+// - error: Error if any occurred during deletion.
+func (repo Repo) DeleteImage(image *models.ProductImage) error {
+	return repo.base.Delete(image, false)
+}
+
+// DeleteProductImages permanently deletes the images of a product, its files being deleted from the object store.
+// Parameters:
+// - productID (uuid.UUID): The product id.
+// Returns:
+// - []models.ProductImage: The deleted images.
+// - error: Error if any occurred during deletion.
+func (repo Repo) DeleteProductImages(productID uuid.UUID) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := repo.base.DB.Unscoped().Clauses(clause.Returning{}).
+		Where("product_images.product_id = ?", productID).
+		Delete(&images).Error
+	return images, err
+}
+
+// SetImagePositions saves the position of each image, in one transaction.
+// Parameters:
+// - images ([]models.ProductImage): The images with their new position.
+// Returns:
+// - error: Error if any occurred, in which case no position is changed.
+func (repo Repo) SetImagePositions(images []models.ProductImage) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		for _, image := range images {
+			if err := tx.Model(&models.ProductImage{}).
+				Where("product_images.image_id = ?", image.ImageID).
+				Update("position", image.Position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/modules/media/service"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewMediaHandler() *Handler {
+	service := service.NewMediaService()
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetProductImages godoc
+// @Summary      Get the Images of a Product
+// @Description  Returns the images of an active product in display order, with URLs of the original and of the thumbnails signed for a limited time (see url_expires_at).
+// @Tags         Catalog
+// @Produce      json
+// @Param        id   path      string                                               true  "Product ID"
+// @Success      200  {object}  models.SuccessResponse[[]models.ProductImageResponse]  "Images"
+// @Failure      400  {object}  models.BadRequestError                               "Invalid ID or product not found"
+// @Router       /catalog/products/{id}/images [get]
+func (handler *Handler) GetProductImages(context *gin.Context) {
+	images, err := handler.service.GetProductImages(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, images)
+}
+
+// GetProductImagesForAdmin godoc
+// @Summary      Get the Images of a Product of any Status
+// @Description  Returns the images of a product in display order with their signed URLs, whatever the status of the product.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id   path      string                                               true  "Product ID"
+// @Success      200  {object}  models.SuccessResponse[[]models.ProductImageResponse]  "Images"
+// @Failure      400  {object}  models.BadRequestError                               "Invalid ID or product not found"
+// @Failure      403  {object}  models.ForbiddenError                                "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/images [get]
+func (handler *Handler) GetProductImagesForAdmin(context *gin.Context) {
+	images, err := handler.service.GetProductImagesForAdmin(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, images)
+}
+
+// AddProductImage godoc
+// @Summary      Upload an Image of a Product
+// @Description  Uploads a JPEG, PNG, GIF or WebP image (the type is detected from the content) after the images of the product. The file is streamed to the object store as it is received, so the alt_text field must come before the file field; the fields after it are ignored. Thumbnails are generated in the configured sizes; the files are private and served with signed URLs.
+// @Tags         Catalog Administration
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id        path      string                                             true   "Product ID"
+// @Param        file      formData  file                                               true   "Image file"
+// @Param        alt_text  formData  string                                             false  "Text describing the image"
+// @Success      201       {object}  models.SuccessResponse[models.ProductImageResponse]  "Image stored"
+// @Failure      400       {object}  models.BadRequestError                             "Product not found, unsupported file type or image too large"
+// @Failure      403       {object}  models.ForbiddenError                              "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/images [post]
+func (handler *Handler) AddProductImage(context *gin.Context) {
+	// the body is cut after the size limit (with room for the other form fields) instead of being read entirely
+	context.Request.Body = http.MaxBytesReader(context.Writer, context.Request.Body, helper.MaxImageUploadSize+1<<20)
+
+	// the parts are read in order without buffering the form: the file part is handed to the service as it arrives
+	multipartReader, err := context.Request.MultipartReader()
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please upload the image as a multipart form.")
+		return
+	}
+
+	altText := ""
+
+	for {
+		part, err := multipartReader.NextPart()
+		if err != nil {
+			helper.ResponseWriter(context, http.StatusBadRequest, fmt.Sprintf("Please upload an image of at most %d MB in the file field.", helper.MaxImageUploadSize>>20))
+			return
+		}
+
+		switch part.FormName() {
+		case "alt_text":
+			// longer than the 255 characters accepted, so the service refuses it instead of storing it cut
+			value, err := io.ReadAll(io.LimitReader(part, 1024))
+			if err != nil {
+				helper.ResponseWriter(context, http.StatusBadRequest, "Invalid alt_text field.")
+				return
+			}
+			altText = string(value)
+
+		case "file":
+			image, err := handler.service.AddProductImage(context.Param("id"), part, altText)
+			if err != nil {
+				helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			helper.ResponseWriter(context, http.StatusCreated, image)
+			return
+		}
+	}
+}
+
+// SetImageOrder godoc
+// @Summary      Order the Images of a Product
+// @Description  Sets the display order of the images of a product; all the images must be listed and the first one becomes the main image.
+// @Tags         Catalog Administration
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                               true  "Product ID"
+// @Param        request  body      models.ImageOrderRequest                             true  "Image IDs in display order"
+// @Success      200      {object}  models.SuccessResponse[[]models.ProductImageResponse]  "Images in their new order"
+// @Failure      400      {object}  models.BadRequestError                               "Invalid request data or list not matching the images of the product"
+// @Failure      403      {object}  models.ForbiddenError                                "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/images/order [put]
+func (handler *Handler) SetImageOrder(context *gin.Context) {
+	request := models.ImageOrderRequest{}
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, validator.ErrorMessage(err))
+		return
+	}
+
+	images, err := handler.service.SetImageOrder(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, images)
+}
+
+// DeleteProductImage godoc
+// @Summary      Delete an Image of a Product
+// @Description  Deletes an image of a product with its thumbnails; the next images move up.
+// @Tags         Catalog Administration
+// @Produce      json
+// @Param        id       path      string                          true  "Product ID"
+// @Param        imageId  path      string                          true  "Image ID"
+// @Success      200      {object}  models.SuccessResponse[string]  "Image deleted"
+// @Failure      400      {object}  models.BadRequestError          "Image not found"
+// @Failure      403      {object}  models.ForbiddenError           "Missing catalog.manage permission"
+// @Router       /admin/catalog/products/{id}/images/{imageId} [delete]
+func (handler *Handler) DeleteProductImage(context *gin.Context) {
+	message, err := handler.service.DeleteProductImage(context.Param("id"), context.Param("imageId"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// GetFile godoc
+// @Summary      Get a Stored File
+// @Description  Serves a file of the local object store (local development) through a signed URL returned by the API. Not available with the S3 object store, whose URLs point to S3.
+// @Tags         Catalog
+// @Produce      octet-stream
+// @Param        key        path      string                  true  "Object key"
+// @Param        expires    query     int                     true  "Expiry of the URL (unix time)"
+// @Param        signature  query     string                  true  "Signature of the URL"
+// @Success      200        {file}    file                    "File content"
+// @Failure      403        {object}  models.ForbiddenError   "Expired or invalid signature"
+// @Failure      404        {object}  models.NotFoundError    "File not found"
+// @Router       /media/files/{key} [get]
+func (handler *Handler) GetFile(context *gin.Context) {
+	store, ok := services.ObjectStorage.(*services.LocalObjectStore)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusNotFound, "File not found.")
+		return
+	}
+
+	key := strings.TrimPrefix(context.Param("key"), "/")
+
+	if err := store.VerifySignedURL(key, context.Query("expires"), context.Query("signature")); err != nil {
+		helper.ResponseWriter(context, http.StatusForbidden, err.Error())
+		return
+	}
+
+	file, err := store.Get(context.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, services.ErrObjectNotFound) {
+			helper.ResponseWriter(context, http.StatusNotFound, "File not found.")
+			return
+		}
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer file.Close()
+
+	context.DataFromReader(http.StatusOK, -1, mime.TypeByExtension(path.Ext(key)), file, map[string]string{
+		"Cache-Control":          "private, max-age=300",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
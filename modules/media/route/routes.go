@@ -0,0 +1,35 @@
+package route
+
+import (
+	"e-commerce/middleware/auth"
+	"e-commerce/modules/media/handler"
+	"e-commerce/utils/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MediaRoutes(router *gin.Engine) {
+	handler := handler.NewMediaHandler()
+
+	// the signed URLs of the local object store, checked by the handler
+	router.GET(auth.PublicRoute("/media/files/*key"), handler.GetFile)
+
+	{
+		catalog := router.Group("/catalog")
+
+		catalog.GET(auth.PublicGroupRoute(catalog, "/products/:id/images"), handler.GetProductImages)
+	}
+
+	{
+		admin := router.Group("/admin/catalog", auth.RequirePermission(constants.PERMISSION_CATALOG_MANAGE))
+
+		admin.GET("/products/:id/images", handler.GetProductImagesForAdmin)
+
+		admin.POST("/products/:id/images", handler.AddProductImage)
+
+		admin.PUT("/products/:id/images/order", handler.SetImageOrder)
+
+		admin.DELETE("/products/:id/images/:imageId", handler.DeleteProductImage)
+	}
+
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"e-commerce/modules/media/dbAccess"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Service struct {
+	repo *dbAccess.Repo
+}
+
+// NewMediaService creates and returns a new Media Service instance by initializing the repository.
+// Returns:
+//
+//	*Service: A pointer to the newly created Service instance.
+func NewMediaService() *Service {
+	repo := dbAccess.NewMediaRepository()
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetProductImages returns the images of an active product with their signed URLs.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//
+// Returns:
+//
+//	[]models.ProductImageResponse: The images in display order.
+//	error: An error if no active product matches or the URLs can not be signed.
+func (service *Service) GetProductImages(productID string) ([]models.ProductImageResponse, error) {
+	product, err := service.getProduct(productID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.findImages(product.ProductID)
+}
+
+// GetProductImagesForAdmin returns the images of a product of any status with their signed URLs.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//
+// Returns:
+//
+//	[]models.ProductImageResponse: The images in display order.
+//	error: An error if the product is not found or the URLs can not be signed.
+func (service *Service) GetProductImagesForAdmin(productID string) ([]models.ProductImageResponse, error) {
+	product, err := service.getProduct(productID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.findImages(product.ProductID)
+}
+
+// AddProductImage stores an uploaded image of a product with its thumbnails, after the images already there.
+// The upload is streamed to the object store as it is read: the type of the file is sniffed from its first bytes
+// and its dimensions from its header, so the files too large in pixels are refused before being stored,
+// and the upload fails as soon as it exceeds the size limit. The image is decoded for the thumbnails
+// while it is stored; the thumbnails larger than the image are skipped.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//	file (io.Reader): The uploaded file, e.g. the part of a multipart form.
+//	altText (string): The text describing the image.
+//
+// Returns:
+//
+//	models.ProductImageResponse: The stored image with its signed URLs.
+//	error: An error if the product is not found, the file is not a supported image, is too large or the storage fails.
+func (service *Service) AddProductImage(productID string, file io.Reader, altText string) (models.ProductImageResponse, error) {
+	product, err := service.getProduct(productID, false)
+	if err != nil {
+		return models.ProductImageResponse{}, err
+	}
+
+	if len(altText) > 255 {
+		return models.ProductImageResponse{}, fmt.Errorf("the alt text can not be longer than 255 characters")
+	}
+
+	upload := &sizeLimitReader{reader: file, limit: helper.MaxImageUploadSize}
+	buffered := bufio.NewReader(upload)
+
+	contentType, err := helper.DetectImageType(buffered)
+	if err != nil {
+		return models.ProductImageResponse{}, upload.check(err)
+	}
+
+	// the header read by DecodeConfig is kept to be stored with the rest of the file
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(buffered, &header))
+	if err != nil {
+		return models.ProductImageResponse{}, upload.check(fmt.Errorf("the image can not be read: %s", err.Error()))
+	}
+
+	if config.Width*config.Height > helper.MaxImagePixels {
+		return models.ProductImageResponse{}, fmt.Errorf("the image is too large (%dx%d pixels), expects at most %d pixels", config.Width, config.Height, helper.MaxImagePixels)
+	}
+
+	productImage := models.ProductImage{
+		ImageID:     uuid.New(),
+		ProductID:   product.ProductID,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		AltText:     strings.TrimSpace(altText),
+	}
+	productImage.ObjectKey = objectKey(productImage, "original", helper.ImageExtensions[contentType])
+
+	// the stored files are deleted again if a later step fails
+	stored, err := service.storeImage(&productImage, io.MultiReader(&header, buffered))
+	productImage.Size = upload.size
+	if err == nil {
+		err = service.repo.CreateImage(&productImage)
+	}
+
+	if err != nil {
+		deleteObjects(stored)
+
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.ProductImageResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.ProductImageResponse{}, upload.check(err)
+	}
+
+	return imageResponse(productImage)
+}
+
+// SetImageOrder sets the display order of the images of a product; the first image is the main image.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//	request (models.ImageOrderRequest): All the image ids of the product in the new order.
+//
+// Returns:
+//
+//	[]models.ProductImageResponse: The images in their new order.
+//	error: An error if the product is not found, the list does not match the images of the product or the update fails.
+func (service *Service) SetImageOrder(productID string, request models.ImageOrderRequest) ([]models.ProductImageResponse, error) {
+	product, err := service.getProduct(productID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := service.repo.FindImages(product.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.ImageIDs) != len(images) {
+		return nil, fmt.Errorf("the product has %d images, all of them must be listed", len(images))
+	}
+
+	positions := make(map[uuid.UUID]int, len(request.ImageIDs))
+	for position, imageID := range request.ImageIDs {
+		positions[imageID] = position
+	}
+
+	for i := range images {
+		position, ok := positions[images[i].ImageID]
+		if !ok {
+			return nil, fmt.Errorf("the image %s is missing from the list", images[i].ImageID)
+		}
+		images[i].Position = position
+	}
+
+	if err := service.repo.SetImagePositions(images); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	return service.findImages(product.ProductID)
+}
+
+// DeleteProductImage deletes an image of a product with its files; the next images move up.
+//
+// Parameters:
+//
+//	productID (string): The product id (uuid).
+//	imageID (string): The image id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the image is not found or the deletion fails.
+func (service *Service) DeleteProductImage(productID string, imageID string) (string, error) {
+	product, err := service.getProduct(productID, false)
+	if err != nil {
+		return "", err
+	}
+
+	images, err := service.repo.FindImages(product.ProductID)
+	if err != nil {
+		return "", err
+	}
+
+	index := -1
+	for i := range images {
+		if images[i].ImageID.String() == strings.ToLower(imageID) {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		return "", fmt.Errorf("no image found with id = %s for this product", imageID)
+	}
+
+	deleted := images[index]
+	if err := service.repo.DeleteImage(&deleted); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	// the files are deleted once the image is gone, a failure only leaves unused files behind
+	deleteObjects(imageKeys(deleted))
+
+	images = append(images[:index], images[index+1:]...)
+	for i := range images {
+		images[i].Position = i
+	}
+
+	if err := service.repo.SetImagePositions(images); err != nil {
+		return "", err
+	}
+
+	return "Image deleted successfully.", nil
+}
+
+// DeleteProductImages deletes the images of a deleted product with their files.
+// It is registered with services.RegisterProductDeletedHook.
+//
+// Parameters:
+//
+//	productID (uuid.UUID): The id of the deleted product.
+//
+// Returns:
+//
+//	error: An error if the images can not be deleted.
+func (service *Service) DeleteProductImages(productID uuid.UUID) error {
+	images, err := service.repo.DeleteProductImages(productID)
+	if err != nil {
+		return err
+	}
+
+	// the files are deleted once the images are gone, a failure only leaves unused files behind
+	for _, productImage := range images {
+		deleteObjects(imageKeys(productImage))
+	}
+
+	return nil
+}
+
+// getProduct parses the id and loads the product, which must be active for the public endpoints.
+func (service *Service) getProduct(id string, activeOnly bool) (*models.Product, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	condition, args := "products.product_id = ?", []any{parsedUUID}
+	if activeOnly {
+		condition, args = "products.product_id = ? AND products.status = ?", []any{parsedUUID, constants.PRODUCT_STATUS_ACTIVE}
+	}
+
+	product, err := service.repo.GetProductByCondition(condition, args...)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, fmt.Errorf("no product found with id = %s", parsedUUID)
+	}
+
+	return product, nil
+}
+
+// findImages loads the images of the product with their signed URLs.
+func (service *Service) findImages(productID uuid.UUID) ([]models.ProductImageResponse, error) {
+	images, err := service.repo.FindImages(productID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	response := make([]models.ProductImageResponse, 0, len(images))
+	for _, productImage := range images {
+		item, err := imageResponse(productImage)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, item)
+	}
+
+	return response, nil
+}
+
+// storeImage streams the original image to the object store while decoding it, then stores its thumbnails.
+// It returns the keys of the stored files, also when it fails.
+func (service *Service) storeImage(productImage *models.ProductImage, file io.Reader) ([]string, error) {
+	var stored []string
+
+	// the decoder reads the file through a pipe as it is stored, then drains what it does not need
+	pipeReader, pipeWriter := io.Pipe()
+	decodedImage := make(chan decodeResult, 1)
+
+	go func() {
+		decoded, _, err := image.Decode(pipeReader)
+		_, _ = io.Copy(io.Discard, pipeReader)
+		decodedImage <- decodeResult{image: decoded, err: err}
+	}()
+
+	err := services.ObjectStorage.Put(context.Background(), productImage.ObjectKey, io.TeeReader(file, pipeWriter), -1, productImage.ContentType)
+	pipeWriter.CloseWithError(err)
+	result := <-decodedImage
+
+	if err != nil {
+		return stored, fmt.Errorf("failed to store the image: %w", err)
+	}
+	stored = append(stored, productImage.ObjectKey)
+
+	if result.err != nil {
+		return stored, fmt.Errorf("the image can not be read: %s", result.err.Error())
+	}
+
+	thumbnailType, extension := helper.ThumbnailType(productImage.ContentType)
+
+	for _, size := range helper.ThumbnailSizes {
+		if size >= max(productImage.Width, productImage.Height) {
+			break
+		}
+
+		thumbnail, err := helper.EncodeThumbnail(helper.Thumbnail(result.image, size), productImage.ContentType)
+		if err != nil {
+			return stored, err
+		}
+
+		key := objectKey(*productImage, strconv.Itoa(size), extension)
+		if err := services.ObjectStorage.Put(context.Background(), key, bytes.NewReader(thumbnail), int64(len(thumbnail)), thumbnailType); err != nil {
+			return stored, fmt.Errorf("failed to store the thumbnail: %w", err)
+		}
+
+		stored = append(stored, key)
+		productImage.ThumbnailSizes = append(productImage.ThumbnailSizes, int64(size))
+	}
+
+	return stored, nil
+}
+
+// decodeResult is the image decoded while it is stored.
+type decodeResult struct {
+	image image.Image
+	err   error
+}
+
+// errUploadTooLarge is returned by a sizeLimitReader once the upload exceeds the size limit.
+var errUploadTooLarge = errors.New("upload too large")
+
+// sizeLimitReader counts the bytes of an upload and fails with errUploadTooLarge once there are more than limit,
+// which stops the storage of the upload.
+type sizeLimitReader struct {
+	reader io.Reader
+	size   int64
+	limit  int64
+}
+
+func (upload *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := upload.reader.Read(p)
+	upload.size += int64(n)
+	if upload.size > upload.limit {
+		return n, errUploadTooLarge
+	}
+	return n, err
+}
+
+// check returns the error to report for a failure while reading the upload: the size limit if it has been
+// exceeded, whatever error it caused (e.g. an image cut by the limit can not be decoded), the error otherwise.
+func (upload *sizeLimitReader) check(err error) error {
+	if upload.size > upload.limit {
+		return fmt.Errorf("the image is larger than %d MB", upload.limit>>20)
+	}
+	return err
+}
+
+// imageResponse returns the details of the image with URLs of the original and of the thumbnails,
+// signed for helper.SignedURLExpiry.
+func imageResponse(productImage models.ProductImage) (models.ProductImageResponse, error) {
+	response := models.ProductImageResponse{
+		ImageID:      productImage.ImageID,
+		ProductID:    productImage.ProductID,
+		ContentType:  productImage.ContentType,
+		Size:         productImage.Size,
+		Width:        productImage.Width,
+		Height:       productImage.Height,
+		AltText:      productImage.AltText,
+		Position:     productImage.Position,
+		Thumbnails:   make([]models.ThumbnailResponse, 0, len(productImage.ThumbnailSizes)),
+		URLExpiresAt: time.Now().Add(helper.SignedURLExpiry).UTC().Truncate(time.Second),
+	}
+
+	var err error
+	response.URL, err = services.ObjectStorage.SignedURL(context.Background(), productImage.ObjectKey, helper.SignedURLExpiry)
+	if err != nil {
+		return models.ProductImageResponse{}, fmt.Errorf("failed to sign the image url: %w", err)
+	}
+
+	_, extension := helper.ThumbnailType(productImage.ContentType)
+	for _, size := range productImage.ThumbnailSizes {
+		url, err := services.ObjectStorage.SignedURL(context.Background(), objectKey(productImage, strconv.FormatInt(size, 10), extension), helper.SignedURLExpiry)
+		if err != nil {
+			return models.ProductImageResponse{}, fmt.Errorf("failed to sign the thumbnail url: %w", err)
+		}
+		response.Thumbnails = append(response.Thumbnails, models.ThumbnailResponse{Size: int(size), URL: url})
+	}
+
+	return response, nil
+}
+
+// objectKey returns the key of a file of the image: products/<product id>/<image id>/<name>.<extension>.
+func objectKey(productImage models.ProductImage, name string, extension string) string {
+	return fmt.Sprintf("products/%s/%s/%s.%s", productImage.ProductID, productImage.ImageID, name, extension)
+}
+
+// imageKeys returns the keys of the original and of the thumbnails of the image.
+func imageKeys(productImage models.ProductImage) []string {
+	keys := []string{productImage.ObjectKey}
+
+	_, extension := helper.ThumbnailType(productImage.ContentType)
+	for _, size := range productImage.ThumbnailSizes {
+		keys = append(keys, objectKey(productImage, strconv.FormatInt(size, 10), extension))
+	}
+
+	return keys
+}
+
+// deleteObjects deletes files of the object store, logging the failures.
+func deleteObjects(keys []string) {
+	for _, key := range keys {
+		if err := services.ObjectStorage.Delete(context.Background(), key); err != nil {
+			fmt.Printf("failed to delete the object %s: %v\n", key, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package dbAccess
+
+import (
+	"e-commerce/database/connections"
+
+	"e-commerce/base"
+	"e-commerce/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repo defines a concrete implementation of role-specific repository
+// using the generic BaseRepository from the shared layer.
+type Repo struct {
+	base           base.BaseRepository[models.Role]
+	permissionBase base.BaseRepository[models.Permission]
+	userBase       base.BaseRepository[models.User]
+}
+
+// NewRoleRepository creates a new instance of the Role repository.
+// Returns:
+// - *Repo: Pointer to a new Repo with injected DB and Redis client.
+func NewRoleRepository() *Repo {
+	return &Repo{
+		base:           *base.NewBaseRepository[models.Role](connections.GetDB(), connections.GetRedisClient()),
+		permissionBase: *base.NewBaseRepository[models.Permission](connections.GetDB(), connections.GetRedisClient()),
+		userBase:       *base.NewBaseRepository[models.User](connections.GetDB(), connections.GetRedisClient()),
+	}
+}
+
+// Create inserts a new role record into the database.
+// Parameters:
+// - role (*models.Role): Pointer to the role to be created.
+// Returns:
+// - error: Error if any occurred during creation.
+func (repo Repo) Create(role *models.Role) error {
+	return repo.base.Create(role)
+}
+
+// GetByCondition retrieves a single role matching a condition.
+// Parameters:
+// - condition (any): The SQL WHERE condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.Role: Pointer to the matched role, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetByCondition(condition any, args ...any) (*models.Role, error) {
+	return repo.base.GetByCondition(condition, args...)
+}
+
+// FindAll retrieves all the roles ordered by the given column.
+// Parameters:
+// - orderBy (string): Order clause (e.g. "name").
+// Returns:
+// - []models.Role: Slice of role records.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindAll(orderBy string) ([]models.Role, error) {
+	roles, _, err := repo.base.FindAll(nil, orderBy, 0, 0)
+	return roles, err
+}
+
+// Update updates an existing role record.
+// Parameters:
+// - role (*models.Role): Pointer to the role model with updated fields.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) Update(role *models.Role) error {
+	return repo.base.Update(role)
+}
+
+// Delete permanently removes the role together with the permissions granted to it,
+// so its code can be reused by a new role.
+// Parameters:
+// - role (*models.Role): Pointer to the role to delete.
+// Returns:
+// - error: Error if any occurred during the deletion.
+func (repo Repo) Delete(role *models.Role) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_permissions.role_id = ?", role.RoleID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(role).Error
+	})
+}
+
+// FindAllPermissions retrieves all the permissions which can be granted to roles.
+// Returns:
+// - []models.Permission: Slice of permission records.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindAllPermissions() ([]models.Permission, error) {
+	permissions, _, err := repo.permissionBase.FindAll(nil, "code", 0, 0)
+	return permissions, err
+}
+
+// FindPermissionsByCode retrieves the permissions with the given codes.
+// Parameters:
+// - codes ([]string): The permission codes.
+// Returns:
+// - []models.Permission: Slice of matched permissions.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindPermissionsByCode(codes []string) ([]models.Permission, error) {
+	return repo.permissionBase.FindAllByCondition("permissions.code IN ?", codes)
+}
+
+// FindRolePermissions retrieves the permissions granted to the role.
+// Parameters:
+// - roleID (uuid.UUID): The role id.
+// Returns:
+// - []models.Permission: Slice of granted permissions.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindRolePermissions(roleID uuid.UUID) ([]models.Permission, error) {
+	join := "INNER JOIN role_permissions ON role_permissions.permission_id = permissions.permission_id"
+	return repo.permissionBase.FindAllByConditionWithJoin(nil, join, "role_permissions.role_id = ?", roleID)
+}
+
+// ReplaceRolePermissions replaces all the permissions granted to the role in a single transaction.
+// Parameters:
+// - roleID (uuid.UUID): The role id.
+// - permissions ([]models.Permission): The permissions to grant.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) ReplaceRolePermissions(roleID uuid.UUID, permissions []models.Permission) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_permissions.role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		if len(permissions) == 0 {
+			return nil
+		}
+
+		rolePermissions := make([]models.RolePermission, 0, len(permissions))
+		for _, permission := range permissions {
+			rolePermissions = append(rolePermissions, models.RolePermission{
+				RoleID:       roleID,
+				PermissionID: permission.PermissionID,
+			})
+		}
+
+		return tx.Omit("Role", "Permission").Create(&rolePermissions).Error
+	})
+}
+
+// FindUsersByRole retrieves the verified users having the role.
+// Parameters:
+// - roleID (uuid.UUID): The role id.
+// Returns:
+// - []models.User: Slice of matched users.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindUsersByRole(roleID uuid.UUID) ([]models.User, error) {
+	return repo.userBase.FindAllByCondition("users.role_id = ? AND users.is_verified = true", roleID)
+}
+
+// CountUsersByRole counts every user referencing the role, including the unverified
+// and the soft deleted ones, since their rows still hold the foreign key.
+// Parameters:
+// - roleID (uuid.UUID): The role id.
+// Returns:
+// - int64: Number of users referencing the role.
+// - error: Error if any occurred during the query.
+func (repo Repo) CountUsersByRole(roleID uuid.UUID) (int64, error) {
+	var count int64
+	err := repo.userBase.DB.Unscoped().Model(&models.User{}).Where("users.role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
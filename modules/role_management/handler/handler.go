@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/modules/role_management/service"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewRoleHandler() *Handler {
+	service := service.NewRoleService()
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetRoles godoc
+// @Summary      Get Roles
+// @Description  Returns all the roles with the permissions granted to them.
+// @Tags         Roles
+// @Produce      json
+// @Success      200  {array}   models.RoleResponse        "List of roles"
+// @Failure      403  {object}  models.BadRequestError     "Missing role.manage permission"
+// @Failure      500  {object}  models.InternalServerError "Internal server error"
+// @Router       /role [get]
+func (handler *Handler) GetRoles(context *gin.Context) {
+	roles, err := handler.service.GetRoles()
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, roles)
+}
+
+// GetRoleByID godoc
+// @Summary      Get Role by ID
+// @Description  Retrieves a role with the permissions granted to it.
+// @Tags         Roles
+// @Produce      json
+// @Param        id   path      string                     true  "Role ID"
+// @Success      200  {object}  models.RoleResponse        "Role data fetched successfully"
+// @Failure      400  {object}  models.BadRequestError     "Invalid ID or role not found"
+// @Failure      500  {object}  models.InternalServerError "Internal server error"
+// @Router       /role/{id} [get]
+func (handler *Handler) GetRoleByID(context *gin.Context) {
+	role, err := handler.service.GetRoleByID(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, role)
+}
+
+// AddRole godoc
+// @Summary      Create a Role
+// @Description  Creates a new role without any permission. The code is stored upper case and must be unique.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        role  body      models.RoleRequest         true  "Role details"
+// @Success      200   {object}  models.RoleResponse        "Role created"
+// @Failure      400   {object}  models.BadRequestError     "Invalid input or code already taken"
+// @Failure      500   {object}  models.InternalServerError "Internal server error"
+// @Router       /role [post]
+func (handler *Handler) AddRole(context *gin.Context) {
+	var request models.RoleRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid role request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide a valid name and code.")
+		return
+	}
+
+	role, err := handler.service.AddRole(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, role)
+}
+
+// UpdateRole godoc
+// @Summary      Update a Role
+// @Description  Updates the name, code and description of a role. The code of the ADMIN and CUSTOMER roles can not be changed.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                         true  "Role ID"
+// @Param        role  body      models.RoleRequest             true  "Role details"
+// @Success      200   {object}  models.SuccessResponse[string] "Role updated"
+// @Failure      400   {object}  models.BadRequestError         "Invalid input or role not found"
+// @Failure      500   {object}  models.InternalServerError     "Internal server error"
+// @Router       /role/{id} [put]
+func (handler *Handler) UpdateRole(context *gin.Context) {
+	var request models.RoleRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid role request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide a valid name and code.")
+		return
+	}
+
+	message, err := handler.service.UpdateRole(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// DeleteRole godoc
+// @Summary      Delete a Role
+// @Description  Deletes a role. Roles still assigned to users and the ADMIN and CUSTOMER roles can not be deleted.
+// @Tags         Roles
+// @Produce      json
+// @Param        id   path      string                         true  "Role ID"
+// @Success      200  {object}  models.SuccessResponse[string] "Role deleted"
+// @Failure      400  {object}  models.BadRequestError         "Role not found or still in use"
+// @Failure      500  {object}  models.InternalServerError     "Internal server error"
+// @Router       /role/{id} [delete]
+func (handler *Handler) DeleteRole(context *gin.Context) {
+	message, err := handler.service.DeleteRole(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// SetRolePermissions godoc
+// @Summary      Set Role Permissions
+// @Description  Replaces the permissions granted to a role. The ADMIN role must keep the role.manage permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                         true  "Role ID"
+// @Param        permissions  body      models.RolePermissionsRequest  true  "Permission codes"
+// @Success      200          {object}  models.SuccessResponse[string] "Permissions updated"
+// @Failure      400          {object}  models.BadRequestError         "Invalid input, unknown permission or role not found"
+// @Failure      500          {object}  models.InternalServerError     "Internal server error"
+// @Router       /role/{id}/permissions [put]
+func (handler *Handler) SetRolePermissions(context *gin.Context) {
+	var request models.RolePermissionsRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid permissions request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the permission codes.")
+		return
+	}
+
+	message, err := handler.service.SetRolePermissions(context.Param("id"), request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// GetRoleUsers godoc
+// @Summary      Get Users by Role
+// @Description  Returns the verified users having the role.
+// @Tags         Roles
+// @Produce      json
+// @Param        id   path      string                     true  "Role ID"
+// @Success      200  {array}   models.UserResponse        "List of users"
+// @Failure      400  {object}  models.BadRequestError     "Invalid ID or role not found"
+// @Failure      500  {object}  models.InternalServerError "Internal server error"
+// @Router       /role/{id}/users [get]
+func (handler *Handler) GetRoleUsers(context *gin.Context) {
+	users, err := handler.service.GetRoleUsers(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, users)
+}
+
+// GetPermissions godoc
+// @Summary      Get Permissions
+// @Description  Returns all the permissions which can be granted to roles.
+// @Tags         Roles
+// @Produce      json
+// @Success      200  {array}   models.Permission          "List of permissions"
+// @Failure      500  {object}  models.InternalServerError "Internal server error"
+// @Router       /permission [get]
+func (handler *Handler) GetPermissions(context *gin.Context) {
+	permissions, err := handler.service.GetPermissions()
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, permissions)
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"e-commerce/middleware/auth"
+	"e-commerce/modules/role_management/handler"
+	"e-commerce/utils/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RoleManagementRoutes(router *gin.Engine) {
+	handler := handler.NewRoleHandler()
+
+	router.GET("/permission", auth.RequirePermission(constants.PERMISSION_ROLE_MANAGE), handler.GetPermissions)
+
+	{
+		role := router.Group("/role", auth.RequirePermission(constants.PERMISSION_ROLE_MANAGE))
+
+		role.POST("", handler.AddRole)
+
+		role.GET("", handler.GetRoles)
+
+		role.GET("/:id", handler.GetRoleByID)
+
+		role.PUT("/:id", handler.UpdateRole)
+
+		role.DELETE("/:id", handler.DeleteRole)
+
+		role.PUT("/:id/permissions", handler.SetRolePermissions)
+
+		role.GET("/:id/users", auth.RequirePermission(constants.PERMISSION_USER_READ), handler.GetRoleUsers)
+	}
+
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"e-commerce/modules/role_management/dbAccess"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// systemRoles are looked up by code by the application itself
+// (registration assigns CUSTOMER, the last admin guard counts ADMIN),
+// so their code can not be changed and they can not be deleted.
+var systemRoles = []string{constants.ROLE_ADMIN, constants.ROLE_CUSTOMER}
+
+// Service provides role management operations: role CRUD,
+// assignment of permissions to roles and listing the users of a role.
+type Service struct {
+	repo *dbAccess.Repo
+}
+
+// NewRoleService creates and returns a new Role Service instance by initializing the repository.
+// Returns:
+//
+//	*Service: A pointer to a new Service instance with its repository initialized.
+func NewRoleService() *Service {
+	repo := dbAccess.NewRoleRepository()
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetRoles returns all the roles with the permissions granted to them.
+//
+// Returns:
+//
+//	[]models.RoleResponse: The list of roles.
+//	error: An error if the query fails.
+func (service *Service) GetRoles() ([]models.RoleResponse, error) {
+	roles, err := service.repo.FindAll("name")
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	result := make([]models.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		permissions, err := service.repo.FindRolePermissions(role.RoleID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, role.ResponseObj(permissions))
+	}
+
+	return result, nil
+}
+
+// GetRoleByID returns the role with the permissions granted to it.
+//
+// Parameters:
+//
+//	id (string): The role id (uuid).
+//
+// Returns:
+//
+//	models.RoleResponse: The role.
+//	error: An error if the id is invalid or the role does not exist.
+func (service *Service) GetRoleByID(id string) (models.RoleResponse, error) {
+	role, err := service.getRole(id)
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+
+	permissions, err := service.repo.FindRolePermissions(role.RoleID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.RoleResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.RoleResponse{}, err
+	}
+
+	return role.ResponseObj(permissions), nil
+}
+
+// AddRole creates a new role without any permission.
+// The code is stored upper case, like the seeded roles.
+//
+// Parameters:
+//
+//	request (models.RoleRequest): The role details.
+//
+// Returns:
+//
+//	models.RoleResponse: The created role.
+//	error: An error if the code is already taken or the creation fails.
+func (service *Service) AddRole(request models.RoleRequest) (models.RoleResponse, error) {
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+
+	existing, err := service.repo.GetByCondition("roles.code = ?", code)
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+
+	if existing != nil {
+		return models.RoleResponse{}, fmt.Errorf("a role with code %s already exists", code)
+	}
+
+	role := models.Role{
+		Name:        request.Name,
+		Code:        code,
+		Description: request.Description,
+	}
+
+	if err := service.repo.Create(&role); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return models.RoleResponse{}, fmt.Errorf(pgErr.Detail)
+		}
+		return models.RoleResponse{}, err
+	}
+
+	return role.ResponseObj(nil), nil
+}
+
+// UpdateRole updates the name, code and description of the role.
+// The code of the system roles (ADMIN, CUSTOMER) can not be changed.
+// Users cached by the auth middleware see the new code once their cache entry expires.
+//
+// Parameters:
+//
+//	id (string): The role id (uuid).
+//	request (models.RoleRequest): The new role details.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the role does not exist, the code is taken or the update fails.
+func (service *Service) UpdateRole(id string, request models.RoleRequest) (string, error) {
+	role, err := service.getRole(id)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+
+	if code != role.Code {
+		if slices.Contains(systemRoles, role.Code) {
+			return "", fmt.Errorf("the code of the %s role can not be changed", role.Code)
+		}
+
+		existing, err := service.repo.GetByCondition("roles.code = ?", code)
+		if err != nil {
+			return "", err
+		}
+
+		if existing != nil {
+			return "", fmt.Errorf("a role with code %s already exists", code)
+		}
+	}
+
+	role.Name = request.Name
+	role.Code = code
+	role.Description = request.Description
+
+	if err := service.repo.Update(role); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Role successfully updated", nil
+}
+
+// DeleteRole deletes the role and the permissions granted to it.
+// A role still referenced by any user, or a system role, can not be deleted.
+//
+// Parameters:
+//
+//	id (string): The role id (uuid).
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the role does not exist, is in use or the deletion fails.
+func (service *Service) DeleteRole(id string) (string, error) {
+	role, err := service.getRole(id)
+	if err != nil {
+		return "", err
+	}
+
+	if slices.Contains(systemRoles, role.Code) {
+		return "", fmt.Errorf("the %s role can not be deleted", role.Code)
+	}
+
+	count, err := service.repo.CountUsersByRole(role.RoleID)
+	if err != nil {
+		return "", err
+	}
+
+	if count > 0 {
+		return "", fmt.Errorf("the role is assigned to %d user(s), reassign them before deleting the role", count)
+	}
+
+	if err := service.repo.Delete(role); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	services.InvalidateRolePermissionsCache(role.RoleID)
+
+	return "Role successfully deleted", nil
+}
+
+// SetRolePermissions replaces the permissions granted to the role.
+// The ADMIN role must keep the role.manage permission, otherwise nobody
+// could manage the roles any more.
+//
+// Parameters:
+//
+//	id (string): The role id (uuid).
+//	request (models.RolePermissionsRequest): The codes of the permissions to grant.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the role or any permission does not exist or the update fails.
+func (service *Service) SetRolePermissions(id string, request models.RolePermissionsRequest) (string, error) {
+	role, err := service.getRole(id)
+	if err != nil {
+		return "", err
+	}
+
+	codes := []string{}
+	for _, code := range request.Permissions {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+
+	if role.Code == constants.ROLE_ADMIN && !slices.Contains(codes, constants.PERMISSION_ROLE_MANAGE) {
+		return "", fmt.Errorf("the %s role must keep the %s permission", constants.ROLE_ADMIN, constants.PERMISSION_ROLE_MANAGE)
+	}
+
+	permissions := []models.Permission{}
+	if len(codes) > 0 {
+		permissions, err = service.repo.FindPermissionsByCode(codes)
+		if err != nil {
+			if pgErr, ok := err.(*pq.Error); ok {
+				return "", fmt.Errorf(pgErr.Detail)
+			}
+			return "", err
+		}
+	}
+
+	if len(permissions) != len(codes) {
+		for _, permission := range permissions {
+			codes = slices.DeleteFunc(codes, func(code string) bool { return code == permission.Code })
+		}
+		return "", fmt.Errorf("unknown permission(s): %s", strings.Join(codes, ", "))
+	}
+
+	if err := service.repo.ReplaceRolePermissions(role.RoleID, permissions); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	services.InvalidateRolePermissionsCache(role.RoleID)
+
+	return "Role permissions successfully updated", nil
+}
+
+// GetRoleUsers returns the verified users having the role.
+//
+// Parameters:
+//
+//	id (string): The role id (uuid).
+//
+// Returns:
+//
+//	[]models.UserResponse: The users of the role.
+//	error: An error if the role does not exist or the query fails.
+func (service *Service) GetRoleUsers(id string) ([]models.UserResponse, error) {
+	role, err := service.getRole(id)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := service.repo.FindUsersByRole(role.RoleID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	result := models.UserList(users).ResponseList()
+	if result == nil {
+		result = []models.UserResponse{}
+	}
+
+	return result, nil
+}
+
+// GetPermissions returns all the permissions which can be granted to roles.
+//
+// Returns:
+//
+//	[]models.Permission: The list of permissions.
+//	error: An error if the query fails.
+func (service *Service) GetPermissions() ([]models.Permission, error) {
+	permissions, err := service.repo.FindAllPermissions()
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// getRole parses the id and loads the role.
+func (service *Service) getRole(id string) (*models.Role, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	role, err := service.repo.GetByCondition("roles.role_id = ?", parsedUUID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if role == nil {
+		return nil, fmt.Errorf("no role found with id = %s", parsedUUID)
+	}
+
+	return role, nil
+}
@@ -2,17 +2,27 @@ package dbAccess
 
 import (
 	"e-commerce/database/connections"
+	"errors"
 
 	"e-commerce/base"
 	"e-commerce/shared/models"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repo defines a concrete implementation of user-specific repository
 // using the generic BaseRepository from the shared layer.
 type Repo struct {
-	base base.BaseRepository[models.User]
+	base             base.BaseRepository[models.User]
+	passwordBase     base.BaseRepository[models.UserPassword]
+	roleBase         base.BaseRepository[models.Role]
+	twoFactorBase    base.BaseRepository[models.UserTwoFactor]
+	recoveryCodeBase base.BaseRepository[models.UserRecoveryCode]
+	identityBase     base.BaseRepository[models.UserIdentity]
 }
 
 // NewUserRepository creates a new instance of the User repository.
@@ -20,7 +30,12 @@ type Repo struct {
 // - *Repo: Pointer to a new Repo with injected DB and Redis client.
 func NewUserRepository() *Repo {
 	return &Repo{
-		base: *base.NewBaseRepository[models.User](connections.GetDB(), connections.GetRedisClient()),
+		base:             *base.NewBaseRepository[models.User](connections.GetDB(), connections.GetRedisClient()),
+		passwordBase:     *base.NewBaseRepository[models.UserPassword](connections.GetDB(), connections.GetRedisClient()),
+		roleBase:         *base.NewBaseRepository[models.Role](connections.GetDB(), connections.GetRedisClient()),
+		twoFactorBase:    *base.NewBaseRepository[models.UserTwoFactor](connections.GetDB(), connections.GetRedisClient()),
+		recoveryCodeBase: *base.NewBaseRepository[models.UserRecoveryCode](connections.GetDB(), connections.GetRedisClient()),
+		identityBase:     *base.NewBaseRepository[models.UserIdentity](connections.GetDB(), connections.GetRedisClient()),
 	}
 }
 
@@ -32,6 +47,13 @@ func (repo Repo) GetFilter() *gorm.DB {
 	return repo.base.DB.Model(&models.User{})
 }
 
+// GetUnscopedFilter returns a GORM query builder for the User model including the soft-deleted users.
+// Returns:
+// - *gorm.DB: GORM DB model scoped to User, without the soft-delete scope.
+func (repo Repo) GetUnscopedFilter() *gorm.DB {
+	return repo.base.DB.Unscoped().Model(&models.User{})
+}
+
 // Create inserts a new user record into the database.
 // Parameters:
 // - user (*models.User): Pointer to the user to be created.
@@ -62,6 +84,18 @@ func (repo Repo) GetByCondition(condition any, args ...any) (*models.User, error
 	return repo.base.GetByCondition(condition, args...)
 }
 
+// GetByConditionWithRelations retrieves a single user matching a condition with relations preloaded.
+// Parameters:
+// - relations ([]string): List of relations to preload (e.g., "Role").
+// - condition (any): The SQL WHERE condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.User: Pointer to the matched user, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetByConditionWithRelations(relations []string, condition any, args ...any) (*models.User, error) {
+	return repo.base.GetByConditionWithRelations(relations, condition, args...)
+}
+
 // FindAll retrieves a list of users based on filters, ordering, and pagination.
 // Parameters:
 // - filters (*gorm.DB): Query filters.
@@ -76,6 +110,33 @@ func (repo Repo) FindAll(filters *gorm.DB, orderBy string, limit, offset int) ([
 	return repo.base.FindAll(filters, orderBy, limit, offset)
 }
 
+// FindPage retrieves one page of the users matching the filters, with the total number of matching users.
+// Parameters:
+// - filters (*gorm.DB): Query filters.
+// - keyset (string): WHERE clause selecting the users after the cursor (can be empty).
+// - keysetArgs ([]any): Arguments for the keyset clause.
+// - orderBy (string): Order clause (e.g. "users.created_at DESC, users.user_id").
+// - limit (int): Number of records per page.
+// - offset (int): Offset for pagination.
+// Returns:
+// - []models.User: Slice of user records.
+// - int64: Total number of matched records.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindPage(filters *gorm.DB, keyset string, keysetArgs []any, orderBy string, limit, offset int) ([]models.User, int64, error) {
+	return repo.base.FindPage(filters, keyset, keysetArgs, orderBy, limit, offset)
+}
+
+// FindInBatches processes all the users matching the filters in batches, ordered by user id.
+// Parameters:
+// - filters (*gorm.DB): Query filters.
+// - batchSize (int): Number of users per batch.
+// - process (func([]models.User) error): Called with every batch; an error stops the processing.
+// Returns:
+// - error: Error if any occurred during the query or returned by process.
+func (repo Repo) FindInBatches(filters *gorm.DB, batchSize int, process func([]models.User) error) error {
+	return repo.base.FindInBatches(filters, batchSize, process)
+}
+
 // FindAllByConditionWithJoin retrieves users with JOINs and WHERE condition.
 // Parameters:
 // - relations ([]string): List of relations to preload (e.g., "Orders", "Profile").
@@ -123,3 +184,380 @@ func (repo Repo) Update(user *models.User) error {
 func (repo Repo) Delete(user *models.User, isSoftDelete bool) error {
 	return repo.base.Delete(user, isSoftDelete)
 }
+
+// CreateMany inserts the users with their passwords in a single transaction.
+// Parameters:
+// - users ([]models.User): The users to create.
+// Returns:
+// - error: Error if any occurred, in which case no user is created.
+func (repo Repo) CreateMany(users []models.User) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&users).Error
+	})
+}
+
+// FindRegisteredEmails returns the given emails that are already used by a user, deleted users included.
+// Parameters:
+// - emails ([]string): The emails to check.
+// Returns:
+// - []string: The registered emails.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindRegisteredEmails(emails []string) ([]string, error) {
+	var registered []string
+	err := repo.GetUnscopedFilter().Where("LOWER(users.email) IN ?", emails).Pluck("LOWER(users.email)", &registered).Error
+	return registered, err
+}
+
+// GetUnscopedByCondition retrieves a single user matching a condition, soft-deleted users included.
+// Parameters:
+// - condition (any): The SQL WHERE condition.
+// - args (...any): Arguments for the condition.
+// Returns:
+// - *models.User: Pointer to the matched user, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetUnscopedByCondition(condition any, args ...any) (*models.User, error) {
+	var user models.User
+	if err := repo.GetUnscopedFilter().Where(condition, args...).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore brings back a soft-deleted user and cancels a pending deletion request.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - bool: Whether a deleted user has been restored.
+// - error: Error if any occurred during the update.
+func (repo Repo) Restore(userID uuid.UUID) (bool, error) {
+	result := repo.GetUnscopedFilter().
+		Where("users.user_id = ? AND users.deleted_at IS NOT NULL", userID).
+		Updates(map[string]any{"deleted_at": nil, "deletion_requested_at": nil})
+	return result.RowsAffected == 1, result.Error
+}
+
+// HardDelete permanently removes the user together with the records owned by the user.
+// Parameters:
+// - user (*models.User): Pointer to the user, deleted or not.
+// Returns:
+// - error: Error if any occurred during the deletion.
+func (repo Repo) HardDelete(user *models.User) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		owned := []any{
+			&models.UserRecoveryCode{},
+			&models.UserTwoFactor{},
+			&models.UserIdentity{},
+			&models.LoginEvent{},
+			&models.UserPassword{},
+			&models.Address{},
+		}
+
+		for _, model := range owned {
+			if err := tx.Unscoped().Where("user_id = ?", user.UserID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return base.NewBaseRepository[models.User](tx, repo.base.RedisClient).Delete(user, false)
+	})
+}
+
+// ErrUserNotFound is returned when the user to update does not exist (any more).
+var ErrUserNotFound = errors.New("user not found")
+
+// UpdatePassword replaces the stored password (hash) of the given user.
+// Parameters:
+// - userID (uuid.UUID): The user whose password is updated.
+// - passwordHash (string): The already hashed password.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) UpdatePassword(userID uuid.UUID, passwordHash string) error {
+	record := map[string]any{
+		"password": passwordHash,
+	}
+	return repo.passwordBase.UpdateSpecificRecord(record, "user_passwords.user_id = ?", userID)
+}
+
+// SetPasswordAndVerify stores the password (hash) of the user and marks the user as verified, in one transaction.
+// Parameters:
+// - userID (uuid.UUID): The user whose password is set.
+// - passwordHash (string): The already hashed password.
+// Returns:
+// - error: ErrUserNotFound if the user or its password record does not exist, or any error of the update,
+// in which case nothing is changed.
+func (repo Repo) SetPasswordAndVerify(userID uuid.UUID, passwordHash string) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserPassword{}).Where("user_passwords.user_id = ?", userID).Update("password", passwordHash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrUserNotFound
+		}
+
+		result = tx.Model(&models.User{}).Where("users.user_id = ?", userID).Update("is_verified", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+// GetRoleByCode retrieves a role by its code.
+// Parameters:
+// - code (string): The role code (e.g. "CUSTOMER").
+// Returns:
+// - *models.Role: Pointer to the role, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetRoleByCode(code string) (*models.Role, error) {
+	return repo.roleBase.GetByCondition("roles.code = ?", code)
+}
+
+// GetRoleByID retrieves a role by its id.
+// Parameters:
+// - roleID (uuid.UUID): The role id.
+// Returns:
+// - *models.Role: Pointer to the role, or nil if not found.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
+	return repo.roleBase.GetByCondition("roles.role_id = ?", roleID)
+}
+
+// CountActiveUsersByRoleCode counts the verified, not deleted users having the role with the given code.
+// Parameters:
+// - code (string): The role code (e.g. "ADMIN").
+// Returns:
+// - int64: Number of matched users.
+// - error: Error if any occurred during the query.
+func (repo Repo) CountActiveUsersByRoleCode(code string) (int64, error) {
+	var count int64
+	err := repo.GetFilter().
+		Joins("INNER JOIN roles ON roles.role_id = users.role_id").
+		Where("roles.code = ? AND users.is_verified = true", code).
+		Count(&count).Error
+	return count, err
+}
+
+// ErrLastOfRole is returned when a change would remove the last active user of a role.
+var ErrLastOfRole = errors.New("the last user of the role can not be removed")
+
+// WithTx returns a copy of the repository running its queries in the given transaction.
+// Parameters:
+// - tx (*gorm.DB): The transaction.
+// Returns:
+// - *Repo: Pointer to the repository bound to the transaction.
+func (repo Repo) WithTx(tx *gorm.DB) *Repo {
+	return &Repo{
+		base:             *base.NewBaseRepository[models.User](tx, repo.base.RedisClient),
+		passwordBase:     *base.NewBaseRepository[models.UserPassword](tx, repo.base.RedisClient),
+		roleBase:         *base.NewBaseRepository[models.Role](tx, repo.base.RedisClient),
+		twoFactorBase:    *base.NewBaseRepository[models.UserTwoFactor](tx, repo.base.RedisClient),
+		recoveryCodeBase: *base.NewBaseRepository[models.UserRecoveryCode](tx, repo.base.RedisClient),
+		identityBase:     *base.NewBaseRepository[models.UserIdentity](tx, repo.base.RedisClient),
+	}
+}
+
+// Transaction runs the change in a transaction, committed if the change returns no error.
+// Parameters:
+// - change (func(tx *gorm.DB) error): The change, run in the transaction.
+// Returns:
+// - error: Error returned by the change or by the commit.
+func (repo Repo) Transaction(change func(tx *gorm.DB) error) error {
+	return repo.base.DB.Transaction(change)
+}
+
+// RemoveFromRole runs a change which may take the user out of the role (deletion, erasure, role change)
+// in a transaction locking the rows of the active users of the role (SELECT ... FOR UPDATE).
+// Concurrent removals wait for each other, so they can not remove the last users of the role together.
+// Parameters:
+// - userID (uuid.UUID): The user about to leave the role.
+// - code (string): The role code (e.g. "ADMIN").
+// - change (func(tx *gorm.DB) error): The change, run in the transaction once the rows are locked.
+// Returns:
+// - error: ErrLastOfRole if the user is the last active user of the role, or the error of the change.
+func (repo Repo) RemoveFromRole(userID uuid.UUID, code string, change func(tx *gorm.DB) error) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		var userIDs []uuid.UUID
+		err := tx.Model(&models.User{}).
+			Joins("INNER JOIN roles ON roles.role_id = users.role_id").
+			Where("roles.code = ? AND users.is_verified = true", code).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "users"}}).
+			Pluck("users.user_id", &userIDs).Error
+		if err != nil {
+			return err
+		}
+
+		if len(userIDs) <= 1 && slices.Contains(userIDs, userID) {
+			return ErrLastOfRole
+		}
+
+		return change(tx)
+	})
+}
+
+// GetTwoFactor retrieves the 2FA settings of the user.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - *models.UserTwoFactor: Pointer to the 2FA settings, or nil if 2FA was never enrolled.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetTwoFactor(userID uuid.UUID) (*models.UserTwoFactor, error) {
+	return repo.twoFactorBase.GetByCondition("user_two_factors.user_id = ?", userID)
+}
+
+// SaveTwoFactor creates or updates the 2FA settings of a user.
+// Parameters:
+// - twoFactor (*models.UserTwoFactor): Pointer to the 2FA settings.
+// Returns:
+// - error: Error if any occurred during the save.
+func (repo Repo) SaveTwoFactor(twoFactor *models.UserTwoFactor) error {
+	return repo.twoFactorBase.DB.Omit(clause.Associations).Save(twoFactor).Error
+}
+
+// UpdateTwoFactor updates specific fields of the 2FA settings.
+// Parameters:
+// - twoFactorID (uuid.UUID): The id of the 2FA settings.
+// - record (map[string]any): Fields to update with their values.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) UpdateTwoFactor(twoFactorID uuid.UUID, record map[string]any) error {
+	return repo.twoFactorBase.UpdateSpecificRecord(record, "user_two_factors.user_two_factor_id = ?", twoFactorID)
+}
+
+// MarkTwoFactorStepUsed records the time step of an accepted TOTP code.
+// The update only succeeds for a step newer than the last used one,
+// so two concurrent requests can not both use the same code.
+// Parameters:
+// - twoFactorID (uuid.UUID): The id of the 2FA settings.
+// - step (int64): The time step of the accepted code.
+// Returns:
+// - bool: Whether the step has been recorded (false if the code was already used).
+// - error: Error if any occurred during the update.
+func (repo Repo) MarkTwoFactorStepUsed(twoFactorID uuid.UUID, step int64) (bool, error) {
+	result := repo.twoFactorBase.DB.Model(&models.UserTwoFactor{}).
+		Where("user_two_factors.user_two_factor_id = ? AND user_two_factors.last_used_step < ?", twoFactorID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteTwoFactor permanently removes the 2FA settings and the recovery codes of the user.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - error: Error if any occurred during the deletion.
+func (repo Repo) DeleteTwoFactor(userID uuid.UUID) error {
+	return repo.twoFactorBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_recovery_codes.user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_two_factors.user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes replaces all the recovery codes of the user.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// - codeHashes ([]string): The hashes of the new recovery codes.
+// Returns:
+// - error: Error if any occurred during the update.
+func (repo Repo) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	return repo.recoveryCodeBase.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_recovery_codes.user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.UserRecoveryCode, 0, len(codeHashes))
+		for _, codeHash := range codeHashes {
+			codes = append(codes, models.UserRecoveryCode{UserID: userID, CodeHash: codeHash})
+		}
+
+		return tx.Omit(clause.Associations).Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode consumes an unused recovery code of the user.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// - codeHash (string): The hash of the presented recovery code.
+// Returns:
+// - bool: Whether an unused code matched and has been consumed.
+// - error: Error if any occurred during the update.
+func (repo Repo) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result := repo.recoveryCodeBase.DB.Model(&models.UserRecoveryCode{}).
+		Where("user_recovery_codes.user_id = ? AND user_recovery_codes.code_hash = ? AND user_recovery_codes.used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// GetIdentity retrieves the external identity of a provider.
+// Parameters:
+// - provider (string): The provider name.
+// - subject (string): The subject (sub) of the identity at the provider.
+// Returns:
+// - *models.UserIdentity: Pointer to the identity, or nil if it is not linked to any user.
+// - error: Error if any occurred during the query.
+func (repo Repo) GetIdentity(provider string, subject string) (*models.UserIdentity, error) {
+	return repo.identityBase.GetByCondition("user_identities.provider = ? AND user_identities.subject = ?", provider, subject)
+}
+
+// FindIdentities retrieves the external identities linked to the user.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - []models.UserIdentity: The linked identities.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindIdentities(userID uuid.UUID) ([]models.UserIdentity, error) {
+	return repo.identityBase.FindAllByCondition("user_identities.user_id = ?", userID)
+}
+
+// CreateIdentity links an external identity to a user.
+// Parameters:
+// - identity (*models.UserIdentity): Pointer to the identity to create.
+// Returns:
+// - error: Error if any occurred during creation.
+func (repo Repo) CreateIdentity(identity *models.UserIdentity) error {
+	return repo.identityBase.DB.Omit(clause.Associations).Create(identity).Error
+}
+
+// CreateWithIdentity creates a user together with its first external identity in a single transaction.
+// Parameters:
+// - user (*models.User): Pointer to the user to create.
+// - identity (*models.UserIdentity): Pointer to the identity; its UserID is set from the created user.
+// Returns:
+// - error: Error if any occurred during creation.
+func (repo Repo) CreateWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return repo.base.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.UserID
+		return tx.Omit(clause.Associations).Create(identity).Error
+	})
+}
+
+// CreateLoginEvent records a successful login in the login history.
+// Parameters:
+// - event (*models.LoginEvent): Pointer to the login event to create.
+// Returns:
+// - error: Error if any occurred during creation.
+func (repo Repo) CreateLoginEvent(event *models.LoginEvent) error {
+	return repo.base.DB.Create(event).Error
+}
+
+// FindLoginEvents retrieves the login history of the user, the latest login first.
+// Parameters:
+// - userID (uuid.UUID): The user id.
+// Returns:
+// - []models.LoginEvent: The logins of the user.
+// - error: Error if any occurred during the query.
+func (repo Repo) FindLoginEvents(userID uuid.UUID) ([]models.LoginEvent, error) {
+	var events []models.LoginEvent
+	err := repo.base.DB.Where("login_events.user_id = ?", userID).Order("login_events.created_at DESC").Find(&events).Error
+	return events, err
+}
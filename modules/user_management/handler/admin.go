@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUsersForAdmin godoc
+// @Summary      List Users for Admins
+// @Description  Returns one page of the users whatever their status: unverified users are included unless is_verified is given, and is_deleted=true lists the soft-deleted users. Supports the pagination and sorting parameters of GET /user.
+// @Tags         User Administration
+// @Produce      json
+// @Param        is_verified  query     bool    false  "Filter by email verification status"
+// @Param        is_deleted   query     bool    false  "List the soft-deleted users"
+// @Param        first_name   query     string  false  "Filter by first name (partial match)"
+// @Param        last_name    query     string  false  "Filter by last name (partial match)"
+// @Param        email        query     string  false  "Filter by email"
+// @Param        role_id      query     string  false  "Filter by role id"
+// @Param        page         query     int     false  "Page number, starting from 1"
+// @Param        limit        query     int     false  "Page size"
+// @Param        cursor       query     string  false  "Cursor of the page (next_cursor of the previous page)"
+// @Param        sort         query     string  false  "Sort columns, prefixed with - for descending order, e.g. -created_at"
+// @Success      200          {object}  models.ResponseWithPagination  "Page of users with their status"
+// @Failure      400          {object}  models.BadRequestError         "Invalid query parameters"
+// @Failure      403          {object}  models.ForbiddenError          "Missing user.admin permission"
+// @Router       /admin/users [get]
+func (handler *Handler) GetUsersForAdmin(context *gin.Context) {
+	queryParams := &models.UserQueryParams{}
+
+	if err := context.ShouldBindQuery(queryParams); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, pagination, err := handler.service.GetUsersForAdmin(queryParams)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.PaginatedResponseWriter(context, users, pagination)
+}
+
+// RestoreUser godoc
+// @Summary      Restore a Deleted User
+// @Description  Brings back a soft-deleted user and cancels a pending deletion request of the user.
+// @Tags         User Administration
+// @Produce      json
+// @Param        id   path      string                          true  "User ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "User restored"
+// @Failure      400  {object}  models.BadRequestError          "User not found or not deleted"
+// @Failure      403  {object}  models.ForbiddenError           "Missing user.admin permission"
+// @Router       /admin/users/{id}/restore [post]
+func (handler *Handler) RestoreUser(context *gin.Context) {
+	message, err := handler.service.RestoreUser(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// HardDeleteUser godoc
+// @Summary      Permanently Delete a User
+// @Description  Erases a user (deleted or not) with the password, 2FA settings, linked identities and addresses of the user, e.g. for a GDPR erasure request. This can not be undone.
+// @Tags         User Administration
+// @Produce      json
+// @Param        id   path      string                          true  "User ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "User permanently deleted"
+// @Failure      400  {object}  models.BadRequestError          "User not found or last admin"
+// @Failure      403  {object}  models.ForbiddenError           "Missing user.admin permission"
+// @Router       /admin/users/{id} [delete]
+func (handler *Handler) HardDeleteUser(context *gin.Context) {
+	message, err := handler.service.HardDeleteUser(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// VerifyUser godoc
+// @Summary      Verify a User Manually
+// @Description  Marks the email of a pending user as verified without the OTP.
+// @Tags         User Administration
+// @Produce      json
+// @Param        id   path      string                          true  "User ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "User verified"
+// @Failure      400  {object}  models.BadRequestError          "User not found, deleted or already verified"
+// @Failure      403  {object}  models.ForbiddenError           "Missing user.admin permission"
+// @Router       /admin/users/{id}/verify [post]
+func (handler *Handler) VerifyUser(context *gin.Context) {
+	message, err := handler.service.VerifyUser(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// ForcePasswordReset godoc
+// @Summary      Force a Password Reset
+// @Description  Clears the password of a user, logs out every device of the user and emails a link to set a new password.
+// @Tags         User Administration
+// @Produce      json
+// @Param        id   path      string                          true  "User ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Password cleared and link sent"
+// @Failure      400  {object}  models.BadRequestError          "User not found, deleted or not verified"
+// @Failure      403  {object}  models.ForbiddenError           "Missing user.admin permission"
+// @Router       /admin/users/{id}/force-password-reset [post]
+func (handler *Handler) ForcePasswordReset(context *gin.Context) {
+	message, err := handler.service.ForcePasswordReset(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportUsers godoc
+// @Summary      Import Users from CSV
+// @Description  Registers the customers listed in a CSV file with the columns first_name, last_name, email and phone, at most 5000 rows. The file is sent as the "file" field of a multipart form or as a text/csv body and is validated row by row; the valid rows are created and get an email with a link to set their password, the first 100 rejected rows are reported with their line.
+// @Tags         Users
+// @Accept       mpfd
+// @Accept       text/csv
+// @Produce      json
+// @Param        file  formData  file                                     false  "CSV file"
+// @Success      200   {object}  models.SuccessResponse[UserImportResult]  "Import report"
+// @Failure      400   {object}  models.BadRequestError                    "Invalid file or header, or too many rows"
+// @Failure      403   {object}  models.ForbiddenError                     "Missing user.manage permission"
+// @Router       /user/import [post]
+func (handler *Handler) ImportUsers(context *gin.Context) {
+	reader, err := csvUpload(context)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := handler.service.ImportUsers(reader)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, result)
+}
+
+// ExportUsers godoc
+// @Summary      Export Users
+// @Description  Streams the verified users matching the filters of GET /user as CSV or as JSON lines.
+// @Tags         Users
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format      query     string  false  "csv (default) or jsonl"
+// @Param        first_name  query     string  false  "Filter by first name (partial match)"
+// @Param        last_name   query     string  false  "Filter by last name (partial match)"
+// @Param        email       query     string  false  "Filter by email"
+// @Param        role_id     query     string  false  "Filter by role id"
+// @Success      200         {string}  string                  "The exported users"
+// @Failure      400         {object}  models.BadRequestError  "Invalid query parameters"
+// @Failure      403         {object}  models.ForbiddenError   "Missing user.read permission"
+// @Router       /user/export [get]
+func (handler *Handler) ExportUsers(context *gin.Context) {
+	queryParams := &models.UserQueryParams{}
+
+	if err := context.ShouldBindQuery(queryParams); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := context.DefaultQuery("format", "csv")
+
+	switch format {
+	case "csv":
+		context.Header("Content-Type", "text/csv; charset=utf-8")
+	case "jsonl":
+		context.Header("Content-Type", "application/x-ndjson")
+	default:
+		helper.ResponseWriter(context, http.StatusBadRequest, "Unknown export format, expects csv or jsonl.")
+		return
+	}
+
+	context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, format))
+	context.Status(http.StatusOK)
+
+	// the status and the first rows are already sent, a failure can only cut the export short
+	if err := handler.service.ExportUsers(queryParams, format, context.Writer); err != nil {
+		fmt.Printf("failed to export users: %v\n", err)
+	}
+}
+
+// csvUpload returns the uploaded CSV file without buffering it: the "file" part of a multipart form,
+// or the request body itself.
+func csvUpload(context *gin.Context) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(context.GetHeader("Content-Type"))
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return context.Request.Body, nil
+	}
+
+	multipartReader, err := context.Request.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart form")
+	}
+
+	for {
+		part, err := multipartReader.NextPart()
+		if err != nil {
+			return nil, fmt.Errorf("please upload the CSV file in the \"file\" field")
+		}
+
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestEmailChange godoc
+// @Summary      Change My Email
+// @Description  Starts the change of the email of the logged in user. An OTP is sent to the new address and the old address is notified; the email changes only once the OTP is confirmed.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ChangeEmailRequest       true  "New email and password (or OTP for users without a password)"
+// @Success      200      {object}  models.SuccessResponse[string]  "OTP sent to the new address"
+// @Failure      400      {object}  models.BadRequestError          "Wrong password or OTP, or email already in use"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError          "Requested again before the resend cooldown expired, or too many failed attempts (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me/email [post]
+func (handler *Handler) RequestEmailChange(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.ChangeEmailRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide a valid Email.")
+		return
+	}
+
+	message, err := handler.service.RequestEmailChange(user, request, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// ConfirmEmailChange godoc
+// @Summary      Confirm Email Change
+// @Description  Confirms a pending email change with the OTP sent to the new address. The email is changed and every session of the user is logged out.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.EmailOTPRequest          true  "New email and OTP"
+// @Success      200      {object}  models.SuccessResponse[string]  "Email changed"
+// @Failure      400      {object}  models.BadRequestError          "Invalid or expired OTP"
+// @Failure      429      {object}  models.BadRequestError          "Too many failed attempts from the client IP (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/email-change/confirm [post]
+func (handler *Handler) ConfirmEmailChange(context *gin.Context) {
+	var request models.EmailOTPRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the new Email and the OTP.")
+		return
+	}
+
+	message, err := handler.service.ConfirmEmailChange(request, context.ClientIP())
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
@@ -1,12 +1,19 @@
 package handler
 
 import (
+	"e-commerce/middleware/auth"
+	"e-commerce/middleware/validator"
 	"e-commerce/modules/user_management/service"
 	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
 	"e-commerce/utils/helper"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
@@ -30,6 +37,7 @@ func NewUserHandler() *Handler {
 // @Success      200        {object} models.SuccessResponse[LoginResponse] "Authenticated successfully with JWT token"
 // @Failure      400        {object} models.BadRequestError                    "Invalid or malformed request body"
 // @Failure      401        {object} models.UnauthorizedError                  "Invalid credentials or unauthorized access"
+// @Failure      429        {object} models.BadRequestError                    "Too many failed attempts, account locked or retry later (see Retry-After)"
 // @Failure      500        {object} models.InternalServerError                "Unexpected server error"
 // @Router       /login [post]
 func (handler *Handler) Login(context *gin.Context) {
@@ -41,8 +49,11 @@ func (handler *Handler) Login(context *gin.Context) {
 		return
 	}
 
-	data, err := handler.service.Login(loginData)
+	data, err := handler.service.Login(loginData, helper.GetClientInfo(context))
 	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
 		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -50,6 +61,96 @@ func (handler *Handler) Login(context *gin.Context) {
 	helper.ResponseWriter(context, http.StatusOK, data)
 }
 
+// RefreshToken godoc
+// @Summary      Refresh Access Token
+// @Description  Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used only once; reusing one logs out the whole session.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body     models.RefreshTokenRequest              true  "Refresh token"
+// @Success      200      {object} models.SuccessResponse[LoginResponse] "New access and refresh tokens"
+// @Failure      400      {object} models.BadRequestError                 "Invalid or malformed request body"
+// @Failure      401      {object} models.UnauthorizedError               "Invalid, expired, revoked or reused refresh token"
+// @Failure      500      {object} models.InternalServerError             "Unexpected server error"
+// @Router       /token/refresh [post]
+func (handler *Handler) RefreshToken(context *gin.Context) {
+	var request models.RefreshTokenRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Bad request data...!")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the refresh token.")
+		return
+	}
+
+	data, err := handler.service.RefreshToken(request.RefreshToken, helper.GetClientInfo(context))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Logs out the current session. The access token is revoked along with the refresh tokens of the session.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object} models.SuccessResponse[string] "Logged out successfully"
+// @Failure      401  {object} models.UnauthorizedError       "Unauthorized access attempt"
+// @Failure      500  {object} models.InternalServerError     "Unexpected server error"
+// @Router       /logout [post]
+func (handler *Handler) Logout(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	tokenData, ok := helper.GetAccessTokenData(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	message, err := handler.service.Logout(user, tokenData)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// LogoutAll godoc
+// @Summary      Logout From All Devices
+// @Description  Logs out every session of the logged in user by revoking all the refresh tokens and access tokens issued so far.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object} models.SuccessResponse[string] "Logged out from all the devices"
+// @Failure      401  {object} models.UnauthorizedError       "Unauthorized access attempt"
+// @Failure      500  {object} models.InternalServerError     "Unexpected server error"
+// @Router       /logout-all [post]
+func (handler *Handler) LogoutAll(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	message, err := handler.service.LogoutAll(user)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
 // GetUserByID godoc
 // @Summary      Get User by ID
 // @Description  Retrieves a user's details by their unique ID.
@@ -85,6 +186,7 @@ func (handler *Handler) GetUserByID(context *gin.Context) {
 // @Param        request  body      models.EmailOTPRequest         true  "Email and OTP"
 // @Success      200      {object}  models.User                    "User verified successfully"
 // @Failure      400      {object}  models.BadRequestError         "Missing or invalid OTP/email"
+// @Failure      429      {object}  models.BadRequestError         "Too many failed attempts from the client IP (see Retry-After)"
 // @Failure      500      {object}  models.InternalServerError     "Internal server error"
 // @Router       /user/verify-email [post]
 func (handler *Handler) VerifyEmail(context *gin.Context) {
@@ -107,8 +209,11 @@ func (handler *Handler) VerifyEmail(context *gin.Context) {
 		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide Email for verification.")
 		return
 	}
-	user, err := handler.service.VerifyEmail(email, otp)
+	user, err := handler.service.VerifyEmail(email, otp, context.ClientIP())
 	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
 		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -125,6 +230,7 @@ func (handler *Handler) VerifyEmail(context *gin.Context) {
 // @Param        request  body      models.ResendEmailRequest      true  "Email for which to resend OTP"
 // @Success      200      {object}  models.User                    "OTP sent successfully"
 // @Failure      400      {object}  models.BadRequestError         "Missing or invalid email"
+// @Failure      429      {object}  models.BadRequestError         "Requested again before the resend cooldown expired (see Retry-After)"
 // @Failure      500      {object}  models.InternalServerError     "Internal server error"
 // @Router       /user/resend-verification [post]
 func (handler *Handler) ResendVerificationCode(context *gin.Context) {
@@ -144,6 +250,9 @@ func (handler *Handler) ResendVerificationCode(context *gin.Context) {
 
 	user, err := handler.service.ResendVerificationCode(email)
 	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
 		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -153,17 +262,22 @@ func (handler *Handler) ResendVerificationCode(context *gin.Context) {
 
 // GetUsers godoc
 // @Summary      Get Users with Filters
-// @Description  Returns a list of users with optional filter/query parameters.
+// @Description  Returns one page of the verified users matching the optional filters. Pages are selected by number (page/limit) or by the next_cursor of the previous page; the limit is capped to the configured maximum page size.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
-// @Param        name       query     string  false  "Filter by name"
-// @Param        email      query     string  false  "Filter by email"
-// @Param        is_active  query     bool    false  "Filter by active status"
-// @Success      200        {array}   models.User                    "List of users"
-// @Failure      400        {object}  models.BadRequestError         "Invalid query parameters"
-// @Failure      500        {object}  models.InternalServerError     "Internal server error"
-// @Router       /users [get]
+// @Param        first_name  query     string  false  "Filter by first name (partial match)"
+// @Param        last_name   query     string  false  "Filter by last name (partial match)"
+// @Param        email       query     string  false  "Filter by email"
+// @Param        role_id     query     string  false  "Filter by role id"
+// @Param        page        query     int     false  "Page number, starting from 1"
+// @Param        limit       query     int     false  "Page size"
+// @Param        cursor      query     string  false  "Cursor of the page (next_cursor of the previous page)"
+// @Param        sort        query     string  false  "Sort columns (first_name, last_name, email, created_at, updated_at, user_id), prefixed with - for descending order, e.g. -created_at,last_name"
+// @Success      200         {object}  models.ResponseWithPagination  "Page of users"
+// @Failure      400         {object}  models.BadRequestError         "Invalid query parameters"
+// @Failure      500         {object}  models.InternalServerError     "Internal server error"
+// @Router       /user [get]
 func (handler *Handler) GetUsers(context *gin.Context) {
 
 	queryParams := &models.UserQueryParams{}
@@ -173,13 +287,13 @@ func (handler *Handler) GetUsers(context *gin.Context) {
 		return
 	}
 
-	users, err := handler.service.GetUsers(queryParams)
+	users, pagination, err := handler.service.GetUsers(queryParams)
 	if err != nil {
 		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	helper.ResponseWriter(context, http.StatusOK, users)
+	helper.PaginatedResponseWriter(context, users, pagination)
 }
 
 // RegisterHandler godoc
@@ -212,6 +326,148 @@ func (handler *Handler) AddUser(context *gin.Context) {
 	helper.ResponseWriter(context, http.StatusOK, message)
 }
 
+// CreateUser godoc
+// @Summary      Create a User
+// @Description  Creates a user on behalf of an admin. No password is set; a single-use, time-limited link to set the password is emailed to the user instead.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        userDetails  body     models.CreateUserRequest       true  "User details"
+// @Success      200          {object} models.SuccessResponse[string] "User created and set-password link sent"
+// @Failure      400          {object} models.BadRequestError         "Invalid input or missing required fields"
+// @Failure      401          {object} models.UnauthorizedError       "Unauthorized access attempt"
+// @Failure      403          {object} models.ForbiddenError          "Only admins can create users"
+// @Failure      500          {object} models.InternalServerError     "Unexpected server error"
+// @Router       /user [post]
+func (handler *Handler) CreateUser(context *gin.Context) {
+
+	var request models.CreateUserRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid user request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid user request data.")
+		return
+	}
+
+	message, err := handler.service.CreateUser(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// SetPassword godoc
+// @Summary      Set Password
+// @Description  Sets the password of a user using the single-use token received in the set-password email.
+// @Tags         User Registration
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.SetPasswordRequest       true  "Token and new password"
+// @Success      200      {object}  models.SuccessResponse[string]  "Password set successfully"
+// @Failure      400      {object}  models.BadRequestError          "Invalid/expired token or password rejected by the policy"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/set-password [post]
+func (handler *Handler) SetPassword(context *gin.Context) {
+
+	var request models.SetPasswordRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide token and password.")
+		return
+	}
+
+	message, err := handler.service.SetPassword(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// ForgotPassword godoc
+// @Summary      Forgot Password
+// @Description  Sends an OTP to reset the password to the email address, if it belongs to a verified user.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ForgotPasswordRequest    true  "Email of the user"
+// @Success      200      {object}  models.SuccessResponse[string]  "OTP sent if the email is registered"
+// @Failure      400      {object}  models.BadRequestError          "Missing or invalid email"
+// @Failure      429      {object}  models.BadRequestError          "Requested again before the resend cooldown expired (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/forgot-password [post]
+func (handler *Handler) ForgotPassword(context *gin.Context) {
+
+	var request models.ForgotPasswordRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide a valid Email.")
+		return
+	}
+
+	message, err := handler.service.ForgotPassword(request)
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// ResetPassword godoc
+// @Summary      Reset Password
+// @Description  Resets the password using the OTP received by email. All the existing sessions of the user are logged out.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ResetPasswordRequest     true  "Email, OTP and the new password"
+// @Success      200      {object}  models.SuccessResponse[string]  "Password reset successfully"
+// @Failure      400      {object}  models.BadRequestError          "Invalid/expired OTP or password rejected by the policy"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/reset-password [post]
+func (handler *Handler) ResetPassword(context *gin.Context) {
+
+	var request models.ResetPasswordRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide Email, OTP and the new password.")
+		return
+	}
+
+	message, err := handler.service.ResetPassword(request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
 func (handler *Handler) UpdateUser(context *gin.Context) {
 	id := context.Param("id")
 	if id == "" {
@@ -226,6 +482,16 @@ func (handler *Handler) UpdateUser(context *gin.Context) {
 		return
 	}
 
+	if !canChangeRole(context, request.RoleID) {
+		helper.ResponseWriter(context, http.StatusForbidden, "You are not allowed to change the role.")
+		return
+	}
+
+	if !canChangeEmail(context, request.Email) {
+		helper.ResponseWriter(context, http.StatusForbidden, "Please change your email using /user/me/email.")
+		return
+	}
+
 	message, err := handler.service.UpdateUser(id, request)
 	if err != nil {
 		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
@@ -249,6 +515,16 @@ func (handler *Handler) PartialUpdateUser(context *gin.Context) {
 		return
 	}
 
+	if request.RoleID != uuid.Nil && !canChangeRole(context, request.RoleID) {
+		helper.ResponseWriter(context, http.StatusForbidden, "You are not allowed to change the role.")
+		return
+	}
+
+	if request.Email != "" && !canChangeEmail(context, request.Email) {
+		helper.ResponseWriter(context, http.StatusForbidden, "Please change your email using /user/me/email.")
+		return
+	}
+
 	message, err := handler.service.PartialUpdateUser(id, request)
 	if err != nil {
 		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
@@ -273,3 +549,39 @@ func (handler *Handler) DeleteUser(context *gin.Context) {
 
 	helper.ResponseWriter(context, http.StatusOK, message)
 }
+
+// canChangeRole reports whether the logged in user may set the given role on a user.
+// Users without the user.manage permission can only update their own record
+// and must keep their current role.
+func canChangeRole(context *gin.Context, roleID uuid.UUID) bool {
+	if auth.HasPermission(context, constants.PERMISSION_USER_MANAGE) {
+		return true
+	}
+
+	user, ok := helper.GetLoggedInUser(context)
+	return ok && user.RoleID == roleID
+}
+
+// canChangeEmail reports whether the logged in user may start an email change through the user routes.
+// Users without the user.manage permission have to use /user/me/email, which asks for the password.
+func canChangeEmail(context *gin.Context, email string) bool {
+	if auth.HasPermission(context, constants.PERMISSION_USER_MANAGE) {
+		return true
+	}
+
+	user, ok := helper.GetLoggedInUser(context)
+	return ok && strings.EqualFold(strings.TrimSpace(email), user.Email)
+}
+
+// writeThrottleError replies 429 with a Retry-After header if the error comes from
+// the brute force protection. It returns whether the response has been written.
+func writeThrottleError(context *gin.Context, err error) bool {
+	var throttleErr *helper.ThrottleError
+	if !errors.As(err, &throttleErr) {
+		return false
+	}
+
+	context.Header("Retry-After", strconv.Itoa(throttleErr.RetryAfterSeconds()))
+	helper.ResponseWriter(context, http.StatusTooManyRequests, throttleErr.Error())
+	return true
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartOIDCLogin godoc
+// @Summary      Start Login with an OIDC Provider
+// @Description  Starts a "Sign in with ..." login (authorization code flow with PKCE). Returns the provider URL the user has to be redirected to; with redirect=true the response is the redirect itself. The guest cart of the X-Cart-Token header (or of the cart_token parameter, for a browser redirect) is merged into the cart of the user once logged in.
+// @Tags         Authentication
+// @Produce      json
+// @Param        provider      path      string                      true   "Provider name (e.g. google)"
+// @Param        redirect      query     bool                        false  "Reply with a 302 redirect instead of JSON"
+// @Param        cart_token    query     string                      false  "Token of the guest cart, if not sent in the header"
+// @Param        X-Cart-Token  header    string                      false  "Token of the guest cart"
+// @Success      200           {object}  models.OIDCLoginResponse    "Provider authorization URL"
+// @Failure      400           {object}  models.BadRequestError      "Unknown or unreachable provider"
+// @Failure      500           {object}  models.InternalServerError  "Internal server error"
+// @Router       /auth/oidc/{provider}/login [get]
+func (handler *Handler) StartOIDCLogin(context *gin.Context) {
+	cartToken := context.GetHeader(constants.CART_TOKEN_HEADER)
+	if cartToken == "" {
+		cartToken = context.Query("cart_token")
+	}
+
+	data, err := handler.service.StartOIDCLogin(context.Param("provider"), cartToken)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if context.Query("redirect") == "true" {
+		context.Redirect(http.StatusFound, data.AuthorizationURL)
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
+
+// CompleteOIDCLogin godoc
+// @Summary      Complete Login with an OIDC Provider
+// @Description  Exchanges the code and state returned by the provider for the same tokens as the email/password login. First-time users are created as verified customers, or linked to the account with the same verified email.
+// @Tags         Authentication
+// @Produce      json
+// @Param        provider  path      string                                 true  "Provider name (e.g. google)"
+// @Param        code      query     string                                 true  "Authorization code"
+// @Param        state     query     string                                 true  "State of the login"
+// @Success      200       {object}  models.SuccessResponse[LoginResponse] "Authenticated successfully with JWT token"
+// @Failure      400       {object}  models.BadRequestError                 "Missing code or state"
+// @Failure      401       {object}  models.UnauthorizedError               "Invalid state, code or ID token"
+// @Failure      500       {object}  models.InternalServerError             "Internal server error"
+// @Router       /auth/oidc/{provider}/callback [get]
+func (handler *Handler) CompleteOIDCLogin(context *gin.Context) {
+	if errorCode := context.Query("error"); errorCode != "" {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "The login was cancelled or refused by the provider: "+errorCode)
+		return
+	}
+
+	code := context.Query("code")
+	state := context.Query("state")
+	if code == "" || state == "" {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the code and the state.")
+		return
+	}
+
+	data, err := handler.service.CompleteOIDCLogin(context.Param("provider"), code, state, helper.GetClientInfo(context))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportPersonalData godoc
+// @Summary      Download My Personal Data
+// @Description  Returns everything the application holds about the logged in user (profile, linked identities, sessions, addresses, ...) as a JSON file or as a ZIP archive with one JSON file per section.
+// @Tags         Profile
+// @Produce      json
+// @Produce      application/zip
+// @Param        format  query     string                     false  "json (default) or zip"
+// @Success      200     {object}  models.PersonalDataExport  "Personal data"
+// @Failure      400     {object}  models.BadRequestError     "Unknown format"
+// @Failure      401     {object}  models.UnauthorizedError   "Unauthorized access attempt"
+// @Failure      500     {object}  models.InternalServerError "Internal server error"
+// @Router       /user/me/personal-data [get]
+func (handler *Handler) ExportPersonalData(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	format, ok := personalDataFormat(context)
+	if !ok {
+		return
+	}
+
+	export, err := handler.service.ExportPersonalData(user.UserID)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writePersonalData(context, format, export)
+}
+
+// ExportPersonalDataForAdmin godoc
+// @Summary      Download the Personal Data of a User
+// @Description  Returns everything the application holds about a user, deleted users included, as a JSON file or as a ZIP archive.
+// @Tags         User Administration
+// @Produce      json
+// @Produce      application/zip
+// @Param        id      path      string                     true   "User ID"
+// @Param        format  query     string                     false  "json (default) or zip"
+// @Success      200     {object}  models.PersonalDataExport  "Personal data"
+// @Failure      400     {object}  models.BadRequestError     "User not found or unknown format"
+// @Failure      403     {object}  models.ForbiddenError      "Missing user.admin permission"
+// @Router       /admin/users/{id}/personal-data [get]
+func (handler *Handler) ExportPersonalDataForAdmin(context *gin.Context) {
+	format, ok := personalDataFormat(context)
+	if !ok {
+		return
+	}
+
+	export, err := handler.service.ExportPersonalDataForAdmin(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writePersonalData(context, format, export)
+}
+
+// AnonymiseUser godoc
+// @Summary      Erase the Personal Data of a User
+// @Description  Anonymises the personal data of a user in every module (right to erasure) and logs out every device of the user. The rows are kept so the orders and other records stay valid. This can not be undone.
+// @Tags         User Administration
+// @Produce      json
+// @Param        id   path      string                          true  "User ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Personal data erased"
+// @Failure      400  {object}  models.BadRequestError          "User not found or last admin"
+// @Failure      403  {object}  models.ForbiddenError           "Missing user.admin permission"
+// @Router       /admin/users/{id}/anonymise [post]
+func (handler *Handler) AnonymiseUser(context *gin.Context) {
+	message, err := handler.service.AnonymiseUser(context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// personalDataFormat returns the requested export format, answering 400 if it is unknown.
+func personalDataFormat(context *gin.Context) (string, bool) {
+	format := context.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Unknown format, expects json or zip.")
+		return "", false
+	}
+	return format, true
+}
+
+// writePersonalData sends the export as a file to download.
+func writePersonalData(context *gin.Context, format string, export models.PersonalDataExport) {
+	fileName := fmt.Sprintf("personal-data-%s.%s", export.UserID, format)
+	context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	if format == "json" {
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+			return
+		}
+		context.Data(http.StatusOK, "application/json", data)
+		return
+	}
+
+	context.Header("Content-Type", "application/zip")
+	context.Status(http.StatusOK)
+
+	if err := services.WritePersonalDataZip(context.Writer, export); err != nil {
+		fmt.Printf("failed to write the personal data of %s: %v\n", export.UserID, err)
+	}
+}
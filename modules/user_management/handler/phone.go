@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResendPhoneVerificationCode godoc
+// @Summary      Send Phone Verification Code
+// @Description  Sends an OTP by SMS to the phone number of the logged in user.
+// @Tags         Profile
+// @Produce      json
+// @Success      200  {object}  models.SuccessResponse[string]  "OTP sent"
+// @Failure      400  {object}  models.BadRequestError          "No phone number or already verified"
+// @Failure      401  {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429  {object}  models.BadRequestError          "Requested again before the resend cooldown expired (see Retry-After)"
+// @Failure      500  {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me/phone/resend-verification [post]
+func (handler *Handler) ResendPhoneVerificationCode(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	message, err := handler.service.ResendPhoneVerificationCode(user)
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// VerifyPhone godoc
+// @Summary      Verify Phone with OTP
+// @Description  Verifies the phone number of the logged in user with the OTP sent by SMS.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.PhoneOTPRequest          true  "OTP"
+// @Success      200      {object}  models.SuccessResponse[string]  "Phone verified"
+// @Failure      400      {object}  models.BadRequestError          "Missing, invalid or expired OTP"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError          "Too many failed attempts from the client IP (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me/phone/verification [post]
+func (handler *Handler) VerifyPhone(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.PhoneOTPRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide OTP for verification.")
+		return
+	}
+
+	message, err := handler.service.VerifyPhone(user, request.OTP, context.ClientIP())
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
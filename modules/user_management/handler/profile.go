@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProfile godoc
+// @Summary      Get My Profile
+// @Description  Returns the profile of the logged in user, without having to know the user id.
+// @Tags         Profile
+// @Produce      json
+// @Success      200  {object}  models.SuccessResponse[UserResponse] "Profile of the logged in user"
+// @Failure      401  {object}  models.UnauthorizedError             "Unauthorized access attempt"
+// @Router       /user/me [get]
+func (handler *Handler) GetProfile(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, handler.service.GetProfile(user))
+}
+
+// UpdateProfile godoc
+// @Summary      Update My Profile
+// @Description  Replaces the names and the phone number of the logged in user. The email and the role can not be changed here.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.UpdateProfileRequest     true  "Profile"
+// @Success      200      {object}  models.SuccessResponse[string]  "Profile updated"
+// @Failure      400      {object}  models.BadRequestError          "Invalid request data"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me [put]
+func (handler *Handler) UpdateProfile(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.UpdateProfileRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid user request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide a valid first name, last name and phone.")
+		return
+	}
+
+	message, err := handler.service.UpdateProfile(user, request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// PatchProfile godoc
+// @Summary      Partially Update My Profile
+// @Description  Updates the given fields (names, phone number) of the logged in user.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.PatchProfileRequest      true  "Fields to update"
+// @Success      200      {object}  models.SuccessResponse[string]  "Profile updated"
+// @Failure      400      {object}  models.BadRequestError          "Invalid request data"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me [patch]
+func (handler *Handler) PatchProfile(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.PatchProfileRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid user request data.")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide a valid first name, last name or phone.")
+		return
+	}
+
+	message, err := handler.service.PatchProfile(user, request)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// ChangePassword godoc
+// @Summary      Change My Password
+// @Description  Changes the password of the logged in user. The current password is required; the other devices are logged out.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ChangePasswordRequest    true  "Current and new password"
+// @Success      200      {object}  models.SuccessResponse[string]  "Password changed"
+// @Failure      400      {object}  models.BadRequestError          "Wrong current password or new password rejected by the policy"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError          "Too many failed attempts (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me/change-password [post]
+func (handler *Handler) ChangePassword(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	tokenData, ok := helper.GetAccessTokenData(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.ChangePasswordRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the current and the new password.")
+		return
+	}
+
+	message, err := handler.service.ChangePassword(user, tokenData, request, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// DeleteProfile godoc
+// @Summary      Delete My Account
+// @Description  Schedules the deletion of the account of the logged in user and logs out every device. Logging in again within the grace period cancels the deletion.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.DeleteAccountRequest     true  "Password (or OTP for users without a password) confirming the deletion"
+// @Success      200      {object}  models.SuccessResponse[string]  "Deletion scheduled"
+// @Failure      400      {object}  models.BadRequestError          "Wrong password or OTP, or last admin"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError          "Too many failed attempts (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me [delete]
+func (handler *Handler) DeleteProfile(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.DeleteAccountRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Invalid request data.")
+		return
+	}
+
+	message, err := handler.service.RequestAccountDeletion(user, request, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// SendReauthenticationCode godoc
+// @Summary      Send Re-authentication Code
+// @Description  Emails an OTP to the logged in user, to confirm the deletion of the account or a change of the email when the user has no password (signs in only with an external provider).
+// @Tags         Profile
+// @Produce      json
+// @Success      200  {object}  models.SuccessResponse[string]  "OTP sent"
+// @Failure      400  {object}  models.BadRequestError          "The user has a password"
+// @Failure      401  {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429  {object}  models.BadRequestError          "Requested again before the resend cooldown expired (see Retry-After)"
+// @Failure      500  {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me/reauthentication [post]
+func (handler *Handler) SendReauthenticationCode(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	message, err := handler.service.SendReauthenticationCode(user)
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
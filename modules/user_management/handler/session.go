@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions godoc
+// @Summary      List Active Sessions
+// @Description  Lists the devices the logged in user is logged in on, with the user agent, IP address and last seen time. The session of the current request is flagged as current.
+// @Tags         Sessions
+// @Produce      json
+// @Success      200  {object}  models.SuccessResponse[[]Session] "Active sessions"
+// @Failure      401  {object}  models.UnauthorizedError          "Unauthorized access attempt"
+// @Failure      500  {object}  models.InternalServerError        "Internal server error"
+// @Router       /user/me/sessions [get]
+func (handler *Handler) ListSessions(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	tokenData, ok := helper.GetAccessTokenData(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	data, err := handler.service.ListSessions(user, tokenData)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
+
+// RevokeSession godoc
+// @Summary      Revoke a Session
+// @Description  Logs out a single device of the logged in user. Its refresh tokens are revoked and its access tokens stop working immediately.
+// @Tags         Sessions
+// @Produce      json
+// @Param        id   path      string                          true  "Session ID"
+// @Success      200  {object}  models.SuccessResponse[string]  "Session revoked"
+// @Failure      401  {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      404  {object}  models.BadRequestError          "No active session with this id"
+// @Failure      500  {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/me/sessions/{id} [delete]
+func (handler *Handler) RevokeSession(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	tokenData, ok := helper.GetAccessTokenData(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	message, err := handler.service.RevokeSession(user, tokenData, context.Param("id"))
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusNotFound, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginTwoFactor godoc
+// @Summary      Complete Login with 2FA
+// @Description  Exchanges the challenge token returned by the login of a user with 2FA enabled, together with a TOTP code or a recovery code, for the access and refresh tokens.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body     models.TwoFactorLoginRequest            true  "Challenge token and code"
+// @Success      200      {object} models.SuccessResponse[LoginResponse] "Authenticated successfully with JWT token"
+// @Failure      400      {object} models.BadRequestError                 "Invalid or malformed request body"
+// @Failure      401      {object} models.UnauthorizedError               "Invalid or expired challenge, or wrong code"
+// @Failure      429      {object} models.BadRequestError                 "Too many failed attempts from the client IP (see Retry-After)"
+// @Failure      500      {object} models.InternalServerError             "Unexpected server error"
+// @Router       /login/2fa [post]
+func (handler *Handler) LoginTwoFactor(context *gin.Context) {
+	var request models.TwoFactorLoginRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Bad request data...!")
+		return
+	}
+
+	if err := validator.ValidateStruct(request); err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the challenge token and the code.")
+		return
+	}
+
+	data, err := handler.service.LoginTwoFactor(request, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
+
+// EnrollTwoFactor godoc
+// @Summary      Enrol 2FA
+// @Description  Generates a new TOTP secret for the logged in user and returns it with the otpauth:// URI to scan in an authenticator app. 2FA is enabled only after confirming a first code.
+// @Tags         Two-Factor Authentication
+// @Produce      json
+// @Success      200  {object}  models.TwoFactorEnrollResponse "Secret and provisioning URI"
+// @Failure      400  {object}  models.BadRequestError         "2FA already enabled"
+// @Failure      401  {object}  models.UnauthorizedError       "Unauthorized access attempt"
+// @Failure      500  {object}  models.InternalServerError     "Internal server error"
+// @Router       /user/2fa/enroll [post]
+func (handler *Handler) EnrollTwoFactor(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	data, err := handler.service.EnrollTwoFactor(user)
+	if err != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary      Confirm 2FA
+// @Description  Enables 2FA with a first code from the authenticator app. Returns the single-use recovery codes, which are shown only once.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.TwoFactorCodeRequest    true  "TOTP code"
+// @Success      200      {object}  models.RecoveryCodesResponse   "2FA enabled, recovery codes"
+// @Failure      400      {object}  models.BadRequestError         "No pending enrolment or wrong code"
+// @Failure      401      {object}  models.UnauthorizedError       "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError         "Too many failed attempts, the account is locked (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError     "Internal server error"
+// @Router       /user/2fa/confirm [post]
+func (handler *Handler) ConfirmTwoFactor(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.TwoFactorCodeRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the code.")
+		return
+	}
+
+	data, err := handler.service.ConfirmTwoFactor(user, request.Code, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable 2FA
+// @Description  Disables 2FA for the logged in user. Requires the password and a current TOTP code or an unused recovery code.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.TwoFactorDisableRequest  true  "Password and code"
+// @Success      200      {object}  models.SuccessResponse[string]  "2FA disabled"
+// @Failure      400      {object}  models.BadRequestError          "Wrong password or code"
+// @Failure      401      {object}  models.UnauthorizedError        "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError          "Too many failed attempts, the account is locked (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError      "Internal server error"
+// @Router       /user/2fa/disable [post]
+func (handler *Handler) DisableTwoFactor(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.TwoFactorDisableRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the password and the code.")
+		return
+	}
+
+	message, err := handler.service.DisableTwoFactor(user, request, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, message)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate 2FA Recovery Codes
+// @Description  Replaces the recovery codes of the logged in user; the previous codes stop working. Requires a current TOTP code or an unused recovery code.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.TwoFactorCodeRequest    true  "TOTP or recovery code"
+// @Success      200      {object}  models.RecoveryCodesResponse   "New recovery codes"
+// @Failure      400      {object}  models.BadRequestError         "2FA not enabled or wrong code"
+// @Failure      401      {object}  models.UnauthorizedError       "Unauthorized access attempt"
+// @Failure      429      {object}  models.BadRequestError         "Too many failed attempts, the account is locked (see Retry-After)"
+// @Failure      500      {object}  models.InternalServerError     "Internal server error"
+// @Router       /user/2fa/recovery-codes [post]
+func (handler *Handler) RegenerateRecoveryCodes(context *gin.Context) {
+	user, ok := helper.GetLoggedInUser(context)
+	if !ok {
+		helper.ResponseWriter(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.TwoFactorCodeRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil || validator.ValidateStruct(request) != nil {
+		helper.ResponseWriter(context, http.StatusBadRequest, "Please provide the code.")
+		return
+	}
+
+	data, err := handler.service.RegenerateRecoveryCodes(user, request.Code, helper.GetClientInfo(context))
+	if err != nil {
+		if writeThrottleError(context, err) {
+			return
+		}
+		helper.ResponseWriter(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.ResponseWriter(context, http.StatusOK, data)
+}
@@ -3,6 +3,7 @@ package route
 import (
 	"e-commerce/middleware/auth"
 	"e-commerce/modules/user_management/handler"
+	"e-commerce/utils/constants"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,24 +12,101 @@ func UserManagementRoutes(router *gin.Engine) {
 	handler := handler.NewUserHandler()
 
 	router.POST(auth.PublicRoute("/login"), handler.Login)
+
+	router.POST(auth.PublicRoute("/login/2fa"), handler.LoginTwoFactor)
+
+	router.GET(auth.PublicRoute("/auth/oidc/:provider/login"), handler.StartOIDCLogin)
+
+	router.GET(auth.PublicRoute("/auth/oidc/:provider/callback"), handler.CompleteOIDCLogin)
+
+	router.POST(auth.PublicRoute("/token/refresh"), handler.RefreshToken)
+
+	router.POST("/logout", handler.Logout)
+
+	router.POST("/logout-all", handler.LogoutAll)
+
 	{
 		user := router.Group("/user")
 
-		user.POST(auth.PublicRoute("/register"), handler.AddUser)
+		user.POST(auth.PublicGroupRoute(user, "/register"), handler.AddUser)
+
+		user.POST(auth.PublicGroupRoute(user, "/verification"), handler.VerifyEmail)
+
+		user.POST(auth.PublicGroupRoute(user, "/resend-verification"), handler.ResendVerificationCode)
+
+		user.POST(auth.PublicGroupRoute(user, "/email-change/confirm"), handler.ConfirmEmailChange)
+
+		user.POST(auth.PublicGroupRoute(user, "/set-password"), handler.SetPassword)
+
+		user.POST(auth.PublicGroupRoute(user, "/forgot-password"), handler.ForgotPassword)
+
+		user.POST(auth.PublicGroupRoute(user, "/reset-password"), handler.ResetPassword)
+
+		user.POST("/2fa/enroll", handler.EnrollTwoFactor)
+
+		user.POST("/2fa/confirm", handler.ConfirmTwoFactor)
+
+		user.POST("/2fa/disable", handler.DisableTwoFactor)
+
+		user.POST("/2fa/recovery-codes", handler.RegenerateRecoveryCodes)
+
+		user.GET("/me", handler.GetProfile)
+
+		user.PUT("/me", handler.UpdateProfile)
+
+		user.PATCH("/me", handler.PatchProfile)
+
+		user.DELETE("/me", handler.DeleteProfile)
+
+		user.POST("/me/reauthentication", handler.SendReauthenticationCode)
+
+		user.POST("/me/change-password", handler.ChangePassword)
+
+		user.POST("/me/email", handler.RequestEmailChange)
+
+		user.POST("/me/phone/resend-verification", handler.ResendPhoneVerificationCode)
+
+		user.POST("/me/phone/verification", handler.VerifyPhone)
+
+		user.GET("/me/personal-data", handler.ExportPersonalData)
+
+		user.GET("/me/sessions", handler.ListSessions)
+
+		user.DELETE("/me/sessions/:id", handler.RevokeSession)
+
+		user.POST("", auth.RequirePermission(constants.PERMISSION_USER_MANAGE), handler.CreateUser)
+
+		user.GET("", auth.RequirePermission(constants.PERMISSION_USER_READ), handler.GetUsers)
+
+		user.POST("/import", auth.RequirePermission(constants.PERMISSION_USER_MANAGE), handler.ImportUsers)
+
+		user.GET("/export", auth.RequirePermission(constants.PERMISSION_USER_READ), handler.ExportUsers)
+
+		user.GET("/:id", auth.RequireSelfOrPermission("id", constants.PERMISSION_USER_READ), handler.GetUserByID)
+
+		user.PUT("/:id", auth.RequireSelfOrPermission("id", constants.PERMISSION_USER_MANAGE), handler.UpdateUser)
+
+		user.PATCH("/:id", auth.RequireSelfOrPermission("id", constants.PERMISSION_USER_MANAGE), handler.PartialUpdateUser)
+
+		user.DELETE("/:id", auth.RequirePermission(constants.PERMISSION_USER_MANAGE), handler.DeleteUser)
+	}
+
+	{
+		admin := router.Group("/admin/users", auth.RequirePermission(constants.PERMISSION_USER_ADMIN))
 
-		user.POST(auth.PublicRoute("/verification"), handler.VerifyEmail)
+		admin.GET("", handler.GetUsersForAdmin)
 
-		user.POST(auth.PublicRoute("/resend-verification"), handler.ResendVerificationCode)
+		admin.POST("/:id/restore", handler.RestoreUser)
 
-		user.GET("", handler.GetUsers)
+		admin.POST("/:id/verify", handler.VerifyUser)
 
-		user.GET("/:id", handler.GetUserByID)
+		admin.POST("/:id/force-password-reset", handler.ForcePasswordReset)
 
-		user.PUT("/:id", handler.UpdateUser)
+		admin.GET("/:id/personal-data", handler.ExportPersonalDataForAdmin)
 
-		user.PATCH("/:id", handler.PartialUpdateUser)
+		admin.POST("/:id/anonymise", handler.AnonymiseUser)
 
-		user.DELETE("/:id", handler.DeleteUser)
+		admin.DELETE("/:id", handler.HardDeleteUser)
 	}
 
 }
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// GetUsersForAdmin retrieves one page of the users matching the query parameters, whatever their status.
+// Unlike GetUsers, unverified users are listed unless is_verified is given,
+// and is_deleted=true lists the soft-deleted users instead of the active ones.
+//
+// Parameters:
+//
+//	queryParams (*models.UserQueryParams): The query parameters for filtering, sorting and paginating users.
+//
+// Returns:
+//
+//	[]models.AdminUserResponse: A slice of users with their account status.
+//	models.Pagination: The page, the limit, the total number of users and the cursor of the next page.
+//	error: An error if the sort or the cursor is invalid, no data is found or if any operation fails.
+func (service *Service) GetUsersForAdmin(queryParams *models.UserQueryParams) ([]models.AdminUserResponse, models.Pagination, error) {
+	filter := service.repo.GetFilter()
+
+	if queryParams.IsDeleted != nil && *queryParams.IsDeleted {
+		filter = service.repo.GetUnscopedFilter().Where("users.deleted_at IS NOT NULL")
+	}
+
+	users, pagination, err := service.findUsers(filter, queryParams)
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
+
+	return models.UserList(users).AdminResponseList(), pagination, nil
+}
+
+// RestoreUser brings back a soft-deleted user. A pending deletion request of the user is cancelled too.
+//
+// Parameters:
+//
+//	id (string): The user's UUID in string format.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the user is not found or is not deleted.
+func (service *Service) RestoreUser(id string) (string, error) {
+	user, err := service.getUserForAdmin(id)
+	if err != nil {
+		return "", err
+	}
+
+	if !user.DeletedAt.Valid {
+		return "", fmt.Errorf("the user with id = %s is not deleted", user.UserID)
+	}
+
+	restored, err := service.repo.Restore(user.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if !restored {
+		return "", fmt.Errorf("the user with id = %s is not deleted", user.UserID)
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	return "User restored successfully.", nil
+}
+
+// HardDeleteUser permanently erases a user, deleted or not, with the records owned by the user
+// (password, 2FA settings, linked identities and addresses). All the sessions of the user are logged out.
+//
+// Parameters:
+//
+//	id (string): The user's UUID in string format.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the user is not found or is the last admin.
+func (service *Service) HardDeleteUser(id string) (string, error) {
+	user, err := service.getUserForAdmin(id)
+	if err != nil {
+		return "", err
+	}
+
+	// soft-deleted users are no longer counted as admins and pass the guard
+	err = service.removeAdmin(user, func(tx *gorm.DB) error {
+		return service.repo.WithTx(tx).HardDelete(user)
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		fmt.Printf("failed to revoke the tokens of %s: %v\n", user.UserID, err)
+	}
+
+	return "User permanently deleted.", nil
+}
+
+// VerifyUser marks the email of a pending user as verified without the OTP,
+// e.g. when the user could not receive the verification email.
+//
+// Parameters:
+//
+//	id (string): The user's UUID in string format.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the user is not found, is deleted or is already verified.
+func (service *Service) VerifyUser(id string) (string, error) {
+	user, err := service.getUserForAdmin(id)
+	if err != nil {
+		return "", err
+	}
+
+	if user.DeletedAt.Valid {
+		return "", fmt.Errorf("the user with id = %s is deleted, restore the user first", user.UserID)
+	}
+
+	if user.IsVerified {
+		return "", fmt.Errorf("the user with id = %s is already verified", user.UserID)
+	}
+
+	record := map[string]any{
+		"is_verified": true,
+	}
+
+	if err := service.repo.UpdateSpecificRecord(record, "users.user_id = ? AND users.is_verified = false", user.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if err := helper.DeleteCache(user.Email, constants.VERIFICATION_OTP_ATTEMPTS_PREFIX+user.Email); err != nil {
+		fmt.Printf("failed to delete the verification OTP of %s: %v\n", user.Email, err)
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	return "User verified successfully.", nil
+}
+
+// ForcePasswordReset clears the password of a verified user, logs out all the sessions of the user
+// and emails a link to set a new password, e.g. when the account may be compromised.
+//
+// Parameters:
+//
+//	id (string): The user's UUID in string format.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the user is not found, is deleted or is not verified.
+func (service *Service) ForcePasswordReset(id string) (string, error) {
+	user, err := service.getUserForAdmin(id)
+	if err != nil {
+		return "", err
+	}
+
+	if user.DeletedAt.Valid {
+		return "", fmt.Errorf("the user with id = %s is deleted, restore the user first", user.UserID)
+	}
+
+	if !user.IsVerified {
+		return "", fmt.Errorf("the user with id = %s is not verified yet", user.UserID)
+	}
+
+	if err := service.repo.UpdatePassword(user.UserID, ""); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	// a reset OTP requested before must not set a password either
+	if err := helper.DeleteCache(constants.FORGOT_PASSWORD_OTP_PREFIX+user.Email, constants.FORGOT_PASSWORD_ATTEMPTS_PREFIX+user.Email); err != nil {
+		fmt.Printf("failed to delete the reset OTP of %s: %v\n", user.Email, err)
+	}
+
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		return "", err
+	}
+
+	if err := service.sendSetPasswordLink(user); err != nil {
+		return "", err
+	}
+
+	return "The password of the user has been cleared and a link to set a new password has been sent to the Email Address.", nil
+}
+
+// getUserForAdmin loads the user with the given id, soft-deleted users included.
+func (service *Service) getUserForAdmin(id string) (*models.User, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id format, expects uuid")
+	}
+
+	user, err := service.repo.GetUnscopedByCondition("users.user_id = ?", parsedUUID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("no user found with id = %s", parsedUUID)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"e-commerce/middleware/validator"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// importChunkSize is the number of rows created together in one transaction.
+const importChunkSize = 100
+
+// importMaxRows is the number of rows an imported CSV file can have at most.
+const importMaxRows = 5000
+
+// importMaxErrors is the number of rejected rows reported with their error, the others are only counted.
+const importMaxErrors = 100
+
+// exportBatchSize is the number of users loaded at once while exporting.
+const exportBatchSize = 500
+
+// importColumns are the columns an imported CSV file must have (in any order).
+var importColumns = []string{"first_name", "last_name", "email", "phone"}
+
+// exportColumns are the columns of the exported CSV files.
+var exportColumns = []string{"user_id", "first_name", "last_name", "email", "phone", "role_id", "is_phone_verified"}
+
+// importRow is a validated row of an imported CSV file waiting for its chunk to be created.
+type importRow struct {
+	line int
+	user models.User
+}
+
+// ImportUsers registers the customers listed in a CSV file, like CreateUser does for a single user.
+// The file is read row by row and every row is validated against models.CreateUserRequest;
+// once the whole file is read, the valid rows are created in transactional chunks and
+// the imported users are emailed a link to set their password, no password is ever part of the file.
+// A row that can not be imported does not stop the import, it is reported with its line.
+//
+// Parameters:
+//
+//	reader (io.Reader): The CSV file; the first row names the columns first_name, last_name, email and phone.
+//
+// Returns:
+//
+//	models.UserImportResult: The number of imported rows and the errors of the first rejected rows.
+//	error: An error if the header is invalid, the file has more than importMaxRows rows or can not be read;
+//	       nothing is imported then.
+func (service *Service) ImportUsers(reader io.Reader) (models.UserImportResult, error) {
+	result := models.UserImportResult{Errors: []models.UserImportRowError{}}
+
+	role, err := service.repo.GetRoleByCode(constants.ROLE_CUSTOMER)
+	if err != nil {
+		return result, err
+	}
+
+	if role == nil {
+		return result, fmt.Errorf("import is not available, the customer role is not configured")
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return result, fmt.Errorf("the file is not a valid CSV file with a header row")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return result, fmt.Errorf("the column %q is missing, the header must contain: %s", name, strings.Join(importColumns, ", "))
+		}
+	}
+
+	rows := []importRow{}
+	emails := map[string]struct{}{}
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return result, err
+			}
+			result.Total++
+			rejectImportRow(&result, parseErr.Line, "", "the row is not valid CSV")
+			continue
+		}
+
+		result.Total++
+		if result.Total > importMaxRows {
+			return models.UserImportResult{}, fmt.Errorf("the file has more than %d rows, please split it", importMaxRows)
+		}
+
+		line, _ := csvReader.FieldPos(0)
+
+		field := func(name string) string {
+			if index := columns[name]; index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+
+		request := models.CreateUserRequest{
+			FirstName: field("first_name"),
+			LastName:  field("last_name"),
+			Email:     strings.ToLower(field("email")),
+			Phone:     field("phone"),
+			RoleID:    role.RoleID,
+		}
+
+		user, err := importUser(request)
+		if err != nil {
+			rejectImportRow(&result, line, request.Email, err.Error())
+			continue
+		}
+
+		if _, ok := emails[user.Email]; ok {
+			rejectImportRow(&result, line, request.Email, "the email is given more than once in the file")
+			continue
+		}
+		emails[user.Email] = struct{}{}
+
+		rows = append(rows, importRow{line: line, user: user})
+	}
+
+	for start := 0; start < len(rows); start += importChunkSize {
+		service.createImportChunk(rows[start:min(start+importChunkSize, len(rows))], &result)
+	}
+
+	return result, nil
+}
+
+// ExportUsers writes the verified users matching the query parameters to writer, as CSV or as JSON lines.
+// The users are loaded and written in batches, so the export never holds all the users in memory;
+// writer is flushed after every batch if it supports it (e.g. an HTTP response).
+//
+// Parameters:
+//
+//	queryParams (*models.UserQueryParams): The filters of GetUsers; the pagination parameters are ignored.
+//	format (string): "csv" or "jsonl".
+//	writer (io.Writer): The destination of the export.
+//
+// Returns:
+//
+//	error: An error if the format is unknown, the users can not be loaded or writing fails.
+func (service *Service) ExportUsers(queryParams *models.UserQueryParams, format string, writer io.Writer) error {
+	isVerified := true
+	queryParams.IsVerified = &isVerified
+
+	filter := helper.BuildQuery(service.repo.GetFilter(), queryParams)
+
+	flush := func() {
+		if flusher, ok := writer.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+	}
+
+	switch format {
+	case "csv":
+		csvWriter := csv.NewWriter(writer)
+		if err := csvWriter.Write(exportColumns); err != nil {
+			return err
+		}
+
+		return service.repo.FindInBatches(filter, exportBatchSize, func(users []models.User) error {
+			for _, user := range users {
+				record := []string{
+					user.UserID.String(),
+					user.FirstName,
+					user.LastName,
+					user.Email,
+					user.Phone,
+					user.RoleID.String(),
+					strconv.FormatBool(user.IsPhoneVerified),
+				}
+				if err := csvWriter.Write(record); err != nil {
+					return err
+				}
+			}
+
+			csvWriter.Flush()
+			flush()
+
+			return csvWriter.Error()
+		})
+	case "jsonl":
+		encoder := json.NewEncoder(writer)
+
+		return service.repo.FindInBatches(filter, exportBatchSize, func(users []models.User) error {
+			for _, user := range users {
+				if err := encoder.Encode(user.ResponseObj()); err != nil {
+					return err
+				}
+			}
+
+			flush()
+
+			return nil
+		})
+	default:
+		return fmt.Errorf("unknown export format %q, expects csv or jsonl", format)
+	}
+}
+
+// importUser validates an imported row and builds the user to create, without a password:
+// the user sets it with the link emailed once the user is created.
+func importUser(request models.CreateUserRequest) (models.User, error) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return models.User{}, fmt.Errorf("%s", validator.ErrorMessage(err))
+	}
+
+	phone, err := helper.NormalizePhone(request.Phone)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		FirstName:    request.FirstName,
+		LastName:     request.LastName,
+		Email:        request.Email,
+		Phone:        phone,
+		RoleID:       request.RoleID,
+		UserPassword: models.UserPassword{},
+	}
+
+	return user, nil
+}
+
+// rejectImportRow counts a rejected row; only the first importMaxErrors rejected rows are reported with their error.
+func rejectImportRow(result *models.UserImportResult, line int, email string, message string) {
+	result.Failed++
+	if len(result.Errors) < importMaxErrors {
+		result.Errors = append(result.Errors, models.UserImportRowError{Line: line, Email: email, Error: message})
+	}
+}
+
+// createImportChunk creates the users of a chunk in one transaction and queues their set-password links.
+// The rows whose email is already registered are rejected; if the transaction fails, every row of the chunk is.
+func (service *Service) createImportChunk(chunk []importRow, result *models.UserImportResult) {
+	emails := make([]string, 0, len(chunk))
+	for _, row := range chunk {
+		emails = append(emails, row.user.Email)
+	}
+
+	rejectRows := func(rows []importRow, message string) {
+		for _, row := range rows {
+			rejectImportRow(result, row.line, row.user.Email, message)
+		}
+	}
+
+	registered, err := service.repo.FindRegisteredEmails(emails)
+	if err != nil {
+		rejectRows(chunk, err.Error())
+		return
+	}
+
+	users := make([]models.User, 0, len(chunk))
+	rows := make([]importRow, 0, len(chunk))
+
+	for _, row := range chunk {
+		if slices.Contains(registered, row.user.Email) {
+			rejectRows([]importRow{row}, "the email is already registered")
+			continue
+		}
+		users = append(users, row.user)
+		rows = append(rows, row)
+	}
+
+	if len(users) == 0 {
+		return
+	}
+
+	if err := service.repo.CreateMany(users); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			rejectRows(rows, pgErr.Detail)
+		} else {
+			rejectRows(rows, err.Error())
+		}
+		return
+	}
+
+	result.Created += len(users)
+
+	service.queueSetPasswordLinks(users)
+}
+
+// queueSetPasswordLinks emails the set-password links of the imported users one after the other
+// in the background, so a large import does not open a connection to the SMTP server per user.
+// The imported users are verified once they set their password with the link.
+func (service *Service) queueSetPasswordLinks(users []models.User) {
+	go func() {
+		isHtml := true
+
+		for _, user := range users {
+			subject, emailBody, err := setPasswordEmail(&user, isHtml)
+			if err != nil {
+				fmt.Printf("failed to issue the set-password link of %s: %v\n", user.Email, err)
+				continue
+			}
+
+			if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
+				fmt.Printf("failed to send email to %s: %v", user.Email, err)
+			}
+		}
+	}()
+}
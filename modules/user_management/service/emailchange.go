@@ -0,0 +1,198 @@
+package service
+
+import (
+	"crypto/subtle"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RequestEmailChange starts the change of the email of the logged in user.
+// The request is confirmed with the password, or with an OTP emailed to the current address
+// for the users signing in only with an external provider (see reauthenticate).
+// The email is not changed yet: an OTP is sent to the new address and the old address is notified,
+// the change is applied by ConfirmEmailChange.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	request (models.ChangeEmailRequest): The new email and the password or the OTP.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	string: A message asking to confirm the new address.
+//	error: An error if the password or the OTP is wrong or the email can not be used;
+//	       a *helper.ThrottleError if a code was requested too recently or the attempt
+//	       is refused by the brute force protection.
+func (service *Service) RequestEmailChange(user models.User, request models.ChangeEmailRequest, client models.ClientInfo) (string, error) {
+	if err := service.reauthenticate(user, request.Password, request.OTP, client); err != nil {
+		return "", err
+	}
+
+	if err := service.startEmailChange(user, request.Email); err != nil {
+		return "", err
+	}
+
+	return "We have sent an OTP to your new email address, your email will be changed once you confirm it.", nil
+}
+
+// ConfirmEmailChange applies a pending email change with the OTP sent to the new address.
+// All the sessions of the user are logged out, the user has to login with the new email.
+// The OTP is invalidated after too many wrong guesses, and the failures count
+// against the client IP like failed logins.
+//
+// Parameters:
+//
+//	request (models.EmailOTPRequest): The new email and the OTP sent to it.
+//	clientIP (string): The IP address of the client.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the OTP is invalid or expired;
+//	       a *helper.ThrottleError if the client IP is blocked.
+func (service *Service) ConfirmEmailChange(request models.EmailOTPRequest, clientIP string) (string, error) {
+	if err := helper.CheckClientIP(clientIP); err != nil {
+		return "", err
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(request.Email))
+	pendingKey := constants.EMAIL_CHANGE_PREFIX + newEmail
+	attemptsKey := constants.EMAIL_CHANGE_ATTEMPTS_PREFIX + newEmail
+
+	cached, err := helper.GetCache(pendingKey)
+	if err != nil {
+		helper.RecordIPFailure(clientIP)
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	pending, err := helper.JsonToStruct[models.PendingEmailChange](cached)
+	if err != nil {
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	otp := strings.ReplaceAll(request.OTP, " ", "")
+
+	if subtle.ConstantTimeCompare([]byte(pending.OTP), []byte(otp)) != 1 {
+		if helper.RecordOtpFailure(attemptsKey, pendingKey, clientIP) {
+			return "", fmt.Errorf("too many incorrect attempts. Please request a new OTP")
+		}
+		return "", fmt.Errorf("the OTP you entered is incorrect. Please check and try again")
+	}
+
+	condition := "users.user_id = ? AND users.is_verified = true"
+
+	user, err := service.repo.GetByCondition(condition, pending.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	// the email has been changed by other means in the meantime
+	if user == nil || user.Email != pending.OldEmail {
+		_ = helper.DeleteCache(pendingKey, attemptsKey)
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	record := map[string]any{"email": newEmail}
+
+	if err := service.repo.UpdateSpecificRecord(record, condition, user.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if err := helper.DeleteCache(pendingKey, attemptsKey, constants.EMAIL_CHANGE_USER_PREFIX+user.UserID.String()); err != nil {
+		fmt.Printf("failed to delete the pending email change of %s: %v", user.UserID, err)
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		return "", err
+	}
+
+	return "Your email has been changed successfully. Please login with your new email.", nil
+}
+
+// startEmailChange puts the change of the user's email to newEmail in the pending state:
+// an OTP is sent to the new address, and the old address is notified.
+// A new request replaces the previous pending change of the user.
+func (service *Service) startEmailChange(user models.User, newEmail string) error {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return fmt.Errorf("the new email is the same as the current email")
+	}
+
+	existingUser, err := service.repo.GetByCondition("LOWER(users.email) = ?", newEmail)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return fmt.Errorf(pgErr.Detail)
+		}
+		return err
+	}
+
+	if existingUser != nil {
+		return fmt.Errorf("the email (%s) is already in use", newEmail)
+	}
+
+	userKey := constants.EMAIL_CHANGE_USER_PREFIX + user.UserID.String()
+
+	if err := helper.StartOtpResendCooldown(constants.EMAIL_CHANGE_COOLDOWN_PREFIX + user.UserID.String()); err != nil {
+		return err
+	}
+
+	// only the latest requested address can be confirmed
+	if previousEmail, err := helper.GetCache(userKey); err == nil {
+		if err := helper.DeleteCache(constants.EMAIL_CHANGE_PREFIX+previousEmail, constants.EMAIL_CHANGE_ATTEMPTS_PREFIX+previousEmail); err != nil {
+			fmt.Printf("failed to delete the pending email change of %s: %v", user.UserID, err)
+		}
+	}
+
+	pending := models.PendingEmailChange{
+		UserID:   user.UserID,
+		OldEmail: user.Email,
+		OTP:      helper.GenerateSecureOTP(),
+	}
+
+	expiry := time.Duration(helper.OtpExpTime) * time.Minute
+
+	if _, err := helper.SetCache(constants.EMAIL_CHANGE_PREFIX+newEmail, helper.StructToJson(pending), expiry); err != nil {
+		return err
+	}
+
+	if _, err := helper.SetCache(userKey, newEmail, expiry); err != nil {
+		return err
+	}
+
+	if err := helper.DeleteCache(constants.EMAIL_CHANGE_ATTEMPTS_PREFIX + newEmail); err != nil {
+		fmt.Printf("failed to reset otp attempts for %s: %v", newEmail, err)
+	}
+
+	isHtml := true
+	otpSubject, otpBody := helper.GetEmailVerificationFormat(user.FullName(), pending.OTP, isHtml)
+	noticeSubject, noticeBody := helper.GetEmailChangeNoticeFormat(user.FullName(), newEmail, isHtml)
+
+	go func() {
+		if err := services.SmtpServer.SendEmail(newEmail, otpSubject, otpBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", newEmail, err)
+		}
+
+		if err := services.SmtpServer.SendEmail(user.Email, noticeSubject, noticeBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+
+	return nil
+}
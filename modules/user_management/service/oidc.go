@@ -0,0 +1,256 @@
+package service
+
+import (
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// how long the user has to complete the login at the provider
+const oidcStateExpiry = 10 * time.Minute
+
+// StartOIDCLogin starts a login with an OIDC provider (authorization code flow with PKCE).
+// The state, the nonce, the PKCE code verifier and the guest cart token are kept in Redis until the callback.
+//
+// Parameters:
+//
+//	providerName (string): The name of the configured provider.
+//	cartToken (string): The token of the guest cart to merge into the cart of the user once logged in, or empty.
+//
+// Returns:
+//
+//	models.OIDCLoginResponse: The provider URL the user has to be redirected to.
+//	error: An error if the provider is unknown or can not be reached.
+func (service *Service) StartOIDCLogin(providerName string, cartToken string) (models.OIDCLoginResponse, error) {
+	var response models.OIDCLoginResponse
+
+	provider, ok := services.GetOIDCProvider(providerName)
+	if !ok {
+		return response, fmt.Errorf("login with %s is not available", providerName)
+	}
+
+	state, err := helper.GenerateSecureToken()
+	if err != nil {
+		return response, err
+	}
+
+	nonce, err := helper.GenerateSecureToken()
+	if err != nil {
+		return response, err
+	}
+
+	codeVerifier, err := helper.GenerateSecureToken()
+	if err != nil {
+		return response, err
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(state, nonce, codeVerifier)
+	if err != nil {
+		return response, err
+	}
+
+	pending := models.OIDCState{
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		CartToken:    cartToken,
+	}
+
+	if _, err := helper.SetCache(constants.OIDC_STATE_PREFIX+state, helper.StructToJson(pending), oidcStateExpiry); err != nil {
+		return response, err
+	}
+
+	response.AuthorizationURL = authorizationURL
+	return response, nil
+}
+
+// CompleteOIDCLogin handles the provider callback: the code is exchanged, the ID token verified
+// and the external identity resolved to a user, which gets the same tokens as with Login.
+// An unknown identity is linked to the user with the same (provider verified) email,
+// or a new verified customer is created for it.
+//
+// Parameters:
+//
+//	providerName (string): The name of the provider in the callback URL.
+//	code (string): The authorization code returned by the provider.
+//	state (string): The state returned by the provider.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	any: A models.LoginResponse, or a models.TwoFactorChallengeResponse if the user has 2FA enabled.
+//	error: An error if the state, the code or the ID token is invalid.
+func (service *Service) CompleteOIDCLogin(providerName string, code string, state string, client models.ClientInfo) (any, error) {
+	// the state is single-use, a replayed callback finds nothing
+	cachedState, err := helper.PopCache(constants.OIDC_STATE_PREFIX + state)
+	if err != nil {
+		return nil, fmt.Errorf("the login session is invalid or has expired, please try again")
+	}
+
+	pending, err := helper.JsonToStruct[models.OIDCState](cachedState)
+	if err != nil || !strings.EqualFold(pending.Provider, providerName) {
+		return nil, fmt.Errorf("the login session is invalid or has expired, please try again")
+	}
+
+	provider, ok := services.GetOIDCProvider(pending.Provider)
+	if !ok {
+		return nil, fmt.Errorf("login with %s is not available", providerName)
+	}
+
+	rawIDToken, err := provider.Exchange(code, pending.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.VerifyIDToken(rawIDToken, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := service.resolveOIDCUser(provider.Name, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := service.completeLogin(*user, client, constants.LOGIN_METHOD_OIDC_PREFIX+provider.Name)
+	if err == nil {
+		service.loggedIn(response, user.UserID, pending.CartToken)
+	}
+
+	return response, err
+}
+
+// resolveOIDCUser returns the user linked to the external identity, linking or creating it when needed.
+func (service *Service) resolveOIDCUser(providerName string, claims *services.OIDCIDTokenClaims) (*models.User, error) {
+	identity, err := service.repo.GetIdentity(providerName, claims.Subject)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if identity != nil {
+		user, err := service.repo.GetByConditionWithRelations([]string{"Role"}, "users.user_id = ? AND users.is_verified = true", identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if user == nil {
+			return nil, fmt.Errorf("the account linked to this %s login is not available", providerName)
+		}
+
+		return user, nil
+	}
+
+	// without a verified email the identity can neither be linked nor create an account
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !claims.IsEmailVerified() {
+		return nil, fmt.Errorf("your %s account has no verified email address", providerName)
+	}
+
+	newIdentity := models.UserIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	}
+
+	user, err := service.repo.GetByConditionWithRelations([]string{"Role"}, "LOWER(users.email) = ?", email)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if user != nil {
+		if !user.IsVerified {
+			// the provider proved the ownership of the email; the password set during an
+			// unverified registration may come from someone else, so it is dropped
+			if err := service.repo.UpdatePassword(user.UserID, ""); err != nil {
+				return nil, err
+			}
+
+			record := map[string]any{"is_verified": true}
+			if err := service.repo.UpdateSpecificRecord(record, "users.user_id = ?", user.UserID); err != nil {
+				return nil, err
+			}
+			user.IsVerified = true
+			services.InvalidateUserCache(user.UserID)
+
+			if err := helper.DeleteCache(user.Email, constants.VERIFICATION_OTP_ATTEMPTS_PREFIX+user.Email); err != nil {
+				fmt.Printf("failed to delete the verification otp of %s: %v", user.Email, err)
+			}
+		}
+
+		newIdentity.UserID = user.UserID
+		if err := service.repo.CreateIdentity(&newIdentity); err != nil {
+			if pgErr, ok := err.(*pq.Error); ok {
+				return nil, fmt.Errorf(pgErr.Detail)
+			}
+			return nil, err
+		}
+
+		return user, nil
+	}
+
+	role, err := service.repo.GetRoleByCode(constants.ROLE_CUSTOMER)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == nil {
+		return nil, fmt.Errorf("registration is not available, the customer role is not configured")
+	}
+
+	firstName, lastName := oidcUserNames(claims, email)
+
+	newUser := models.User{
+		FirstName:  firstName,
+		LastName:   lastName,
+		Email:      email,
+		RoleID:     role.RoleID,
+		IsVerified: true,
+		// no password, it can be set later with the forgot-password flow
+		UserPassword: models.UserPassword{},
+	}
+
+	if err := service.repo.CreateWithIdentity(&newUser, &newIdentity); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	newUser.Role = *role
+	return &newUser, nil
+}
+
+// oidcUserNames picks the first and last name from the ID token claims,
+// falling back to the full name and then to the email address.
+func oidcUserNames(claims *services.OIDCIDTokenClaims, email string) (string, string) {
+	firstName := strings.TrimSpace(claims.GivenName)
+	lastName := strings.TrimSpace(claims.FamilyName)
+
+	if firstName == "" {
+		parts := strings.Fields(claims.Name)
+		if len(parts) > 0 {
+			firstName = parts[0]
+			if lastName == "" && len(parts) > 1 {
+				lastName = strings.Join(parts[1:], " ")
+			}
+		}
+	}
+
+	if firstName == "" {
+		firstName = strings.Split(email, "@")[0]
+	}
+
+	return firstName, lastName
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	configs "e-commerce/config"
+	"e-commerce/database/connections"
+	"e-commerce/database/migrations"
+	"e-commerce/services"
+	"e-commerce/services/oidctest"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+// testClient is the device the test logins come from.
+var testClient = models.ClientInfo{IP: "127.0.0.1", UserAgent: "go-test"}
+
+// integrationReady is set once the database and Redis of config.env are connected.
+var integrationReady bool
+
+// TestMain sets the application up the way main does. The tests of this package read and write
+// the Postgres database and the Redis of config.env, so they only run with INTEGRATION_TEST=1.
+func TestMain(m *testing.M) {
+	if os.Getenv("INTEGRATION_TEST") != "" {
+		if err := setupIntegration(); err != nil {
+			fmt.Fprintf(os.Stderr, "integration setup failed: %v\n", err)
+			os.Exit(1)
+		}
+		integrationReady = true
+	}
+
+	os.Exit(m.Run())
+}
+
+func setupIntegration() error {
+	// the config and the keys are read relative to the root of the repository
+	if err := os.Chdir("../../.."); err != nil {
+		return err
+	}
+
+	if err := godotenv.Load("config.env"); err != nil {
+		return err
+	}
+
+	configData, err := configs.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := connections.InitDB(&configData.DBConnection); err != nil {
+		return err
+	}
+
+	if err := connections.InitRedis(&configData.RedisConnection); err != nil {
+		return err
+	}
+
+	services.InitUserCache(configData.UserCacheTTLSec)
+	helper.InitiateHelper(*configData)
+
+	if err := helper.InitJwtKeys(configData.Jwt); err != nil {
+		return err
+	}
+
+	return migrations.RunMigrations()
+}
+
+func requireIntegration(t *testing.T) {
+	t.Helper()
+	if !integrationReady {
+		t.Skip("needs Postgres and Redis, run with INTEGRATION_TEST=1")
+	}
+}
+
+// newMockProvider starts a mock OIDC provider registered as "mock".
+func newMockProvider(t *testing.T) *oidctest.Provider {
+	t.Helper()
+
+	mock, err := oidctest.NewProvider("test-client")
+	if err != nil {
+		t.Fatalf("failed to start the mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	services.InitOIDCProviders([]models.OIDCProvider{{
+		Name:        "mock",
+		IssuerURL:   mock.Issuer(),
+		ClientID:    mock.ClientID,
+		RedirectURL: "http://localhost:8080/auth/oidc/mock/callback",
+	}})
+	t.Cleanup(func() { services.InitOIDCProviders(nil) })
+
+	return mock
+}
+
+// startLogin starts a login with the mock provider and has the user with the claims log in there.
+func startLogin(t *testing.T, service *Service, mock *oidctest.Provider, claims jwt.MapClaims) (string, string) {
+	t.Helper()
+
+	response, err := service.StartOIDCLogin("mock", "")
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+
+	code, state, err := mock.Authorize(response.AuthorizationURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	return code, state
+}
+
+// tamperState changes the pending login stored under the state.
+func tamperState(t *testing.T, state string, change func(*models.OIDCState)) {
+	t.Helper()
+
+	key := constants.OIDC_STATE_PREFIX + state
+	cached, err := helper.GetCache(key)
+	if err != nil {
+		t.Fatalf("the state is not cached: %v", err)
+	}
+
+	pending, err := helper.JsonToStruct[models.OIDCState](cached)
+	if err != nil {
+		t.Fatalf("invalid cached state: %v", err)
+	}
+
+	change(&pending)
+
+	if _, err := helper.SetCache(key, helper.StructToJson(pending), time.Minute); err != nil {
+		t.Fatalf("failed to cache the state: %v", err)
+	}
+}
+
+// createVerifiedUser creates a verified customer, deleted at the end of the test.
+func createVerifiedUser(t *testing.T, service *Service, email string) *models.User {
+	t.Helper()
+
+	role := customerRole(t, service)
+
+	user := models.User{
+		FirstName:    "Jane",
+		LastName:     "Doe",
+		Email:        email,
+		RoleID:       role.RoleID,
+		IsVerified:   true,
+		UserPassword: models.UserPassword{},
+	}
+	if err := service.repo.Create(&user); err != nil {
+		t.Fatalf("failed to create the user: %v", err)
+	}
+	deleteUserAfterTest(t, email)
+
+	return &user
+}
+
+// customerRole returns the customer role, loaded with GET /load-data.
+func customerRole(t *testing.T, service *Service) *models.Role {
+	t.Helper()
+
+	role, err := service.repo.GetRoleByCode(constants.ROLE_CUSTOMER)
+	if err != nil {
+		t.Fatalf("GetRoleByCode: %v", err)
+	}
+	if role == nil {
+		t.Skip("the customer role is not loaded, call GET /load-data first")
+	}
+	return role
+}
+
+// deleteUserAfterTest permanently deletes the user with the email and the records it owns, at the end of the test.
+func deleteUserAfterTest(t *testing.T, email string) {
+	t.Cleanup(func() {
+		db := connections.GetDB().Unscoped()
+		userIDs := db.Model(&models.User{}).Select("user_id").Where("users.email = ?", email)
+		db.Where("user_identities.user_id IN (?)", userIDs).Delete(&models.UserIdentity{})
+		db.Where("user_recovery_codes.user_id IN (?)", userIDs).Delete(&models.UserRecoveryCode{})
+		db.Where("user_two_factors.user_id IN (?)", userIDs).Delete(&models.UserTwoFactor{})
+		db.Where("login_events.user_id IN (?)", userIDs).Delete(&models.LoginEvent{})
+		db.Where("user_passwords.user_id IN (?)", userIDs).Delete(&models.UserPassword{})
+		db.Where("users.email = ?", email).Delete(&models.User{})
+	})
+}
+
+func loggedInUserID(t *testing.T, result any) uuid.UUID {
+	t.Helper()
+
+	response, ok := result.(models.LoginResponse)
+	if !ok {
+		t.Fatalf("expected a login response, got %T", result)
+	}
+	return response.UserDetails.UserID
+}
+
+func TestOIDCLoginChecksState(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+	mock := newMockProvider(t)
+
+	if _, err := service.CompleteOIDCLogin("mock", "a-code", "an-unknown-state", testClient); err == nil {
+		t.Fatal("a login with an unknown state was accepted")
+	}
+
+	code, state := startLogin(t, service, mock, jwt.MapClaims{"sub": uuid.NewString()})
+
+	// the state belongs to the mock provider and is consumed by the failed callback
+	if _, err := service.CompleteOIDCLogin("another", code, state, testClient); err == nil {
+		t.Fatal("the state of another provider was accepted")
+	}
+
+	if _, err := service.CompleteOIDCLogin("mock", code, state, testClient); err == nil {
+		t.Fatal("a consumed state was accepted")
+	}
+}
+
+func TestOIDCLoginChecksPKCEAndNonce(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+	mock := newMockProvider(t)
+
+	code, state := startLogin(t, service, mock, jwt.MapClaims{"sub": uuid.NewString()})
+	tamperState(t, state, func(pending *models.OIDCState) { pending.CodeVerifier = "another-code-verifier" })
+
+	if _, err := service.CompleteOIDCLogin("mock", code, state, testClient); err == nil {
+		t.Fatal("a login with a wrong code verifier was accepted")
+	}
+
+	code, state = startLogin(t, service, mock, jwt.MapClaims{"sub": uuid.NewString()})
+	tamperState(t, state, func(pending *models.OIDCState) { pending.Nonce = "another-nonce" })
+
+	if _, err := service.CompleteOIDCLogin("mock", code, state, testClient); err == nil {
+		t.Fatal("an id token with a wrong nonce was accepted")
+	}
+}
+
+func TestOIDCLoginLinksIdentity(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+	mock := newMockProvider(t)
+
+	email := fmt.Sprintf("oidc-%s@example.com", uuid.NewString())
+	user := createVerifiedUser(t, service, email)
+	subject := uuid.NewString()
+
+	// first login: the identity is linked to the user with the same verified email
+	code, state := startLogin(t, service, mock, jwt.MapClaims{"sub": subject, "email": email, "email_verified": true})
+	result, err := service.CompleteOIDCLogin("mock", code, state, testClient)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if got := loggedInUserID(t, result); got != user.UserID {
+		t.Fatalf("logged in as %s, want %s", got, user.UserID)
+	}
+
+	identity, err := service.repo.GetIdentity("mock", subject)
+	if err != nil || identity == nil || identity.UserID != user.UserID {
+		t.Fatalf("the identity is not linked to the user: %+v, %v", identity, err)
+	}
+
+	// next login: the identity is found by provider and subject, whatever the email
+	code, state = startLogin(t, service, mock, jwt.MapClaims{"sub": subject, "email": "changed@example.com", "email_verified": true})
+	result, err = service.CompleteOIDCLogin("mock", code, state, testClient)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if got := loggedInUserID(t, result); got != user.UserID {
+		t.Fatalf("logged in as %s, want %s", got, user.UserID)
+	}
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+	mock := newMockProvider(t)
+
+	customerRole(t, service)
+
+	email := fmt.Sprintf("oidc-%s@example.com", uuid.NewString())
+	deleteUserAfterTest(t, email)
+
+	// an unverified email can neither be linked nor create an account
+	code, state := startLogin(t, service, mock, jwt.MapClaims{"sub": uuid.NewString(), "email": email, "email_verified": false})
+	if _, err := service.CompleteOIDCLogin("mock", code, state, testClient); err == nil {
+		t.Fatal("an unverified email created an account")
+	}
+
+	subject := uuid.NewString()
+	code, state = startLogin(t, service, mock, jwt.MapClaims{"sub": subject, "email": email, "email_verified": true, "name": "Jane Doe"})
+	result, err := service.CompleteOIDCLogin("mock", code, state, testClient)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+
+	response := result.(models.LoginResponse)
+	if response.UserDetails.Email != email || response.UserDetails.FirstName != "Jane" || response.UserDetails.LastName != "Doe" {
+		t.Fatalf("unexpected user: %+v", response.UserDetails)
+	}
+
+	identity, err := service.repo.GetIdentity("mock", subject)
+	if err != nil || identity == nil || identity.UserID != response.UserDetails.UserID {
+		t.Fatalf("the identity is not linked to the new user: %+v, %v", identity, err)
+	}
+}
+
+func TestOIDCAuthorizationURL(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+	newMockProvider(t)
+
+	response, err := service.StartOIDCLogin("mock", "")
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+
+	parsed, err := url.Parse(response.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+
+	query := parsed.Query()
+	cached, err := helper.GetCache(constants.OIDC_STATE_PREFIX + query.Get("state"))
+	if err != nil {
+		t.Fatalf("the state is not cached: %v", err)
+	}
+
+	pending, err := helper.JsonToStruct[models.OIDCState](cached)
+	if err != nil {
+		t.Fatalf("invalid cached state: %v", err)
+	}
+
+	if pending.Nonce != query.Get("nonce") || services.PKCECodeChallenge(pending.CodeVerifier) != query.Get("code_challenge") {
+		t.Fatalf("the cached login does not match the authorization URL")
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"e-commerce/modules/user_management/dbAccess"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// accountData is the "account" section of the personal data export.
+type accountData struct {
+	Profile    models.AdminUserResponse `json:"profile"`
+	Identities []identityData           `json:"linked_identities"`
+	TwoFactor  *twoFactorData           `json:"two_factor,omitempty"`
+	// the devices currently logged in
+	Sessions     []models.Session    `json:"sessions"`
+	LoginHistory []models.LoginEvent `json:"login_history"`
+}
+
+type identityData struct {
+	Provider string    `json:"provider"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+type twoFactorData struct {
+	IsEnabled bool       `json:"is_enabled"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+}
+
+// AccountDataProvider contributes the account of the user to the personal data registry:
+// the profile, the linked identities, the 2FA status, the sessions and the login history.
+type AccountDataProvider struct {
+	repo *dbAccess.Repo
+}
+
+// NewAccountDataProvider creates the personal data provider of the user accounts.
+func NewAccountDataProvider() *AccountDataProvider {
+	return &AccountDataProvider{repo: dbAccess.NewUserRepository()}
+}
+
+func (provider *AccountDataProvider) Name() string {
+	return "account"
+}
+
+func (provider *AccountDataProvider) Export(userID uuid.UUID) (any, error) {
+	user, err := provider.repo.GetUnscopedByCondition("users.user_id = ?", userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	data := accountData{Profile: user.AdminResponseObj(), Identities: []identityData{}}
+
+	identities, err := provider.repo.FindIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, identity := range identities {
+		data.Identities = append(data.Identities, identityData{Provider: identity.Provider, Email: identity.Email, LinkedAt: identity.CreatedAt})
+	}
+
+	twoFactor, err := provider.repo.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor != nil {
+		data.TwoFactor = &twoFactorData{IsEnabled: twoFactor.IsEnabled, EnabledAt: twoFactor.EnabledAt}
+	}
+
+	data.Sessions, err = services.ListUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data.LoginHistory, err = provider.repo.FindLoginEvents(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Anonymise replaces the personal data of the user with placeholders and deletes the credentials
+// and the login history.
+// The user row is kept (soft-deleted) so the records referencing the user stay valid,
+// and the original email can be registered again.
+func (provider *AccountDataProvider) Anonymise(tx *gorm.DB, userID uuid.UUID) error {
+	for _, model := range []any{&models.UserRecoveryCode{}, &models.UserTwoFactor{}, &models.UserIdentity{}, &models.LoginEvent{}} {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.UserPassword{}).Where("user_passwords.user_id = ?", userID).Update("password", "").Error; err != nil {
+		return err
+	}
+
+	record := map[string]any{
+		"first_name":            "Deleted",
+		"last_name":             "User",
+		"email":                 fmt.Sprintf("deleted-%s@anonymised.invalid", userID),
+		"phone":                 "",
+		"is_phone_verified":     false,
+		"is_verified":           false,
+		"deletion_requested_at": nil,
+		"deleted_at":            gorm.Expr("COALESCE(users.deleted_at, NOW())"),
+	}
+
+	return tx.Unscoped().Model(&models.User{}).Where("users.user_id = ?", userID).Updates(record).Error
+}
+
+// ExportPersonalData collects everything the application holds about the user (GDPR data export)
+// from the providers registered by the modules.
+//
+// Parameters:
+//
+//	userID (uuid.UUID): The user whose data is exported.
+//
+// Returns:
+//
+//	models.PersonalDataExport: The data by section.
+//	error: An error if a provider fails.
+func (service *Service) ExportPersonalData(userID uuid.UUID) (models.PersonalDataExport, error) {
+	return services.ExportPersonalData(userID)
+}
+
+// ExportPersonalDataForAdmin exports the personal data of any user, deleted users included,
+// e.g. to answer a data access request received by the support.
+//
+// Parameters:
+//
+//	id (string): The user's UUID in string format.
+//
+// Returns:
+//
+//	models.PersonalDataExport: The data by section.
+//	error: An error if the user is not found or a provider fails.
+func (service *Service) ExportPersonalDataForAdmin(id string) (models.PersonalDataExport, error) {
+	user, err := service.getUserForAdmin(id)
+	if err != nil {
+		return models.PersonalDataExport{}, err
+	}
+
+	return services.ExportPersonalData(user.UserID)
+}
+
+// AnonymiseUser erases the personal data of a user (right to erasure) with every registered provider.
+// Unlike HardDeleteUser the rows are kept, so the records referencing the user (e.g. orders) stay valid.
+// The user is logged out everywhere and can not login anymore.
+//
+// Parameters:
+//
+//	id (string): The user's UUID in string format.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the user is not found, is the last admin or a provider fails.
+func (service *Service) AnonymiseUser(id string) (string, error) {
+	user, err := service.getUserForAdmin(id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.anonymiseUser(*user); err != nil {
+		return "", err
+	}
+
+	return "The personal data of the user has been erased.", nil
+}
+
+// anonymiseUser erases the personal data of the user and ends all the sessions of the user.
+// The last admin is refused; soft-deleted users are no longer counted as admins and pass the guard.
+func (service *Service) anonymiseUser(user models.User) error {
+	err := service.removeAdmin(&user, func(tx *gorm.DB) error {
+		return services.AnonymisePersonalData(tx, user.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		fmt.Printf("failed to revoke the tokens of %s: %v\n", user.UserID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/subtle"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ResendPhoneVerificationCode sends an OTP by SMS to the phone of the logged in user.
+// A new OTP can be requested only once the resend cooldown has expired;
+// it replaces the previous OTP and resets its wrong guess counter.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//
+// Returns:
+//
+//	string: A message confirming that the OTP has been sent.
+//	error: An error if the phone is missing or already verified;
+//	       a *helper.ThrottleError if the previous OTP was sent too recently.
+func (service *Service) ResendPhoneVerificationCode(user models.User) (string, error) {
+	if user.Phone == "" {
+		return "", fmt.Errorf("please add a phone number to your profile first")
+	}
+
+	if user.IsPhoneVerified {
+		return "", fmt.Errorf("your phone number (%s) is already verified", user.Phone)
+	}
+
+	if services.SmsSender == nil {
+		return "", fmt.Errorf("phone verification is not available")
+	}
+
+	userID := user.UserID.String()
+
+	if err := helper.StartOtpResendCooldown(constants.PHONE_OTP_COOLDOWN_PREFIX + userID); err != nil {
+		return "", err
+	}
+
+	// the OTP is bound to the number it is sent to, changing the phone invalidates it
+	pending := models.PendingPhoneVerification{
+		Phone: user.Phone,
+		OTP:   helper.GenerateSecureOTP(),
+	}
+
+	_, err := helper.SetCache(constants.PHONE_OTP_PREFIX+userID, helper.StructToJson(pending), time.Duration(helper.OtpExpTime)*time.Minute)
+	if err != nil {
+		return "", err
+	}
+
+	if err := helper.DeleteCache(constants.PHONE_OTP_ATTEMPTS_PREFIX + userID); err != nil {
+		fmt.Printf("failed to reset otp attempts for %s: %v", userID, err)
+	}
+
+	go func() {
+		err := services.SmsSender.SendSMS(pending.Phone, constants.PHONE_VERIFICATION_SMS, os.Getenv(constants.COMPANY_NAME), pending.OTP, helper.OtpExpTime)
+		if err != nil {
+			fmt.Printf("failed to send sms to %s: %v", helper.MaskPhone(pending.Phone), err)
+		}
+	}()
+
+	return fmt.Sprintf("We have sent the OTP to your phone number %s.", helper.MaskPhone(user.Phone)), nil
+}
+
+// VerifyPhone verifies the phone of the logged in user with the OTP sent by SMS.
+// The OTP is invalidated after too many wrong guesses, and the failures count
+// against the client IP like failed logins.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	otp (string): The one-time password entered by the user.
+//	clientIP (string): The IP address of the client.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the OTP is invalid or expired;
+//	       a *helper.ThrottleError if the client IP is blocked.
+func (service *Service) VerifyPhone(user models.User, otp string, clientIP string) (string, error) {
+	if err := helper.CheckClientIP(clientIP); err != nil {
+		return "", err
+	}
+
+	if user.IsPhoneVerified {
+		return "", fmt.Errorf("your phone number (%s) is already verified", user.Phone)
+	}
+
+	userID := user.UserID.String()
+	otpKey := constants.PHONE_OTP_PREFIX + userID
+	attemptsKey := constants.PHONE_OTP_ATTEMPTS_PREFIX + userID
+
+	cached, err := helper.GetCache(otpKey)
+	if err != nil {
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	pending, err := helper.JsonToStruct[models.PendingPhoneVerification](cached)
+	if err != nil || pending.Phone != user.Phone {
+		_ = helper.DeleteCache(otpKey, attemptsKey)
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	otp = strings.ReplaceAll(otp, " ", "")
+
+	if subtle.ConstantTimeCompare([]byte(pending.OTP), []byte(otp)) != 1 {
+		if helper.RecordOtpFailure(attemptsKey, otpKey, clientIP) {
+			return "", fmt.Errorf("too many incorrect attempts. Please request a new OTP")
+		}
+		return "", fmt.Errorf("the OTP you entered is incorrect. Please check and try again")
+	}
+
+	// the phone must not have changed since the OTP was sent
+	record := map[string]any{"is_phone_verified": true}
+	condition := "users.user_id = ? AND users.phone = ?"
+
+	if err := service.repo.UpdateSpecificRecord(record, condition, user.UserID, pending.Phone); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if err := helper.DeleteCache(otpKey, attemptsKey); err != nil {
+		fmt.Printf("failed to delete the phone otp of %s: %v", userID, err)
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	return "Your phone number has been successfully verified!", nil
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetProfile returns the profile of the logged in user.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//
+// Returns:
+//
+//	models.UserResponse: The profile of the user.
+func (service *Service) GetProfile(user models.User) models.UserResponse {
+	return user.ResponseObj()
+}
+
+// UpdateProfile replaces the profile (names and phone) of the logged in user.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	request (models.UpdateProfileRequest): The new profile.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the update fails.
+func (service *Service) UpdateProfile(user models.User, request models.UpdateProfileRequest) (string, error) {
+	record, err := phoneChange(user, request.Phone)
+	if err != nil {
+		return "", err
+	}
+
+	record["first_name"] = request.FirstName
+	record["last_name"] = request.LastName
+
+	return service.updateProfile(user, record)
+}
+
+// PatchProfile updates the given fields of the profile of the logged in user.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	request (models.PatchProfileRequest): The fields to update; empty fields are left unchanged.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if nothing is given or the update fails.
+func (service *Service) PatchProfile(user models.User, request models.PatchProfileRequest) (string, error) {
+	record := make(map[string]any)
+
+	if request.FirstName != "" {
+		record["first_name"] = request.FirstName
+	}
+
+	if request.LastName != "" {
+		record["last_name"] = request.LastName
+	}
+
+	if request.Phone != "" {
+		phoneRecord, err := phoneChange(user, request.Phone)
+		if err != nil {
+			return "", err
+		}
+		maps.Copy(record, phoneRecord)
+	}
+
+	if len(record) == 0 {
+		return "", fmt.Errorf("nothing to update, please provide at least one new value")
+	}
+
+	return service.updateProfile(user, record)
+}
+
+// ChangePassword replaces the password of the logged in user after checking the current one;
+// the wrong current passwords count like failed logins. The other sessions of the user are logged out, the current one stays logged in.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	tokenData (models.AccessTokenData): The details of the access token used for the request.
+//	request (models.ChangePasswordRequest): The current and the new password.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the current password is wrong or the new one is rejected by the policy;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) ChangePassword(user models.User, tokenData models.AccessTokenData, request models.ChangePasswordRequest, client models.ClientInfo) (string, error) {
+	passwordHash, err := service.getPasswordHash(user)
+	if err != nil {
+		return "", err
+	}
+
+	if passwordHash == "" {
+		return "", fmt.Errorf("your account has no password yet, please set one using the forgot-password option")
+	}
+
+	if err := helper.CheckLoginAttempt(user.Email, client.IP); err != nil {
+		return "", err
+	}
+
+	match, _ := helper.VerifyPassword(passwordHash, request.CurrentPassword)
+	if err := service.checkAttempt(user, client, match, "the current password you entered is incorrect"); err != nil {
+		return "", err
+	}
+
+	if request.NewPassword == request.CurrentPassword {
+		return "", fmt.Errorf("the new password must be different from the current password")
+	}
+
+	if err := helper.ValidatePassword(request.NewPassword); err != nil {
+		return "", err
+	}
+
+	newPasswordHash, err := helper.HashPassword(request.NewPassword)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.repo.UpdatePassword(user.UserID, newPasswordHash); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if err := helper.RevokeOtherTokenFamilies(user.UserID.String(), tokenData.SessionID); err != nil {
+		return "", err
+	}
+
+	return "Your password has been changed and your other devices have been logged out.", nil
+}
+
+// RequestAccountDeletion schedules the deletion of the account of the logged in user.
+// The deletion is confirmed with the password, or with an emailed OTP for the users
+// signing in only with an external provider (see reauthenticate).
+// The user is logged out everywhere; logging in again within the grace period
+// cancels the deletion, otherwise the account is deleted by PurgeDeletedAccounts.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	request (models.DeleteAccountRequest): The password or the OTP confirming the deletion.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	string: A message with the deletion date.
+//	error: An error if the password or the OTP is wrong or the user is the last admin;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) RequestAccountDeletion(user models.User, request models.DeleteAccountRequest, client models.ClientInfo) (string, error) {
+	if err := service.reauthenticate(user, request.Password, request.OTP, client); err != nil {
+		return "", err
+	}
+
+	if err := service.ensureAnotherAdmin(&user); err != nil {
+		return "", err
+	}
+
+	requestedAt := time.Now()
+	record := map[string]any{"deletion_requested_at": requestedAt}
+
+	if err := service.repo.UpdateSpecificRecord(record, "users.user_id = ?", user.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		return "", err
+	}
+
+	deletionDate := requestedAt.AddDate(0, 0, helper.AccountDeletionGraceDays).Format("January 2, 2006")
+
+	isHtml := true
+	subject, emailBody := helper.GetAccountDeletionEmailFormat(user.FullName(), deletionDate, isHtml)
+
+	go func() {
+		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+
+	return fmt.Sprintf("Your account will be deleted on %s. Log in again before that date to cancel the deletion.", deletionDate), nil
+}
+
+// PurgeDeletedAccounts erases the accounts whose deletion grace period is over:
+// the personal data is anonymised by the registered providers and the account is soft-deleted.
+// It is run periodically by the cron scheduler.
+//
+// Returns:
+//
+//	error: An error if the accounts can not be loaded; failures of single accounts are logged.
+func (service *Service) PurgeDeletedAccounts() error {
+	cutoff := time.Now().AddDate(0, 0, -helper.AccountDeletionGraceDays)
+
+	users, err := service.repo.FindAllByCondition("users.deletion_requested_at IS NOT NULL AND users.deletion_requested_at <= ?", cutoff)
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		user := users[i]
+
+		// another admin may have been removed since the deletion was requested,
+		// the last admin is refused by anonymiseUser
+		if err := service.anonymiseUser(user); err != nil {
+			fmt.Printf("failed to delete the account %s: %v\n", user.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// cancelAccountDeletion cancels a pending deletion of the account when the user logs in again.
+func (service *Service) cancelAccountDeletion(user models.User) error {
+	if user.DeletionRequestedAt == nil {
+		return nil
+	}
+
+	record := map[string]any{"deletion_requested_at": nil}
+
+	if err := service.repo.UpdateSpecificRecord(record, "users.user_id = ?", user.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return fmt.Errorf(pgErr.Detail)
+		}
+		return err
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	return nil
+}
+
+// updateProfile stores the changed profile fields of the user.
+func (service *Service) updateProfile(user models.User, record map[string]any) (string, error) {
+	if err := service.repo.UpdateSpecificRecord(record, "users.user_id = ?", user.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	services.InvalidateUserCache(user.UserID)
+
+	return "Your profile has been updated successfully.", nil
+}
+
+// getPasswordHash loads the stored password hash of the user ("" if no password is set).
+func (service *Service) getPasswordHash(user models.User) (string, error) {
+	userWithPassword, err := service.repo.GetByConditionWithRelations([]string{"UserPassword"}, "users.user_id = ?", user.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if userWithPassword == nil {
+		return "", fmt.Errorf("no user found with id = %s", user.UserID)
+	}
+
+	return userWithPassword.UserPassword.Password, nil
+}
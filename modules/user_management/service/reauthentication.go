@@ -0,0 +1,131 @@
+package service
+
+import (
+	"crypto/subtle"
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"e-commerce/utils/helper"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SendReauthenticationCode emails an OTP to the logged in user, to confirm a sensitive action
+// (account deletion, email change) when the user has no password, i.e. signs in only with
+// an external provider. A stolen session alone is not enough: the OTP goes to the mailbox.
+// A new OTP can be requested only once the resend cooldown has expired;
+// it replaces the previous OTP and resets its wrong guess counter.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//
+// Returns:
+//
+//	string: A message confirming that the OTP has been sent.
+//	error: An error if the user has a password;
+//	       a *helper.ThrottleError if the previous OTP was sent too recently.
+func (service *Service) SendReauthenticationCode(user models.User) (string, error) {
+	passwordHash, err := service.getPasswordHash(user)
+	if err != nil {
+		return "", err
+	}
+
+	if passwordHash != "" {
+		return "", fmt.Errorf("your account has a password, please confirm with your password instead")
+	}
+
+	userID := user.UserID.String()
+
+	if err := helper.StartOtpResendCooldown(constants.REAUTHENTICATION_OTP_COOLDOWN_PREFIX + userID); err != nil {
+		return "", err
+	}
+
+	otp := helper.GenerateSecureOTP()
+
+	_, err = helper.SetCache(constants.REAUTHENTICATION_OTP_PREFIX+userID, otp, time.Duration(helper.OtpExpTime)*time.Minute)
+	if err != nil {
+		return "", err
+	}
+
+	if err := helper.DeleteCache(constants.REAUTHENTICATION_OTP_ATTEMPTS_PREFIX + userID); err != nil {
+		fmt.Printf("failed to reset otp attempts for %s: %v", userID, err)
+	}
+
+	isHtml := true
+	subject, emailBody := helper.GetReauthenticationEmailFormat(user.FullName(), otp, isHtml)
+
+	go func() {
+		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+
+	return "We have sent an OTP to your email address, please use it to confirm it is you.", nil
+}
+
+// reauthenticate confirms the identity of the logged in user before a sensitive action.
+// Users with a password confirm with it, the wrong passwords count like failed logins;
+// users signing in only with an external provider confirm with the OTP sent by
+// SendReauthenticationCode, which is single-use and invalidated after too many wrong guesses.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	password (string): The password, for users having one.
+//	otp (string): The re-authentication OTP, for users without a password.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	error: An error if the password or the OTP is missing or wrong;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) reauthenticate(user models.User, password string, otp string, client models.ClientInfo) error {
+	passwordHash, err := service.getPasswordHash(user)
+	if err != nil {
+		return err
+	}
+
+	if passwordHash != "" {
+		if err := helper.CheckLoginAttempt(user.Email, client.IP); err != nil {
+			return err
+		}
+
+		match, _ := helper.VerifyPassword(passwordHash, password)
+		return service.checkAttempt(user, client, match, "the password you entered is incorrect")
+	}
+
+	if err := helper.CheckClientIP(client.IP); err != nil {
+		return err
+	}
+
+	otp = strings.ReplaceAll(otp, " ", "")
+	if otp == "" {
+		return fmt.Errorf("please confirm with the OTP sent to your email address, request it with /user/me/reauthentication")
+	}
+
+	userID := user.UserID.String()
+	otpKey := constants.REAUTHENTICATION_OTP_PREFIX + userID
+	attemptsKey := constants.REAUTHENTICATION_OTP_ATTEMPTS_PREFIX + userID
+
+	cachedOtp, err := helper.GetCache(otpKey)
+	if err != nil {
+		helper.RecordIPFailure(client.IP)
+		return fmt.Errorf("the OTP you entered is expired")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(cachedOtp), []byte(otp)) != 1 {
+		if helper.RecordOtpFailure(attemptsKey, otpKey, client.IP) {
+			return fmt.Errorf("too many incorrect attempts. Please request a new OTP")
+		}
+		return fmt.Errorf("the OTP you entered is incorrect. Please check and try again")
+	}
+
+	// the OTP confirms one action only
+	if err := helper.DeleteCache(otpKey, attemptsKey); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,16 +1,22 @@
 package service
 
 import (
+	"crypto/subtle"
 	"e-commerce/modules/user_management/dbAccess"
 	"e-commerce/services"
 	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
 	"e-commerce/utils/helper"
+	"errors"
 	"fmt"
+	"maps"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 // Service provides user management operations, including login, email verification,
@@ -31,22 +37,35 @@ func NewUserService() *Service {
 }
 
 // Login processes user authentication based on provided login credentials.
-// It validates the username, password and then generates a JWT token upon successful authentication.
+// It loads the verified user by email, verifies the password against the stored hash
+// and then generates a JWT token upon successful authentication.
+// Legacy plain text passwords are transparently rehashed on the first successful login.
+// Failed attempts are counted per email and per client IP: every failure doubles the delay
+// before the next attempt, and too many failures lock the account (the user is notified by email).
+// For users with 2FA enabled a short-lived challenge token is returned instead of the tokens,
+// to be exchanged on /login/2fa together with a TOTP or recovery code.
 //
 // Parameters:
 //
 //	data (models.Login): Login credentials containing UserName and Password.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
 //
 // Returns:
 //
-//	any: Typically a models.LoginResponse on success.
-//	error: An error if authentication fails or other issues occur.
-func (service *Service) Login(data models.Login) (any, error) {
+//	any: Typically a models.LoginResponse on success,
+//	     or a models.TwoFactorChallengeResponse if the user has 2FA enabled.
+//	error: An error if authentication fails or other issues occur;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) Login(data models.Login, client models.ClientInfo) (any, error) {
+	if err := helper.CheckLoginAttempt(data.UserName, client.IP); err != nil {
+		return nil, err
+	}
+
 	join := "INNER JOIN user_passwords ON users.user_id = user_passwords.user_id"
-	condition := "users.email = ? AND user_passwords.password = ? AND users.is_verified = true"
+	condition := "users.email = ? AND users.is_verified = true"
 
-	relations := []string{"Role"}
-	userList, err := service.repo.FindAllByConditionWithJoin(relations, join, condition, data.UserName, data.Password)
+	relations := []string{"Role", "UserPassword"}
+	userList, err := service.repo.FindAllByConditionWithJoin(relations, join, condition, data.UserName)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			return nil, fmt.Errorf(pgErr.Detail)
@@ -54,20 +73,240 @@ func (service *Service) Login(data models.Login) (any, error) {
 		return nil, err
 	}
 	if len(userList) < 1 {
+		helper.FakePasswordCheck(data.Password)
+		// unknown emails are counted too, so the responses do not reveal which emails are registered
+		if helper.RecordLoginFailure(data.UserName, client.IP) {
+			return nil, helper.CheckLoginAttempt(data.UserName, client.IP)
+		}
 		return nil, fmt.Errorf("invalid User credentials")
 	}
 
-	user := userList[0].ResponseObj()
+	match, needsRehash := helper.VerifyPassword(userList[0].UserPassword.Password, data.Password)
+	if !match {
+		if helper.RecordLoginFailure(data.UserName, client.IP) {
+			service.sendAccountLockedEmail(userList[0])
+			return nil, helper.CheckLoginAttempt(data.UserName, client.IP)
+		}
+		return nil, fmt.Errorf("invalid User credentials")
+	}
+
+	helper.ResetLoginFailures(data.UserName)
+
+	if needsRehash {
+		if passwordHash, err := helper.HashPassword(data.Password); err == nil {
+			if err := service.repo.UpdatePassword(userList[0].UserID, passwordHash); err != nil {
+				fmt.Printf("failed to rehash password for user %s: %v", userList[0].UserID, err)
+			}
+		}
+	}
+
+	response, err := service.completeLogin(userList[0], client, constants.LOGIN_METHOD_PASSWORD)
+	if err == nil {
+		service.loggedIn(response, userList[0].UserID, data.CartToken)
+	}
+
+	return response, err
+}
+
+// completeLogin finishes the login of an authenticated user: the tokens are issued,
+// or a 2FA challenge is returned if the user has 2FA enabled.
+//
+// Parameters:
+//
+//	user (models.User): The authenticated user with its Role loaded.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//	loginMethod (string): How the user has been authenticated, recorded in the login history.
+//
+// Returns:
+//
+//	any: A models.LoginResponse, or a models.TwoFactorChallengeResponse if 2FA is enabled.
+//	error: An error if the tokens or the challenge can not be created.
+func (service *Service) completeLogin(user models.User, client models.ClientInfo, loginMethod string) (any, error) {
+	twoFactorEnabled, err := service.isTwoFactorEnabled(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first factor is verified, but the tokens are only issued once the second one is
+	if twoFactorEnabled {
+		challengeToken, challengeExpiry, err := helper.CreateTwoFactorChallenge(user, loginMethod)
+		if err != nil {
+			return nil, fmt.Errorf("not able to create the login challenge, please try again")
+		}
+
+		return models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ChallengeExpiry:   challengeExpiry,
+		}, nil
+	}
+
+	response, err := service.issueTokens(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	service.recordLogin(user, client, loginMethod, false)
+	return response, nil
+}
+
+// recordLogin adds a successful login to the login history of the user.
+// The login is not refused when it can not be recorded.
+func (service *Service) recordLogin(user models.User, client models.ClientInfo, loginMethod string, twoFactor bool) {
+	event := models.LoginEvent{
+		UserID:    user.UserID,
+		Method:    loginMethod,
+		TwoFactor: twoFactor,
+		IPAddress: client.IP,
+		UserAgent: client.UserAgent,
+	}
+
+	if err := service.repo.CreateLoginEvent(&event); err != nil {
+		fmt.Printf("failed to record the login of %s: %v\n", user.UserID, err)
+	}
+}
+
+// RefreshToken rotates the refresh token and issues a new access token for the same session.
+// Reusing an already rotated refresh token revokes the whole session (token family).
+//
+// Parameters:
+//
+//	refreshToken (string): The refresh token received on login or on the previous refresh.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	any: A models.LoginResponse with the new tokens.
+//	error: An error if the refresh token is invalid, expired, revoked or reused.
+func (service *Service) RefreshToken(refreshToken string, client models.ClientInfo) (any, error) {
+	userID, familyID, newRefreshToken, refreshExpiry, err := helper.RotateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	condition := "users.user_id = ? AND users.is_verified = true"
+
+	user, err := service.repo.GetByConditionWithRelations([]string{"Role"}, condition, userID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if user == nil {
+		_ = helper.RevokeTokenFamily(userID, familyID)
+		return nil, helper.ErrInvalidRefreshToken
+	}
+
+	if session, err := services.GetSession(familyID); err == nil && session != nil {
+		services.TouchSession(session, client.IP)
+	}
 
-	token, ok := helper.CreateJwtWithClaims(user)
+	userResponse := user.ResponseObj()
+
+	token, ok := helper.CreateJwtWithClaims(*user, familyID)
 	if !ok {
 		return nil, fmt.Errorf("not able to create jwt token, please try again")
 	}
 
 	var response models.LoginResponse
-	response.UserDetails = user
+	response.UserDetails = userResponse
+	response.AuthorizationToken = token
+	response.Expiry = time.Now().Add(time.Duration(helper.ExpiryTime) * time.Minute)
+	response.RefreshToken = newRefreshToken
+	response.RefreshExpiry = refreshExpiry
+
+	return response, nil
+}
+
+// Logout ends the current session: the access token is put on the denylist
+// and the refresh tokens of the session are revoked.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	tokenData (models.AccessTokenData): The details of the access token used for the request.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the revocation fails.
+func (service *Service) Logout(user models.User, tokenData models.AccessTokenData) (string, error) {
+	if err := helper.DenyAccessToken(tokenData.TokenID, tokenData.ExpiresAt); err != nil {
+		return "", err
+	}
+
+	if tokenData.SessionID != "" {
+		if err := helper.RevokeTokenFamily(user.UserID.String(), tokenData.SessionID); err != nil {
+			return "", err
+		}
+	}
+
+	return "Logged out successfully.", nil
+}
+
+// LogoutAll ends all the sessions of the user on every device.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the revocation fails.
+func (service *Service) LogoutAll(user models.User) (string, error) {
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		return "", err
+	}
+
+	return "Logged out from all the devices successfully.", nil
+}
+
+// loggedIn runs the login hooks of the other modules (e.g. the merge of the guest cart) once the tokens are issued;
+// on a 2FA challenge they are run by the 2FA login. A failing hook does not fail the login.
+func (service *Service) loggedIn(response any, userID uuid.UUID, cartToken string) {
+	if _, ok := response.(models.LoginResponse); !ok {
+		return
+	}
+
+	services.LoggedIn(userID, cartToken)
+}
+
+// issueTokens starts a new session (refresh token family) for the user on the client's device
+// and returns the access token along with the first refresh token of the session.
+func (service *Service) issueTokens(user models.User, client models.ClientInfo) (models.LoginResponse, error) {
+	var response models.LoginResponse
+
+	// logging in again within the grace period cancels a requested account deletion
+	if err := service.cancelAccountDeletion(user); err != nil {
+		return response, err
+	}
+
+	familyID := uuid.New().String()
+
+	refreshToken, refreshExpiry, err := helper.IssueRefreshToken(user.UserID.String(), familyID)
+	if err != nil {
+		return response, err
+	}
+
+	if err := services.RegisterSession(familyID, client); err != nil {
+		return response, err
+	}
+
+	userResponse := user.ResponseObj()
+
+	token, ok := helper.CreateJwtWithClaims(user, familyID)
+	if !ok {
+		return response, fmt.Errorf("not able to create jwt token, please try again")
+	}
+
+	response.UserDetails = userResponse
 	response.AuthorizationToken = token
 	response.Expiry = time.Now().Add(time.Duration(helper.ExpiryTime) * time.Minute)
+	response.RefreshToken = refreshToken
+	response.RefreshExpiry = refreshExpiry
 
 	return response, nil
 }
@@ -107,19 +346,27 @@ func (service *Service) GetUserByID(id string) (any, error) {
 
 // VerifyEmail verifies a user's email using an OTP (One-Time Password).
 // It validates the user's status, compares the provided OTP with cached OTP,
-// and, upon a successful match, updates the user's status to verified,
-// clears the OTP cache, and sends a confirmation email.
+// and, upon a successful match, updates the user's status to verified
+// and clears the OTP cache.
+// The OTP is invalidated after too many wrong guesses, and the failures count
+// against the client IP like failed logins.
 //
 // Parameters:
 //
 //	email (string): The email address to verify.
 //	otp (string): The one-time password entered by the user.
+//	clientIP (string): The IP address of the client.
 //
 // Returns:
 //
 //	any: A success message indicating the email is verified.
-//	error: An error if verification fails or other issues occur.
-func (service *Service) VerifyEmail(email, otp string) (any, error) {
+//	error: An error if verification fails or other issues occur;
+//	       a *helper.ThrottleError if the client IP is blocked.
+func (service *Service) VerifyEmail(email, otp, clientIP string) (any, error) {
+	if err := helper.CheckClientIP(clientIP); err != nil {
+		return nil, err
+	}
+
 	condition__ := "users.email = ?"
 
 	user, err := service.repo.GetByCondition(condition__, email)
@@ -131,6 +378,7 @@ func (service *Service) VerifyEmail(email, otp string) (any, error) {
 	}
 
 	if user == nil {
+		helper.RecordIPFailure(clientIP)
 		return nil, fmt.Errorf("user with email (%s) is not registered", email)
 	}
 
@@ -145,7 +393,10 @@ func (service *Service) VerifyEmail(email, otp string) (any, error) {
 
 	otp_ := strings.ReplaceAll(otp, " ", "")
 
-	if cachedOtp != otp_ {
+	if subtle.ConstantTimeCompare([]byte(cachedOtp), []byte(otp_)) != 1 {
+		if helper.RecordOtpFailure(constants.VERIFICATION_OTP_ATTEMPTS_PREFIX+email, email, clientIP) {
+			return nil, fmt.Errorf("too many wrong attempts, the OTP is no longer valid. Please request a new one")
+		}
 		return nil, fmt.Errorf("the OTP you entered is incorrect. Please check and try again")
 	}
 
@@ -163,40 +414,18 @@ func (service *Service) VerifyEmail(email, otp string) (any, error) {
 		return nil, err
 	}
 
-	relations := []string{"UserPassword", "Role"}
-	join := "INNER JOIN user_passwords ON users.user_id = user_passwords.user_id"
-
-	users, err := service.repo.FindAllByConditionWithJoin(relations, join, condition, email, true, false)
-	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok {
-			return nil, fmt.Errorf(pgErr.Detail)
-		}
-		return nil, err
-	}
-
-	if len(users) < 1 {
-		return nil, fmt.Errorf("user details not found")
-	}
-
-	err = helper.DeleteCache(email)
+	err = helper.DeleteCache(email, constants.VERIFICATION_OTP_ATTEMPTS_PREFIX+email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete cache")
 	}
 
-	isHtml := true
-	subject, emailBody := helper.GetCredentialEmailFormat(users[0].FullName(), users[0].Email, users[0].UserPassword.Password, isHtml)
-
-	go func() {
-		if err := services.SmtpServer.SendEmail(users[0].Email, subject, emailBody, isHtml); err != nil {
-			fmt.Printf("failed to send email to %s: %v", users[0].Email, err)
-		}
-	}()
-
-	return "Your email has been successfully verified! We've sent your login credentials to your registered email address. Please check your inbox to proceed.", nil
+	return "Your email has been successfully verified! You can now login with your email and password.", nil
 }
 
 // ResendVerificationCode handles the process to resend an OTP verification code
 // to users who have not yet verified their email.
+// A new OTP can be requested only once the resend cooldown has expired;
+// it replaces the previous OTP and resets its wrong guess counter.
 //
 // Parameters:
 //
@@ -225,6 +454,10 @@ func (service *Service) ResendVerificationCode(email string) (any, error) {
 		return nil, fmt.Errorf("user with email (%s) is already verified, you can proceed to login", email)
 	}
 
+	if err := helper.StartOtpResendCooldown(constants.VERIFICATION_OTP_COOLDOWN_PREFIX + user.Email); err != nil {
+		return nil, err
+	}
+
 	otp := helper.GenerateSecureOTP()
 	isHtml := true
 	subject, emailBody := helper.GetEmailVerificationFormat(user.FullName(), otp, isHtml)
@@ -234,6 +467,10 @@ func (service *Service) ResendVerificationCode(email string) (any, error) {
 		return nil, err
 	}
 
+	if err := helper.DeleteCache(constants.VERIFICATION_OTP_ATTEMPTS_PREFIX + user.Email); err != nil {
+		fmt.Printf("failed to reset otp attempts for %s: %v", user.Email, err)
+	}
+
 	go func() {
 		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
 			fmt.Printf("failed to send email to %s: %v", user.Email, err)
@@ -243,42 +480,83 @@ func (service *Service) ResendVerificationCode(email string) (any, error) {
 	return "We have sent the OTP to your Email address.", nil
 }
 
-// GetUsers retrieves a list of users matching the specified query parameters.
+// userSortColumns are the columns the user list can be sorted by.
+var userSortColumns = map[string]string{
+	"first_name": "users.first_name",
+	"last_name":  "users.last_name",
+	"email":      "users.email",
+	"created_at": "users.created_at",
+	"updated_at": "users.updated_at",
+	"user_id":    "users.user_id",
+}
+
+// GetUsers retrieves one page of the users matching the specified query parameters.
 // It filters for only verified users by default, builds a dynamic query,
-// and returns a formatted list of user responses.
+// and returns a formatted list of user responses with the pagination metadata.
+// Pages are selected by number (page/limit) or by the cursor returned with the previous page.
 //
 // Parameters:
 //
-//	queryParams (*models.UserQueryParams): The query parameters for filtering users.
+//	queryParams (*models.UserQueryParams): The query parameters for filtering, sorting and paginating users.
 //
 // Returns:
 //
 //	[]models.UserResponse: A slice of user response objects.
-//	error: An error if no data is found or if any operation fails.
-func (service *Service) GetUsers(queryParams *models.UserQueryParams) ([]models.UserResponse, error) {
-	queryParams.IsVerified = true
+//	models.Pagination: The page, the limit, the total number of users and the cursor of the next page.
+//	error: An error if the sort or the cursor is invalid, no data is found or if any operation fails.
+func (service *Service) GetUsers(queryParams *models.UserQueryParams) ([]models.UserResponse, models.Pagination, error) {
+	isVerified := true
+	queryParams.IsVerified = &isVerified
 
-	filter := service.repo.GetFilter()
+	users, pagination, err := service.findUsers(service.repo.GetFilter(), queryParams)
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
+
+	return models.UserList(users).ResponseList(), pagination, nil
+}
+
+// findUsers loads one page of the users matching the filter and the query parameters.
+func (service *Service) findUsers(filter *gorm.DB, queryParams *models.UserQueryParams) ([]models.User, models.Pagination, error) {
+	page, err := helper.ParsePageQuery(queryParams.PageQuery, userSortColumns, "user_id")
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
 
 	filter = helper.BuildQuery(filter, queryParams)
 
-	users, _, err := service.repo.FindAll(filter, "user_id", 0, 0)
+	keyset, keysetArgs := page.KeysetCondition()
+
+	users, total, err := service.repo.FindPage(filter, keyset, keysetArgs, page.OrderBy(), page.Limit, page.Offset())
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
-			return nil, fmt.Errorf(pgErr.Detail)
+			return nil, models.Pagination{}, fmt.Errorf(pgErr.Detail)
 		}
-		return nil, err
+		return nil, models.Pagination{}, err
 	}
 
-	if len(users) < 1 {
-		return nil, fmt.Errorf("no data found")
+	if total < 1 {
+		return nil, models.Pagination{}, fmt.Errorf("no data found")
 	}
 
-	return models.UserList(users).ResponseList(), nil
+	var nextCursor string
+	if len(users) > 0 {
+		last := users[len(users)-1]
+		nextCursor = page.NextCursor(len(users), map[string]any{
+			"first_name": last.FirstName,
+			"last_name":  last.LastName,
+			"email":      last.Email,
+			"created_at": last.CreatedAt,
+			"updated_at": last.UpdatedAt,
+			"user_id":    last.UserID,
+		})
+	}
+
+	return users, page.Pagination(total, nextCursor), nil
 }
 
-// AddUser creates a new user in the system and sends an email verification OTP.
-// It populates the user data from the request, generates a password,
+// AddUser registers a new customer with the password chosen by the user and sends an email verification OTP.
+// It validates the password against the password policy, stores only its hash,
 // and caches an OTP before sending it asynchronously.
 //
 // Parameters:
@@ -290,17 +568,43 @@ func (service *Service) GetUsers(queryParams *models.UserQueryParams) ([]models.
 //	string: A success message instructing the user to verify their email.
 //	error: An error if the creation or OTP email sending fails.
 func (service *Service) AddUser(request models.UserRequest) (string, error) {
+	if err := helper.ValidatePassword(request.Password); err != nil {
+		return "", err
+	}
+
+	role, err := service.repo.GetRoleByCode(constants.ROLE_CUSTOMER)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if role == nil {
+		return "", fmt.Errorf("registration is not available, the customer role is not configured")
+	}
+
+	phone, err := helper.NormalizePhone(request.Phone)
+	if err != nil {
+		return "", err
+	}
+
+	passwordHash, err := helper.HashPassword(request.Password)
+	if err != nil {
+		return "", err
+	}
+
 	user := models.User{
 		FirstName: request.FirstName,
 		LastName:  request.LastName,
 		Email:     request.Email,
-		Phone:     request.Phone,
-		RoleID:    request.RoleID,
+		Phone:     phone,
+		RoleID:    role.RoleID,
 		UserPassword: models.UserPassword{
-			Password: helper.GeneratePassword(),
+			Password: passwordHash,
 		},
 	}
-	err := service.repo.Create(&user)
+	err = service.repo.Create(&user)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			return "", fmt.Errorf(pgErr.Detail)
@@ -317,6 +621,9 @@ func (service *Service) AddUser(request models.UserRequest) (string, error) {
 		return "", err
 	}
 
+	// the OTP has just been sent, an immediate resend has to wait for the cooldown
+	_ = helper.StartOtpResendCooldown(constants.VERIFICATION_OTP_COOLDOWN_PREFIX + user.Email)
+
 	go func() {
 		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
 			fmt.Printf("failed to send email to %s: %v", user.Email, err)
@@ -326,9 +633,299 @@ func (service *Service) AddUser(request models.UserRequest) (string, error) {
 	return "Please verify your Email Address. We have sent an OTP to the Email Address.", nil
 }
 
+// CreateUser creates a user on behalf of an admin. The user has no password yet;
+// instead a single-use, time-limited set-password link is emailed to them.
+//
+// Parameters:
+//
+//	request (models.CreateUserRequest): The user data.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the creation or sending the link fails.
+func (service *Service) CreateUser(request models.CreateUserRequest) (string, error) {
+	phone, err := helper.NormalizePhone(request.Phone)
+	if err != nil {
+		return "", err
+	}
+
+	user := models.User{
+		FirstName:    request.FirstName,
+		LastName:     request.LastName,
+		Email:        request.Email,
+		Phone:        phone,
+		RoleID:       request.RoleID,
+		UserPassword: models.UserPassword{},
+	}
+	err = service.repo.Create(&user)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	err = service.sendSetPasswordLink(&user)
+	if err != nil {
+		return "", err
+	}
+
+	return "User created successfully. A link to set the password has been sent to the Email Address.", nil
+}
+
+// SetPassword stores the new password of the user of a set-password token, then consumes the token.
+// Since the token was delivered by email, the user's email is marked as verified as well.
+//
+// Parameters:
+//
+//	request (models.SetPasswordRequest): The token and the new password.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the token is invalid/expired or the password is rejected.
+func (service *Service) SetPassword(request models.SetPasswordRequest) (string, error) {
+	// validate before reading the token, so a rejected password is reported first
+	if err := helper.ValidatePassword(request.Password); err != nil {
+		return "", err
+	}
+
+	cacheKey := constants.SET_PASSWORD_TOKEN_PREFIX + helper.HashToken(request.Token)
+
+	userID, err := helper.GetCache(cacheKey)
+	if err != nil {
+		return "", fmt.Errorf("the link is invalid or has expired")
+	}
+
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return "", fmt.Errorf("the link is invalid or has expired")
+	}
+
+	passwordHash, err := helper.HashPassword(request.Password)
+	if err != nil {
+		return "", err
+	}
+
+	err = service.repo.SetPasswordAndVerify(parsedUUID, passwordHash)
+	if err != nil {
+		if errors.Is(err, dbAccess.ErrUserNotFound) {
+			return "", fmt.Errorf("the link is invalid or has expired")
+		}
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	// the link is used up only once the password is stored, so a failed update can be retried
+	if err := helper.DeleteCache(cacheKey); err != nil {
+		fmt.Printf("failed to delete the set-password token of %s: %v\n", parsedUUID, err)
+	}
+
+	return "Your password has been set successfully. You can now login with your email and password.", nil
+}
+
+// ForgotPassword starts the password recovery of a verified user by emailing a reset OTP.
+// The same message is returned whether or not the email is registered,
+// so the endpoint cannot be used to find out registered emails.
+// A new OTP can be requested only once the resend cooldown of the email has expired;
+// it replaces the previous OTP but keeps its wrong guess counter.
+//
+// Parameters:
+//
+//	request (models.ForgotPasswordRequest): The email of the user.
+//
+// Returns:
+//
+//	string: A message confirming the OTP has been sent (if the email is registered).
+//	error: An error if the cooldown has not expired or caching the OTP fails.
+func (service *Service) ForgotPassword(request models.ForgotPasswordRequest) (string, error) {
+	message := "If the Email Address is registered with us, we have sent an OTP to reset the password."
+
+	// the cooldown applies to unregistered emails too, a 429 must not tell them apart
+	if err := helper.StartOtpResendCooldown(constants.FORGOT_PASSWORD_COOLDOWN_PREFIX + request.Email); err != nil {
+		return "", err
+	}
+
+	condition := "users.email = ? AND users.is_verified = true"
+
+	user, err := service.repo.GetByCondition(condition, request.Email)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if user == nil {
+		return message, nil
+	}
+
+	otp := helper.GenerateSecureOTP()
+	isHtml := true
+	subject, emailBody := helper.GetPasswordResetEmailFormat(user.FullName(), otp, isHtml)
+
+	_, err = helper.SetCache(constants.FORGOT_PASSWORD_OTP_PREFIX+user.Email, otp, time.Duration(helper.OtpExpTime)*time.Minute)
+	if err != nil {
+		return "", err
+	}
+
+	go func() {
+		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+
+	return message, nil
+}
+
+// ResetPassword sets a new password using the OTP sent by ForgotPassword.
+// Wrong OTPs are counted and the OTP is invalidated after too many attempts.
+// The counter outlives the OTP: until it expires, the OTPs issued again are refused as well.
+// On success all the existing sessions of the user are invalidated.
+//
+// Parameters:
+//
+//	request (models.ResetPasswordRequest): The email, OTP and the new password.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the OTP is invalid/expired or the password is rejected.
+func (service *Service) ResetPassword(request models.ResetPasswordRequest) (string, error) {
+	if err := helper.ValidatePassword(request.Password); err != nil {
+		return "", err
+	}
+
+	otpKey := constants.FORGOT_PASSWORD_OTP_PREFIX + request.Email
+	attemptsKey := constants.FORGOT_PASSWORD_ATTEMPTS_PREFIX + request.Email
+
+	cachedOtp, err := helper.GetCache(otpKey)
+	if err != nil {
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	if cachedAttempts, err := helper.GetCache(attemptsKey); err == nil {
+		if attempts, _ := strconv.Atoi(cachedAttempts); attempts >= helper.ResetPasswordMaxAttempts {
+			_ = helper.DeleteCache(otpKey)
+			return "", fmt.Errorf("too many incorrect attempts. Please try again later")
+		}
+	}
+
+	otp := strings.ReplaceAll(request.OTP, " ", "")
+
+	if subtle.ConstantTimeCompare([]byte(cachedOtp), []byte(otp)) != 1 {
+		attempts, err := helper.IncrementCache(attemptsKey, time.Duration(helper.OtpExpTime)*time.Minute)
+		if err != nil {
+			return "", err
+		}
+
+		// the counter is kept, so that requesting a new OTP does not give more attempts
+		if attempts >= int64(helper.ResetPasswordMaxAttempts) {
+			_ = helper.DeleteCache(otpKey)
+			return "", fmt.Errorf("too many incorrect attempts. Please try again later")
+		}
+
+		return "", fmt.Errorf("the OTP you entered is incorrect. Please check and try again")
+	}
+
+	condition := "users.email = ? AND users.is_verified = true"
+
+	user, err := service.repo.GetByCondition(condition, request.Email)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if user == nil {
+		return "", fmt.Errorf("the OTP you entered is expired")
+	}
+
+	passwordHash, err := helper.HashPassword(request.Password)
+	if err != nil {
+		return "", err
+	}
+
+	err = service.repo.UpdatePassword(user.UserID, passwordHash)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if err := helper.DeleteCache(otpKey, attemptsKey); err != nil {
+		return "", fmt.Errorf("failed to delete cache")
+	}
+
+	if err := helper.RevokeAllUserTokens(user.UserID.String()); err != nil {
+		return "", err
+	}
+
+	return "Your password has been reset successfully. Please login with your new password.", nil
+}
+
+// sendSetPasswordLink issues a single-use set-password token for the user,
+// caches its hash and emails the link asynchronously.
+func (service *Service) sendSetPasswordLink(user *models.User) error {
+	isHtml := true
+	subject, emailBody, err := setPasswordEmail(user, isHtml)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+
+	return nil
+}
+
+// setPasswordEmail issues a single-use set-password token for the user, caches its hash
+// and returns the email with the link.
+func setPasswordEmail(user *models.User, isHtml bool) (string, string, error) {
+	token, err := helper.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	cacheKey := constants.SET_PASSWORD_TOKEN_PREFIX + helper.HashToken(token)
+	_, err = helper.SetCache(cacheKey, user.UserID.String(), time.Duration(helper.SetPasswordTokenExpTime)*time.Minute)
+	if err != nil {
+		return "", "", err
+	}
+
+	subject, emailBody := helper.GetSetPasswordEmailFormat(user.FullName(), helper.GetSetPasswordLink(token), isHtml)
+	return subject, emailBody, nil
+}
+
+// sendAccountLockedEmail notifies the user that their account has been locked
+// after too many failed login attempts.
+//
+// Parameters:
+//
+//	user (models.User): The locked user.
+func (service *Service) sendAccountLockedEmail(user models.User) {
+	isHtml := true
+	subject, emailBody := helper.GetAccountLockedEmailFormat(user.FullName(), isHtml)
+
+	go func() {
+		if err := services.SmtpServer.SendEmail(user.Email, subject, emailBody, isHtml); err != nil {
+			fmt.Printf("failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+}
+
 // UpdateUser updates an existing user's information based on the provided user ID and new data.
 // It validates the user's UUID, retrieves the current user data,
 // applies the updates, and then saves the changes.
+// A new email is not applied directly, it goes through the confirmation of the new address.
 //
 // Parameters:
 //
@@ -359,13 +956,31 @@ func (service *Service) UpdateUser(id string, request models.UpdateUserRequest)
 		return "", fmt.Errorf("no user found with id = %s", parsedUUID)
 	}
 
+	phoneRecord, err := phoneChange(*user, request.Phone)
+	if err != nil {
+		return "", err
+	}
+
+	roleChanged := request.RoleID != user.RoleID
+	emailChanged := !strings.EqualFold(strings.TrimSpace(request.Email), user.Email)
+
 	user.FirstName = request.FirstName
 	user.LastName = request.LastName
-	user.Email = request.Email
-	user.Phone = request.Phone
 	user.RoleID = request.RoleID
+	if len(phoneRecord) > 0 {
+		user.Phone = phoneRecord["phone"].(string)
+		user.IsPhoneVerified = false
+	}
+
+	update := func(tx *gorm.DB) error {
+		return service.repo.WithTx(tx).Update(user)
+	}
 
-	err = service.repo.Update(user)
+	if roleChanged {
+		err = service.removeAdmin(user, update)
+	} else {
+		err = service.repo.Transaction(update)
+	}
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			return "", fmt.Errorf(pgErr.Detail)
@@ -373,6 +988,17 @@ func (service *Service) UpdateUser(id string, request models.UpdateUserRequest)
 		return "", err
 	}
 
+	services.InvalidateUserCache(user.UserID)
+
+	// a new email is only applied once confirmed, see startEmailChange;
+	// the OTP is sent once the other changes are committed
+	if emailChanged {
+		if err := service.startEmailChange(*user, request.Email); err != nil {
+			return "", fmt.Errorf("the user has been updated but not the email: %w", err)
+		}
+		return "User upadated successfully. The new email will be used once confirmed with the OTP sent to it.", nil
+	}
+
 	return "User upadated successfully.", nil
 }
 
@@ -406,18 +1032,35 @@ func (service *Service) PartialUpdateUser(id string, request models.PatchUserReq
 		patchData["last_name"] = request.LastName
 	}
 
-	if request.Email != "" {
-		patchData["email"] = request.Email
-	}
+	emailChanged := request.Email != "" && !strings.EqualFold(strings.TrimSpace(request.Email), user.Email)
 
 	if request.Phone != "" {
-		patchData["phone"] = request.Phone
+		phoneRecord, err := phoneChange(*user, request.Phone)
+		if err != nil {
+			return "", err
+		}
+		maps.Copy(patchData, phoneRecord)
 	}
-	if request.RoleID != uuid.Nil {
+
+	roleChanged := request.RoleID != uuid.Nil && request.RoleID != user.RoleID
+	if roleChanged {
 		patchData["role_id"] = request.RoleID
 	}
 
-	err = service.repo.UpdateSpecificRecord(patchData, condition, parsedUUID)
+	update := func(tx *gorm.DB) error {
+		if len(patchData) > 0 {
+			if err := service.repo.WithTx(tx).UpdateSpecificRecord(patchData, condition, parsedUUID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if roleChanged {
+		err = service.removeAdmin(user, update)
+	} else {
+		err = service.repo.Transaction(update)
+	}
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			return "", fmt.Errorf(pgErr.Detail)
@@ -425,6 +1068,19 @@ func (service *Service) PartialUpdateUser(id string, request models.PatchUserReq
 		return "", err
 	}
 
+	if len(patchData) > 0 {
+		services.InvalidateUserCache(parsedUUID)
+	}
+
+	// a new email is only applied once confirmed, see startEmailChange;
+	// the OTP is sent once the other changes are committed
+	if emailChanged {
+		if err := service.startEmailChange(*user, request.Email); err != nil {
+			return "", fmt.Errorf("the user has been updated but not the email: %w", err)
+		}
+		return "User upadated successfully. The new email will be used once confirmed with the OTP sent to it.", nil
+	}
+
 	return "User upadated successfully.", nil
 }
 
@@ -448,7 +1104,9 @@ func (service *Service) DeleteUser(id string) (string, error) {
 		return "", fmt.Errorf("no user found with id = %s", parsedUUID)
 	}
 
-	err = service.repo.Delete(user, true)
+	err = service.removeAdmin(user, func(tx *gorm.DB) error {
+		return service.repo.WithTx(tx).Delete(user, true)
+	})
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			return "", fmt.Errorf(pgErr.Detail)
@@ -456,5 +1114,80 @@ func (service *Service) DeleteUser(id string) (string, error) {
 		return "", err
 	}
 
+	services.InvalidateUserCache(user.UserID)
+
 	return "User successfully deleted", nil
 }
+
+// phoneChange normalises the phone to E.164 and returns the fields to update if it differs
+// from the current phone of the user; a new phone has to be verified again.
+func phoneChange(user models.User, phone string) (map[string]any, error) {
+	normalized, err := helper.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if normalized == user.Phone {
+		return map[string]any{}, nil
+	}
+
+	return map[string]any{
+		"phone":             normalized,
+		"is_phone_verified": false,
+	}, nil
+}
+
+// ensureAnotherAdmin returns an error if the user is the last active admin.
+// It is only an early check, e.g. before a deletion is scheduled: the removal itself
+// goes through removeAdmin, which checks again under lock.
+//
+// Parameters:
+//
+//	user (*models.User): The user about to be deleted or to lose their role.
+//
+// Returns:
+//
+//	error: An error if the user is the last admin or the check fails.
+func (service *Service) ensureAnotherAdmin(user *models.User) error {
+	role, err := service.repo.GetRoleByID(user.RoleID)
+	if err != nil {
+		return err
+	}
+
+	if role == nil || role.Code != constants.ROLE_ADMIN {
+		return nil
+	}
+
+	count, err := service.repo.CountActiveUsersByRoleCode(constants.ROLE_ADMIN)
+	if err != nil {
+		return err
+	}
+
+	if count <= 1 {
+		return errLastAdmin
+	}
+
+	return nil
+}
+
+// removeAdmin runs a change which may remove an admin (deletion, erasure or role change)
+// in a transaction locking the active admins, so that concurrent changes can not remove
+// the last admins together. The change is refused if the user is the last admin.
+//
+// Parameters:
+//
+//	user (*models.User): The user about to be deleted or to lose their role.
+//	change (func(tx *gorm.DB) error): The change, run in the transaction.
+//
+// Returns:
+//
+//	error: An error if the user is the last admin or the change fails.
+func (service *Service) removeAdmin(user *models.User, change func(tx *gorm.DB) error) error {
+	err := service.repo.RemoveFromRole(user.UserID, constants.ROLE_ADMIN, change)
+	if errors.Is(err, dbAccess.ErrLastOfRole) {
+		return errLastAdmin
+	}
+	return err
+}
+
+var errLastAdmin = errors.New("the last admin can not be removed, assign the admin role to another user first")
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"e-commerce/services"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"fmt"
+)
+
+// ListSessions returns the active sessions (devices) of the user, the most recently used first.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	tokenData (models.AccessTokenData): The details of the access token used for the request,
+//	                                    its session is flagged as the current one.
+//
+// Returns:
+//
+//	[]models.Session: The active sessions.
+//	error: An error if the sessions can not be loaded.
+func (service *Service) ListSessions(user models.User, tokenData models.AccessTokenData) ([]models.Session, error) {
+	sessions, err := services.ListUserSessions(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == tokenData.SessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs a single device of the user out: the refresh tokens of the session
+// are revoked and its access tokens are rejected from the next request on.
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	tokenData (models.AccessTokenData): The details of the access token used for the request.
+//	sessionID (string): The id of the session to revoke.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the session does not belong to the user or the revocation fails.
+func (service *Service) RevokeSession(user models.User, tokenData models.AccessTokenData, sessionID string) (string, error) {
+	session, err := services.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	// sessions of other users are reported as missing, their ids are not confirmed
+	if session == nil || session.UserID != user.UserID {
+		return "", fmt.Errorf("no active session found with id = %s", sessionID)
+	}
+
+	if err := helper.RevokeTokenFamily(user.UserID.String(), sessionID); err != nil {
+		return "", err
+	}
+
+	if sessionID == tokenData.SessionID {
+		if err := helper.DenyAccessToken(tokenData.TokenID, tokenData.ExpiresAt); err != nil {
+			return "", err
+		}
+		return "The current session has been logged out.", nil
+	}
+
+	return fmt.Sprintf("The session on %s has been logged out.", session.Device), nil
+}
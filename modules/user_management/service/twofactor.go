@@ -0,0 +1,400 @@
+package service
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EnrollTwoFactor starts the 2FA enrolment of the user: a new TOTP secret is generated,
+// stored encrypted and returned with the otpauth:// URI for the authenticator app.
+// 2FA is only enabled once the secret is confirmed with a first code (see ConfirmTwoFactor).
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//
+// Returns:
+//
+//	models.TwoFactorEnrollResponse: The secret and the provisioning URI.
+//	error: An error if 2FA is already enabled or the secret can not be stored.
+func (service *Service) EnrollTwoFactor(user models.User) (models.TwoFactorEnrollResponse, error) {
+	var response models.TwoFactorEnrollResponse
+
+	twoFactor, err := service.repo.GetTwoFactor(user.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return response, fmt.Errorf(pgErr.Detail)
+		}
+		return response, err
+	}
+
+	if twoFactor != nil && twoFactor.IsEnabled {
+		return response, fmt.Errorf("two-factor authentication is already enabled, disable it first to enrol a new device")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return response, err
+	}
+
+	secretEncrypted, err := helper.EncryptSecret(secret)
+	if err != nil {
+		return response, err
+	}
+
+	// a pending enrolment is simply replaced
+	if twoFactor == nil {
+		twoFactor = &models.UserTwoFactor{UserID: user.UserID}
+	}
+	twoFactor.SecretEncrypted = secretEncrypted
+	twoFactor.LastUsedStep = 0
+
+	if err := service.repo.SaveTwoFactor(twoFactor); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return response, fmt.Errorf(pgErr.Detail)
+		}
+		return response, err
+	}
+
+	response.Secret = secret
+	response.OtpAuthURI = helper.TOTPProvisioningURI(secret, helper.TwoFactorIssuer, user.Email)
+
+	return response, nil
+}
+
+// ConfirmTwoFactor enables 2FA once the user proves the authenticator app works
+// by sending a first valid code. The recovery codes are generated and returned only here.
+// Wrong codes are counted like failed logins (see checkAttempt).
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	code (string): The current TOTP code.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	models.RecoveryCodesResponse: The single-use recovery codes (shown only once).
+//	error: An error if there is no pending enrolment or the code is invalid;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) ConfirmTwoFactor(user models.User, code string, client models.ClientInfo) (models.RecoveryCodesResponse, error) {
+	var response models.RecoveryCodesResponse
+
+	if err := helper.CheckLoginAttempt(user.Email, client.IP); err != nil {
+		return response, err
+	}
+
+	twoFactor, err := service.repo.GetTwoFactor(user.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return response, fmt.Errorf(pgErr.Detail)
+		}
+		return response, err
+	}
+
+	if twoFactor == nil {
+		return response, fmt.Errorf("two-factor authentication is not enrolled, please enrol first")
+	}
+
+	if twoFactor.IsEnabled {
+		return response, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	ok, err := service.verifyTOTPCode(twoFactor, code)
+	if err != nil {
+		return response, err
+	}
+
+	if err := service.checkAttempt(user, client, ok, "the code you entered is incorrect. Please check and try again"); err != nil {
+		return response, err
+	}
+
+	enabledAt := time.Now()
+	record := map[string]any{
+		"is_enabled": true,
+		"enabled_at": enabledAt,
+	}
+
+	if err := service.repo.UpdateTwoFactor(twoFactor.UserTwoFactorID, record); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return response, fmt.Errorf(pgErr.Detail)
+		}
+		return response, err
+	}
+
+	return service.generateRecoveryCodes(user.UserID)
+}
+
+// DisableTwoFactor disables 2FA for the user. The password and a current code
+// (TOTP or recovery code) are both required, a stolen session alone is not enough.
+// Wrong passwords and codes are counted like failed logins (see checkAttempt).
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	request (models.TwoFactorDisableRequest): The password and the code.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	string: A success message.
+//	error: An error if the password or the code is invalid;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) DisableTwoFactor(user models.User, request models.TwoFactorDisableRequest, client models.ClientInfo) (string, error) {
+	if err := helper.CheckLoginAttempt(user.Email, client.IP); err != nil {
+		return "", err
+	}
+
+	userWithPassword, err := service.repo.GetByConditionWithRelations([]string{"UserPassword"}, "users.user_id = ?", user.UserID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	if userWithPassword == nil {
+		return "", fmt.Errorf("no user found with id = %s", user.UserID)
+	}
+
+	match, _ := helper.VerifyPassword(userWithPassword.UserPassword.Password, request.Password)
+	if err := service.checkAttempt(user, client, match, "the password you entered is incorrect"); err != nil {
+		return "", err
+	}
+
+	twoFactor, err := service.getEnabledTwoFactor(user.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := service.verifyTwoFactorCode(twoFactor, request.Code)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.checkAttempt(user, client, ok, "the code you entered is incorrect. Please check and try again"); err != nil {
+		return "", err
+	}
+
+	if err := service.repo.DeleteTwoFactor(user.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return "", fmt.Errorf(pgErr.Detail)
+		}
+		return "", err
+	}
+
+	return "Two-factor authentication has been disabled.", nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user; the old codes stop working.
+// Wrong codes are counted like failed logins (see checkAttempt).
+//
+// Parameters:
+//
+//	user (models.User): The logged in user.
+//	code (string): A current TOTP code or an unused recovery code.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	models.RecoveryCodesResponse: The new recovery codes (shown only once).
+//	error: An error if 2FA is not enabled or the code is invalid;
+//	       a *helper.ThrottleError if the attempt is refused by the brute force protection.
+func (service *Service) RegenerateRecoveryCodes(user models.User, code string, client models.ClientInfo) (models.RecoveryCodesResponse, error) {
+	if err := helper.CheckLoginAttempt(user.Email, client.IP); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	twoFactor, err := service.getEnabledTwoFactor(user.UserID)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	ok, err := service.verifyTwoFactorCode(twoFactor, code)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	if err := service.checkAttempt(user, client, ok, "the code you entered is incorrect. Please check and try again"); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	return service.generateRecoveryCodes(user.UserID)
+}
+
+// LoginTwoFactor completes the login of a user with 2FA enabled: the challenge token
+// returned by Login is exchanged, together with a TOTP or recovery code, for the tokens.
+// The challenge is single-use and is revoked after too many wrong codes.
+//
+// Parameters:
+//
+//	request (models.TwoFactorLoginRequest): The challenge token and the code.
+//	client (models.ClientInfo): The IP address and the user agent of the client.
+//
+// Returns:
+//
+//	any: A models.LoginResponse on success.
+//	error: An error if the challenge or the code is invalid;
+//	       a *helper.ThrottleError if the client IP is blocked.
+func (service *Service) LoginTwoFactor(request models.TwoFactorLoginRequest, client models.ClientInfo) (any, error) {
+	if err := helper.CheckClientIP(client.IP); err != nil {
+		return nil, err
+	}
+
+	claims, err := helper.ParseTwoFactorChallenge(request.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("the login session is invalid or has expired, please login again")
+	}
+
+	user, err := service.repo.GetByConditionWithRelations([]string{"Role"}, "users.user_id = ? AND users.is_verified = true", userID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("the login session is invalid or has expired, please login again")
+	}
+
+	twoFactor, err := service.getEnabledTwoFactor(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := service.verifyTwoFactorCode(twoFactor, request.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+
+	if !ok {
+		if helper.RecordTwoFactorFailure(claims.Id, expiresAt, client.IP) {
+			return nil, fmt.Errorf("too many wrong codes, please login again")
+		}
+		return nil, fmt.Errorf("the code you entered is incorrect. Please check and try again")
+	}
+
+	// the challenge can be exchanged only once
+	if err := helper.DenyAccessToken(claims.Id, expiresAt); err != nil {
+		return nil, err
+	}
+
+	response, err := service.issueTokens(*user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	service.recordLogin(*user, client, claims.LoginMethod, true)
+	service.loggedIn(response, user.UserID, request.CartToken)
+	return response, nil
+}
+
+// isTwoFactorEnabled reports whether the user has confirmed 2FA.
+func (service *Service) isTwoFactorEnabled(userID uuid.UUID) (bool, error) {
+	twoFactor, err := service.repo.GetTwoFactor(userID)
+	if err != nil {
+		return false, err
+	}
+	return twoFactor != nil && twoFactor.IsEnabled, nil
+}
+
+// getEnabledTwoFactor loads the 2FA settings of the user and fails if 2FA is not enabled.
+func (service *Service) getEnabledTwoFactor(userID uuid.UUID) (*models.UserTwoFactor, error) {
+	twoFactor, err := service.repo.GetTwoFactor(userID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return nil, fmt.Errorf(pgErr.Detail)
+		}
+		return nil, err
+	}
+
+	if twoFactor == nil || !twoFactor.IsEnabled {
+		return nil, fmt.Errorf("two-factor authentication is not enabled")
+	}
+
+	return twoFactor, nil
+}
+
+// checkAttempt counts the outcome of a password or code check made by a logged in user
+// with the brute force protection of the login: a failure is recorded per email and client IP
+// and too many of them lock the account, a success resets the failures of the email.
+// It returns nil on success, the failure message or the lockout *helper.ThrottleError otherwise.
+func (service *Service) checkAttempt(user models.User, client models.ClientInfo, ok bool, failureMessage string) error {
+	if ok {
+		helper.ResetLoginFailures(user.Email)
+		return nil
+	}
+
+	if helper.RecordLoginFailure(user.Email, client.IP) {
+		service.sendAccountLockedEmail(user)
+		return helper.CheckLoginAttempt(user.Email, client.IP)
+	}
+
+	return errors.New(failureMessage)
+}
+
+// verifyTwoFactorCode accepts either a current TOTP code or an unused recovery code,
+// which is consumed.
+func (service *Service) verifyTwoFactorCode(twoFactor *models.UserTwoFactor, code string) (bool, error) {
+	ok, err := service.verifyTOTPCode(twoFactor, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	return service.repo.UseRecoveryCode(twoFactor.UserID, helper.HashToken(helper.NormalizeRecoveryCode(code)))
+}
+
+// verifyTOTPCode validates the code against the decrypted secret at helper.TOTPClock()
+// and records its time step, so the same code can not be used twice.
+func (service *Service) verifyTOTPCode(twoFactor *models.UserTwoFactor, code string) (bool, error) {
+	secret, err := helper.DecryptSecret(twoFactor.SecretEncrypted)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := helper.ValidateTOTPCode(secret, code, helper.TOTPClock(), twoFactor.LastUsedStep)
+	if !ok {
+		return false, nil
+	}
+
+	return service.repo.MarkTwoFactorStepUsed(twoFactor.UserTwoFactorID, step)
+}
+
+// generateRecoveryCodes replaces the recovery codes of the user and returns the new plain codes.
+func (service *Service) generateRecoveryCodes(userID uuid.UUID) (models.RecoveryCodesResponse, error) {
+	var response models.RecoveryCodesResponse
+
+	codes, err := helper.GenerateRecoveryCodes(helper.RecoveryCodeCount)
+	if err != nil {
+		return response, err
+	}
+
+	codeHashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		codeHashes = append(codeHashes, helper.HashToken(code))
+	}
+
+	if err := service.repo.ReplaceRecoveryCodes(userID, codeHashes); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			return response, fmt.Errorf(pgErr.Detail)
+		}
+		return response, err
+	}
+
+	response.RecoveryCodes = codes
+	return response, nil
+}
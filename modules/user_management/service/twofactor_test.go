@@ -0,0 +1,112 @@
+package service
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// newTestClient returns a client with an IP of its own, so the failures counted
+// by a test do not throttle the other tests.
+func newTestClient() models.ClientInfo {
+	return models.ClientInfo{IP: "test-" + uuid.NewString(), UserAgent: "go-test"}
+}
+
+// enableTwoFactor enrols and confirms 2FA for the user and returns the confirmation code and the recovery codes.
+func enableTwoFactor(t *testing.T, service *Service, user models.User) (string, []string) {
+	t.Helper()
+
+	enrollment, err := service.EnrollTwoFactor(user)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+
+	code, err := helper.GenerateTOTPCode(enrollment.Secret, helper.TOTPStep(helper.TOTPClock()))
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode: %v", err)
+	}
+
+	recovery, err := service.ConfirmTwoFactor(user, code, newTestClient())
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+
+	return code, recovery.RecoveryCodes
+}
+
+func TestTwoFactorRecoveryCodesAreSingleUse(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+
+	user := createVerifiedUser(t, service, fmt.Sprintf("2fa-%s@example.com", uuid.NewString()))
+	_, recoveryCodes := enableTwoFactor(t, service, *user)
+
+	twoFactor, err := service.getEnabledTwoFactor(user.UserID)
+	if err != nil {
+		t.Fatalf("2FA is not enabled: %v", err)
+	}
+
+	if ok, err := service.verifyTwoFactorCode(twoFactor, recoveryCodes[0]); err != nil || !ok {
+		t.Fatalf("a recovery code was rejected: %v", err)
+	}
+
+	if ok, err := service.verifyTwoFactorCode(twoFactor, recoveryCodes[0]); err != nil || ok {
+		t.Fatalf("a used recovery code was accepted again: %v", err)
+	}
+
+	if ok, err := service.verifyTwoFactorCode(twoFactor, recoveryCodes[1]); err != nil || !ok {
+		t.Fatalf("another recovery code was rejected: %v", err)
+	}
+}
+
+func TestTwoFactorCodeIsSingleUse(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+
+	user := createVerifiedUser(t, service, fmt.Sprintf("2fa-%s@example.com", uuid.NewString()))
+	code, _ := enableTwoFactor(t, service, *user)
+
+	// the code confirming the enrolment can not be used again
+	twoFactor, err := service.getEnabledTwoFactor(user.UserID)
+	if err != nil {
+		t.Fatalf("2FA is not enabled: %v", err)
+	}
+
+	if ok, err := service.verifyTwoFactorCode(twoFactor, code); err != nil || ok {
+		t.Fatalf("a used code was accepted again: %v", err)
+	}
+}
+
+func TestTwoFactorCodesAreThrottled(t *testing.T) {
+	requireIntegration(t)
+	service := NewUserService()
+
+	user := createVerifiedUser(t, service, fmt.Sprintf("2fa-%s@example.com", uuid.NewString()))
+	client := newTestClient()
+
+	if _, err := service.EnrollTwoFactor(*user); err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+
+	var throttleErr *helper.ThrottleError
+
+	_, err := service.ConfirmTwoFactor(*user, "000000", client)
+	if err == nil || errors.As(err, &throttleErr) {
+		t.Fatalf("expected a wrong code error, got %v", err)
+	}
+
+	// the failure is counted like a failed login: the next attempt waits for the backoff
+	_, err = service.ConfirmTwoFactor(*user, "000000", client)
+	if !errors.As(err, &throttleErr) {
+		t.Fatalf("expected a *helper.ThrottleError, got %v", err)
+	}
+
+	_, err = service.DisableTwoFactor(*user, models.TwoFactorDisableRequest{Password: "wrong", Code: "000000"}, client)
+	if !errors.As(err, &throttleErr) {
+		t.Fatalf("expected a *helper.ThrottleError, got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	addressService "e-commerce/modules/address_management/service"
+	cartService "e-commerce/modules/cart/service"
+	userService "e-commerce/modules/user_management/service"
+	"e-commerce/services"
+)
+
+// registerPersonalDataProviders registers the modules holding personal data,
+// used by the GDPR data export and the erasure of the accounts
+func registerPersonalDataProviders() {
+	services.RegisterPersonalDataProvider(userService.NewAccountDataProvider())
+	services.RegisterPersonalDataProvider(addressService.NewAddressDataProvider())
+	services.RegisterPersonalDataProvider(cartService.NewCartDataProvider())
+}
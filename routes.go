@@ -1,12 +1,24 @@
 package main
 
 import (
-	"e-commerce/modules/user_management/route"
+	addressRoute "e-commerce/modules/address_management/route"
+	cartRoute "e-commerce/modules/cart/route"
+	catalogRoute "e-commerce/modules/catalog/route"
+	inventoryRoute "e-commerce/modules/inventory/route"
+	mediaRoute "e-commerce/modules/media/route"
+	roleRoute "e-commerce/modules/role_management/route"
+	userRoute "e-commerce/modules/user_management/route"
 
 	"github.com/gin-gonic/gin"
 )
 
 // registerRoute registers all routes for the application
 func registerRoute(router *gin.Engine) {
-	route.UserManagementRoutes(router)
+	userRoute.UserManagementRoutes(router)
+	roleRoute.RoleManagementRoutes(router)
+	addressRoute.AddressManagementRoutes(router)
+	catalogRoute.CatalogRoutes(router)
+	mediaRoute.MediaRoutes(router)
+	inventoryRoute.InventoryRoutes(router)
+	cartRoute.CartRoutes(router)
 }
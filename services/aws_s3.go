@@ -2,16 +2,25 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"e-commerce/shared/models"
+	"errors"
+	"io"
 	"log"
-	"os"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// s3PartSize is the size of the parts of the uploads of unknown size, the smallest part S3 accepts.
+const s3PartSize = 5 << 20
+
+// S3Service is the ObjectStore backed by an S3 bucket. The objects are private
+// and shared with presigned URLs.
 type S3Service models.S3Service
 
 func NewS3Service(bucket string) *models.S3Service {
@@ -25,26 +34,142 @@ func NewS3Service(bucket string) *models.S3Service {
 	}
 }
 
-func (service *S3Service) UploadFile(filePath string, key string) error {
-	file, err := os.Open(filePath)
+// newS3ObjectStore creates the S3 object store of the bucket, in the region if given
+// (otherwise the region of the AWS configuration is used).
+func newS3ObjectStore(bucket string, region string) (*S3Service, error) {
+	var options []func(*config.LoadOptions) error
+	if region != "" {
+		options = append(options, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Service{
+		Client: s3.NewFromConfig(cfg),
+		Bucket: bucket,
+	}, nil
+}
+
+func (service *S3Service) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if size < 0 {
+		return service.putStream(ctx, key, body, contentType)
+	}
+
+	_, err := service.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &service.Bucket,
+		Key:           &key,
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   &contentType,
+	})
+	return err
+}
+
+// putStream uploads a body of unknown size in parts of s3PartSize, holding one part in memory at a time.
+// A body smaller than a part is uploaded with a single PutObject; a failed multipart upload is aborted.
+func (service *S3Service) putStream(ctx context.Context, key string, body io.Reader, contentType string) error {
+	part := make([]byte, s3PartSize)
+
+	n, err := io.ReadFull(body, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return service.Put(ctx, key, bytes.NewReader(part[:n]), int64(n), contentType)
+	}
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	_, err = service.Client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket: &service.Bucket,
-		Key:    &key,
-		Body:   file,
-		ACL:    types.ObjectCannedACLPublicRead,
+	upload, err := service.Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      &service.Bucket,
+		Key:         &key,
+		ContentType: &contentType,
 	})
 	if err != nil {
 		return err
 	}
-	log.Printf("File uploaded to S3: %s", key)
+
+	abort := func(err error) error {
+		if _, abortErr := service.Client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   &service.Bucket,
+			Key:      &key,
+			UploadId: upload.UploadId,
+		}); abortErr != nil {
+			log.Printf("failed to abort the upload of %s: %v", key, abortErr)
+		}
+		return err
+	}
+
+	var parts []types.CompletedPart
+
+	for number := int32(1); n > 0; number++ {
+		output, err := service.Client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        &service.Bucket,
+			Key:           &key,
+			UploadId:      upload.UploadId,
+			PartNumber:    aws.Int32(number),
+			Body:          bytes.NewReader(part[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, types.CompletedPart{ETag: output.ETag, PartNumber: aws.Int32(number)})
+
+		n, err = io.ReadFull(body, part)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return abort(err)
+		}
+	}
+
+	_, err = service.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &service.Bucket,
+		Key:             &key,
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(err)
+	}
+
 	return nil
 }
 
+func (service *S3Service) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := service.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &service.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (service *S3Service) Delete(ctx context.Context, key string) error {
+	_, err := service.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &service.Bucket,
+		Key:    &key,
+	})
+	return err
+}
+
+func (service *S3Service) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	request, err := s3.NewPresignClient(service.Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: &service.Bucket,
+		Key:    &key,
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
 func (service *S3Service) ListFiles() ([]string, error) {
 	output, err := service.Client.ListObjectsV2(context.TODO(), &s3.ListObjectsV2Input{
 		Bucket: &service.Bucket,
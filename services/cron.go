@@ -1,2 +1,68 @@
 // Cron job setup
 package services
+
+import (
+	"context"
+	"e-commerce/utils/constants"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var cronStop = make(chan struct{})
+var cronWait sync.WaitGroup
+var cronStopOnce sync.Once
+
+// ScheduleJob runs the job every interval until StopCronJobs is called.
+// With several instances of the service running, a Redis lock makes sure
+// a run happens on one instance only.
+func ScheduleJob(name string, interval time.Duration, job func() error) {
+	cronWait.Add(1)
+
+	go func() {
+		defer cronWait.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cronStop:
+				return
+			case <-ticker.C:
+				runJob(name, interval, job)
+			}
+		}
+	}()
+}
+
+// StopCronJobs stops the scheduled jobs and waits for the running ones to finish.
+func StopCronJobs() {
+	cronStopOnce.Do(func() {
+		close(cronStop)
+	})
+	cronWait.Wait()
+}
+
+func runJob(name string, interval time.Duration, job func() error) {
+	ctx := context.Background()
+
+	// the lock outlives the run a little, so a slower instance does not run the job again
+	locked, err := GetRedisClient().SetNX(ctx, constants.CRON_LOCK_PREFIX+name, 1, interval/2).Result()
+	if err != nil {
+		fmt.Printf("cron job %s: failed to take the lock: %v\n", name, err)
+		return
+	}
+
+	if !locked {
+		return
+	}
+
+	startedAt := time.Now()
+	if err := job(); err != nil {
+		fmt.Printf("cron job %s failed after %s: %v\n", name, time.Since(startedAt), err)
+		return
+	}
+
+	fmt.Printf("cron job %s finished in %s\n", name, time.Since(startedAt))
+}
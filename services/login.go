@@ -0,0 +1,29 @@
+// Hooks of the modules on the logins of the users
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// LoginHook is run once a user has logged in and got the tokens, with the guest cart token sent
+// with the login (empty if none), e.g. to move the guest cart into the cart of the user.
+type LoginHook func(userID uuid.UUID, cartToken string) error
+
+var loginHooks []LoginHook
+
+// RegisterLoginHook adds a hook run after every successful login, in the order of registration.
+func RegisterLoginHook(hook LoginHook) {
+	loginHooks = append(loginHooks, hook)
+}
+
+// LoggedIn runs the registered hooks for a user who just logged in.
+// The login does not fail with a hook, its error is only logged.
+func LoggedIn(userID uuid.UUID, cartToken string) {
+	for _, hook := range loginHooks {
+		if err := hook(userID, cartToken); err != nil {
+			fmt.Printf("failed to run a login hook for user %s: %v\n", userID, err)
+		}
+	}
+}
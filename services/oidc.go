@@ -0,0 +1,461 @@
+// OpenID Connect relying party (authorization code flow with PKCE)
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"e-commerce/shared/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// the discovery document and the keys are refreshed after this time
+const oidcMetadataTTL = time.Hour
+
+// accepted clock difference with the provider when validating the ID token times
+const oidcClockSkew = time.Minute
+
+// OIDCProvider is an OpenID Connect provider users can login with.
+// The discovery document and the signing keys are fetched on first use and cached.
+type OIDCProvider struct {
+	Name         string
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu         sync.Mutex
+	discovery  *oidcDiscovery
+	keys       map[string]crypto.PublicKey
+	fetchedAt  time.Time
+	keysLoaded time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	ErrorDesc   string `json:"error_description"`
+}
+
+type oidcJWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// OIDCAudience accepts both forms of the aud claim: a single string or an array.
+type OIDCAudience []string
+
+func (audience *OIDCAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*audience = OIDCAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*audience = multiple
+	return nil
+}
+
+// OIDCIDTokenClaims are the ID token claims used to identify and create the user.
+type OIDCIDTokenClaims struct {
+	Issuer        string       `json:"iss"`
+	Subject       string       `json:"sub"`
+	Audience      OIDCAudience `json:"aud"`
+	AuthorizedBy  string       `json:"azp"`
+	ExpiresAt     int64        `json:"exp"`
+	IssuedAt      int64        `json:"iat"`
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified any          `json:"email_verified"`
+	Name          string       `json:"name"`
+	GivenName     string       `json:"given_name"`
+	FamilyName    string       `json:"family_name"`
+}
+
+// Valid checks the expiry and issue time; the issuer, audience and nonce are checked by VerifyIDToken.
+func (claims OIDCIDTokenClaims) Valid() error {
+	now := time.Now()
+
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(oidcClockSkew)) {
+		return fmt.Errorf("id token is expired")
+	}
+
+	if claims.IssuedAt != 0 && now.Add(oidcClockSkew).Before(time.Unix(claims.IssuedAt, 0)) {
+		return fmt.Errorf("id token is issued in the future")
+	}
+
+	return nil
+}
+
+// IsEmailVerified reports whether the provider vouches for the email address.
+// Some providers send email_verified as a string.
+func (claims OIDCIDTokenClaims) IsEmailVerified() bool {
+	switch verified := claims.EmailVerified.(type) {
+	case bool:
+		return verified
+	case string:
+		return strings.EqualFold(verified, "true")
+	default:
+		return false
+	}
+}
+
+var oidcProviders = map[string]*OIDCProvider{}
+
+// InitOIDCProviders registers the configured OIDC providers.
+// Nothing is fetched here, the provider metadata is discovered on the first login.
+func InitOIDCProviders(configs []models.OIDCProvider) {
+	providers := map[string]*OIDCProvider{}
+
+	for _, config := range configs {
+		if config.Name == "" || config.IssuerURL == "" || config.ClientID == "" {
+			fmt.Printf("skipping the oidc provider (%s): name, issuer_url and client_id are required\n", config.Name)
+			continue
+		}
+
+		scopes := config.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+
+		providers[strings.ToLower(config.Name)] = &OIDCProvider{
+			Name:         strings.ToLower(config.Name),
+			issuerURL:    strings.TrimRight(config.IssuerURL, "/"),
+			clientID:     config.ClientID,
+			clientSecret: os.Getenv(config.ClientSecretEnv),
+			redirectURL:  config.RedirectURL,
+			scopes:       scopes,
+			httpClient:   &http.Client{Timeout: 10 * time.Second},
+		}
+	}
+
+	oidcProviders = providers
+}
+
+// GetOIDCProvider returns the registered provider with the given name.
+func GetOIDCProvider(name string) (*OIDCProvider, bool) {
+	provider, ok := oidcProviders[strings.ToLower(name)]
+	return provider, ok
+}
+
+// PKCECodeChallenge returns the S256 code challenge of the PKCE code verifier (RFC 7636).
+func PKCECodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL of the provider's authorization endpoint the user is redirected to.
+func (provider *OIDCProvider) AuthCodeURL(state string, nonce string, codeVerifier string) (string, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.clientID)
+	query.Set("redirect_uri", provider.redirectURL)
+	query.Set("scope", strings.Join(provider.scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", PKCECodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code at the token endpoint and returns the raw ID token.
+func (provider *OIDCProvider) Exchange(code string, codeVerifier string) (string, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.redirectURL)
+	form.Set("client_id", provider.clientID)
+	form.Set("code_verifier", codeVerifier)
+	if provider.clientSecret != "" {
+		form.Set("client_secret", provider.clientSecret)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := provider.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the %s token endpoint: %w", provider.Name, err)
+	}
+	defer response.Body.Close()
+
+	var tokenResponse oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("invalid response from the %s token endpoint", provider.Name)
+	}
+
+	if response.StatusCode != http.StatusOK || tokenResponse.Error != "" {
+		return "", fmt.Errorf("the %s login failed: %s %s", provider.Name, tokenResponse.Error, tokenResponse.ErrorDesc)
+	}
+
+	if tokenResponse.IDToken == "" {
+		return "", fmt.Errorf("the %s login did not return an id token", provider.Name)
+	}
+
+	return tokenResponse.IDToken, nil
+}
+
+// VerifyIDToken verifies the ID token signature with the provider keys (JWKS)
+// and checks the issuer, the audience, the expiry and the nonce of the login.
+func (provider *OIDCProvider) VerifyIDToken(rawIDToken string, nonce string) (*OIDCIDTokenClaims, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &OIDCIDTokenClaims{}
+	token, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodEd25519:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		return provider.getKey(keyID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid id token")
+	}
+
+	if claims.Issuer != discovery.Issuer {
+		return nil, fmt.Errorf("invalid id token issuer")
+	}
+
+	audienceMatch := false
+	for _, audience := range claims.Audience {
+		if audience == provider.clientID {
+			audienceMatch = true
+			break
+		}
+	}
+	if !audienceMatch || (len(claims.Audience) > 1 && claims.AuthorizedBy != provider.clientID) {
+		return nil, fmt.Errorf("invalid id token audience")
+	}
+
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("invalid id token nonce")
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid id token subject")
+	}
+
+	return claims, nil
+}
+
+// getDiscovery returns the cached discovery document, fetching it when missing or stale.
+func (provider *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.discovery != nil && time.Since(provider.fetchedAt) < oidcMetadataTTL {
+		return provider.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := provider.getJSON(provider.issuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimRight(discovery.Issuer, "/") != provider.issuerURL {
+		return nil, fmt.Errorf("the %s discovery document is for another issuer (%s)", provider.Name, discovery.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, fmt.Errorf("the %s discovery document is incomplete", provider.Name)
+	}
+
+	provider.discovery = &discovery
+	provider.fetchedAt = time.Now()
+	return provider.discovery, nil
+}
+
+// getKey returns the provider's public key with the key id.
+// The keys are fetched again when the key is unknown, since the provider may have rotated them.
+func (provider *OIDCProvider) getKey(keyID string) (crypto.PublicKey, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if key, ok := provider.lookupKey(keyID); ok && time.Since(provider.keysLoaded) < oidcMetadataTTL {
+		return key, nil
+	}
+
+	var keySet struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	if err := provider.getJSON(discovery.JwksURI, &keySet); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseOIDCJWK(jwk)
+		if err != nil {
+			fmt.Printf("skipping the %s key (%s): %v\n", provider.Name, jwk.KeyID, err)
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	provider.keys = keys
+	provider.keysLoaded = time.Now()
+
+	if key, ok := provider.lookupKey(keyID); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key: %s", keyID)
+}
+
+// lookupKey finds the key by id; a token without kid is accepted only if the provider has a single key.
+func (provider *OIDCProvider) lookupKey(keyID string) (crypto.PublicKey, bool) {
+	if keyID == "" && len(provider.keys) == 1 {
+		for _, key := range provider.keys {
+			return key, true
+		}
+	}
+
+	key, ok := provider.keys[keyID]
+	return key, ok
+}
+
+func (provider *OIDCProvider) getJSON(target string, value any) error {
+	response, err := provider.httpClient.Get(target)
+	if err != nil {
+		return fmt.Errorf("failed to reach the %s provider: %w", provider.Name, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", response.StatusCode, target)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(value); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// parseOIDCJWK converts an RSA, EC or OKP (Ed25519) JWK into a public key.
+func parseOIDCJWK(jwk oidcJWK) (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", jwk.KeyType)
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"e-commerce/services/oidctest"
+	"e-commerce/shared/models"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const testClientID = "test-client"
+
+// newTestProvider starts a mock provider and registers it as the "mock" OIDC provider.
+func newTestProvider(t *testing.T) (*oidctest.Provider, *OIDCProvider) {
+	t.Helper()
+
+	mock, err := oidctest.NewProvider(testClientID)
+	if err != nil {
+		t.Fatalf("failed to start the mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	InitOIDCProviders([]models.OIDCProvider{{
+		Name:        "mock",
+		IssuerURL:   mock.Issuer(),
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost:8080/auth/oidc/mock/callback",
+	}})
+
+	provider, ok := GetOIDCProvider("mock")
+	if !ok {
+		t.Fatal("the mock provider is not registered")
+	}
+
+	return mock, provider
+}
+
+func TestOIDCLogin(t *testing.T) {
+	mock, provider := newTestProvider(t)
+
+	authorizationURL, err := provider.AuthCodeURL("the-state", "the-nonce", "the-code-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	if got := parsed.Query().Get("code_challenge"); got != PKCECodeChallenge("the-code-verifier") {
+		t.Errorf("code_challenge = %q, want the S256 challenge of the verifier", got)
+	}
+
+	code, state, err := mock.Authorize(authorizationURL, jwt.MapClaims{"sub": "user-1", "email": "jane@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if state != "the-state" {
+		t.Errorf("state = %q, want the-state", state)
+	}
+
+	idToken, err := provider.Exchange(code, "the-code-verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(idToken, "the-nonce")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "jane@example.com" || !claims.IsEmailVerified() {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestOIDCExchangeChecksPKCE(t *testing.T) {
+	mock, provider := newTestProvider(t)
+
+	authorizationURL, err := provider.AuthCodeURL("the-state", "the-nonce", "the-code-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	code, _, err := mock.Authorize(authorizationURL, jwt.MapClaims{"sub": "user-1"})
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	if _, err := provider.Exchange(code, "another-code-verifier"); err == nil {
+		t.Fatal("Exchange accepted a wrong code verifier")
+	}
+
+	// the code is single-use, even after a failed attempt
+	if _, err := provider.Exchange(code, "the-code-verifier"); err == nil {
+		t.Fatal("Exchange accepted a code already redeemed")
+	}
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	mock, provider := newTestProvider(t)
+
+	other, err := oidctest.NewProvider(testClientID)
+	if err != nil {
+		t.Fatalf("failed to start the mock provider: %v", err)
+	}
+	defer other.Close()
+
+	valid := jwt.MapClaims{"sub": "user-1", "nonce": "the-nonce"}
+
+	tests := []struct {
+		name   string
+		signer *oidctest.Provider
+		claims jwt.MapClaims
+		nonce  string
+	}{
+		{name: "wrong nonce", signer: mock, claims: valid, nonce: "another-nonce"},
+		{name: "no nonce", signer: mock, claims: jwt.MapClaims{"sub": "user-1"}, nonce: ""},
+		{name: "wrong issuer", signer: mock, claims: jwt.MapClaims{"sub": "user-1", "nonce": "the-nonce", "iss": other.Issuer()}, nonce: "the-nonce"},
+		{name: "wrong audience", signer: mock, claims: jwt.MapClaims{"sub": "user-1", "nonce": "the-nonce", "aud": "another-client"}, nonce: "the-nonce"},
+		{name: "expired", signer: mock, claims: jwt.MapClaims{"sub": "user-1", "nonce": "the-nonce", "exp": time.Now().Add(-time.Hour).Unix()}, nonce: "the-nonce"},
+		{name: "no subject", signer: mock, claims: jwt.MapClaims{"nonce": "the-nonce"}, nonce: "the-nonce"},
+		// same key id, but not the key published by the provider
+		{name: "foreign signature", signer: other, claims: jwt.MapClaims{"sub": "user-1", "nonce": "the-nonce", "iss": mock.Issuer()}, nonce: "the-nonce"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idToken, err := test.signer.SignIDToken(test.claims)
+			if err != nil {
+				t.Fatalf("SignIDToken: %v", err)
+			}
+
+			if _, err := provider.VerifyIDToken(idToken, test.nonce); err == nil {
+				t.Fatal("VerifyIDToken accepted the token")
+			}
+		})
+	}
+
+	idToken, err := mock.SignIDToken(valid)
+	if err != nil {
+		t.Fatalf("SignIDToken: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(idToken, "the-nonce"); err != nil {
+		t.Fatalf("VerifyIDToken rejected a valid token: %v", err)
+	}
+
+	// an algorithm other than the asymmetric ones of the provider is refused
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(forged, "the-nonce"); err == nil || !strings.Contains(err.Error(), "signing method") {
+		t.Fatalf("VerifyIDToken accepted an HS256 token: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package oidctest provides a mock OpenID Connect provider for the tests of the OIDC login.
+// It serves the discovery document, the signing keys (JWKS) and a token endpoint which
+// checks the PKCE code verifier, and issues ID tokens signed with an RSA key.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Provider is a mock OIDC provider running on a local HTTP server.
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+
+	key   *rsa.PrivateKey
+	keyID string
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+// authorization is an authorization code waiting to be redeemed at the token endpoint.
+type authorization struct {
+	redirectURI   string
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+// NewProvider starts a mock provider for the client id. Close it at the end of the test.
+func NewProvider(clientID string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &Provider{
+		ClientID: clientID,
+		key:      key,
+		keyID:    "test-key",
+		codes:    map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/jwks", provider.jwks)
+	mux.HandleFunc("/token", provider.token)
+	provider.Server = httptest.NewServer(mux)
+
+	return provider, nil
+}
+
+// Issuer returns the issuer URL of the provider.
+func (provider *Provider) Issuer() string {
+	return provider.Server.URL
+}
+
+// Close shuts the server down.
+func (provider *Provider) Close() {
+	provider.Server.Close()
+}
+
+// Authorize plays the user logging in at the provider: the authorization URL is checked the way
+// the authorization endpoint would, and the code and the state sent back to the callback are returned.
+// The claims describe the user, the ID token issued for the code carries them with the nonce of the URL.
+func (provider *Provider) Authorize(authorizationURL string, claims jwt.MapClaims) (string, string, error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !strings.HasPrefix(authorizationURL, provider.Issuer()+"/authorize") {
+		return "", "", fmt.Errorf("unexpected authorization endpoint: %s", parsed.Path)
+	}
+
+	query := parsed.Query()
+	switch {
+	case query.Get("response_type") != "code":
+		return "", "", fmt.Errorf("unexpected response_type: %s", query.Get("response_type"))
+	case query.Get("client_id") != provider.ClientID:
+		return "", "", fmt.Errorf("unexpected client_id: %s", query.Get("client_id"))
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return "", "", fmt.Errorf("missing S256 code challenge")
+	case query.Get("state") == "" || query.Get("nonce") == "":
+		return "", "", fmt.Errorf("missing state or nonce")
+	}
+
+	idClaims := jwt.MapClaims{}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+	idClaims["nonce"] = query.Get("nonce")
+
+	code := randomString()
+
+	provider.mu.Lock()
+	provider.codes[code] = authorization{
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		claims:        idClaims,
+	}
+	provider.mu.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+// SignIDToken signs an ID token with the key of the provider. The issuer, the audience
+// and the times are filled in when missing.
+func (provider *Provider) SignIDToken(claims jwt.MapClaims) (string, error) {
+	signed := jwt.MapClaims{
+		"iss": provider.Issuer(),
+		"aud": provider.ClientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for name, value := range claims {
+		signed[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, signed)
+	token.Header["kid"] = provider.keyID
+	return token.SignedString(provider.key)
+}
+
+func (provider *Provider) discovery(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"issuer":                 provider.Issuer(),
+		"authorization_endpoint": provider.Issuer() + "/authorize",
+		"token_endpoint":         provider.Issuer() + "/token",
+		"jwks_uri":               provider.Issuer() + "/jwks",
+	})
+}
+
+func (provider *Provider) jwks(writer http.ResponseWriter, request *http.Request) {
+	publicKey := provider.key.PublicKey
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": provider.keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems an authorization code, once, with the code verifier matching its challenge.
+func (provider *Provider) token(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost || request.ParseForm() != nil {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := request.PostForm.Get("code")
+
+	provider.mu.Lock()
+	pending, ok := provider.codes[code]
+	delete(provider.codes, code)
+	provider.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(request.PostForm.Get("code_verifier")))
+
+	switch {
+	case request.PostForm.Get("grant_type") != "authorization_code":
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+	case request.PostForm.Get("client_id") != provider.ClientID:
+		writeJSON(writer, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+	case !ok || request.PostForm.Get("redirect_uri") != pending.redirectURI:
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != pending.codeChallenge:
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+	default:
+		idToken, err := provider.SignIDToken(pending.claims)
+		if err != nil {
+			writeJSON(writer, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+			return
+		}
+		writeJSON(writer, http.StatusOK, map[string]string{
+			"access_token": randomString(),
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	}
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(value)
+}
+
+func randomString() string {
+	data := make([]byte, 16)
+	_, _ = rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,109 @@
+// Registry of the personal data held by the modules (GDPR export and erasure)
+package services
+
+import (
+	"archive/zip"
+	"e-commerce/shared/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalDataProvider exports and anonymises the personal data one module holds about a user.
+// Every module storing personal data registers a provider with RegisterPersonalDataProvider.
+type PersonalDataProvider interface {
+	// Name is the section of the export holding the data (e.g. "account", "addresses").
+	Name() string
+	// Export returns the personal data of the user, nil if the module holds none.
+	Export(userID uuid.UUID) (any, error)
+	// Anonymise removes the personal data of the user within the erasure transaction.
+	// Rows referenced by other records (e.g. the financial records) must be kept and only stripped of the personal data.
+	Anonymise(tx *gorm.DB, userID uuid.UUID) error
+}
+
+var personalDataProviders []PersonalDataProvider
+
+// RegisterPersonalDataProvider adds the provider of a module to the registry.
+// The providers are exported and anonymised in the order of registration.
+func RegisterPersonalDataProvider(provider PersonalDataProvider) {
+	personalDataProviders = append(personalDataProviders, provider)
+}
+
+// ExportPersonalData collects the personal data of the user from every registered provider.
+func ExportPersonalData(userID uuid.UUID) (models.PersonalDataExport, error) {
+	export := models.PersonalDataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now().UTC(),
+		Data:        make(map[string]any, len(personalDataProviders)),
+	}
+
+	for _, provider := range personalDataProviders {
+		data, err := provider.Export(userID)
+		if err != nil {
+			return models.PersonalDataExport{}, fmt.Errorf("failed to export the %s data: %w", provider.Name(), err)
+		}
+
+		if data != nil {
+			export.Data[provider.Name()] = data
+		}
+	}
+
+	return export, nil
+}
+
+// AnonymisePersonalData erases the personal data of the user with every registered provider
+// in a single transaction: either all the data is anonymised or nothing is.
+// Called with a transaction, the erasure becomes part of it (as a savepoint).
+func AnonymisePersonalData(db *gorm.DB, userID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, provider := range personalDataProviders {
+			if err := provider.Anonymise(tx, userID); err != nil {
+				return fmt.Errorf("failed to anonymise the %s data: %w", provider.Name(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// WritePersonalDataZip writes the export as a ZIP archive with one JSON file per section
+// and an export.json file describing the export.
+func WritePersonalDataZip(writer io.Writer, export models.PersonalDataExport) error {
+	archive := zip.NewWriter(writer)
+
+	writeJSON := func(name string, data any) error {
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	}
+
+	sections := make([]string, 0, len(export.Data))
+	for _, provider := range personalDataProviders {
+		if data, ok := export.Data[provider.Name()]; ok {
+			if err := writeJSON(provider.Name()+".json", data); err != nil {
+				return err
+			}
+			sections = append(sections, provider.Name())
+		}
+	}
+
+	manifest := map[string]any{
+		"user_id":      export.UserID,
+		"generated_at": export.GeneratedAt,
+		"sections":     sections,
+	}
+
+	if err := writeJSON("export.json", manifest); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,28 @@
+// Hooks of the modules on the lifecycle of the products
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ProductDeletedHook releases what a module holds for a deleted product (e.g. its stored images).
+type ProductDeletedHook func(productID uuid.UUID) error
+
+var productDeletedHooks []ProductDeletedHook
+
+// RegisterProductDeletedHook adds a hook run after every product deletion, in the order of registration.
+func RegisterProductDeletedHook(hook ProductDeletedHook) {
+	productDeletedHooks = append(productDeletedHooks, hook)
+}
+
+// ProductDeleted runs the registered hooks once the deletion of the product is committed.
+// The deletion is not undone by a failing hook, its error is only logged.
+func ProductDeleted(productID uuid.UUID) {
+	for _, hook := range productDeletedHooks {
+		if err := hook(productID); err != nil {
+			fmt.Printf("failed to clean up the deleted product %s: %v\n", productID, err)
+		}
+	}
+}
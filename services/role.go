@@ -0,0 +1,60 @@
+// Loading of the permissions granted to the roles
+package services
+
+import (
+	"context"
+	"e-commerce/database/connections"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	redisCache "github.com/redis/go-redis/v9"
+)
+
+// LoadRolePermissions returns the permission codes granted to the role.
+// The codes are cached in Redis for the same short time as the users.
+func LoadRolePermissions(roleID uuid.UUID) ([]string, error) {
+	ctx := context.Background()
+	cacheKey := constants.ROLE_PERMISSIONS_CACHE_PREFIX + roleID.String()
+
+	cached, err := GetRedisClient().Get(ctx, cacheKey).Result()
+	if err == nil {
+		var permissions []string
+		if err := json.Unmarshal([]byte(cached), &permissions); err == nil {
+			return permissions, nil
+		}
+	} else if err != redisCache.Nil {
+		fmt.Printf("failed to read role permissions cache for %s: %v\n", roleID, err)
+	}
+
+	permissions := []string{}
+	err = connections.GetDB().
+		Model(&models.Permission{}).
+		Joins("INNER JOIN role_permissions ON role_permissions.permission_id = permissions.permission_id").
+		Where("role_permissions.role_id = ?", roleID).
+		Pluck("permissions.code", &permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if userCacheTTL > 0 {
+		if data, err := json.Marshal(permissions); err == nil {
+			if err := GetRedisClient().Set(ctx, cacheKey, data, userCacheTTL).Err(); err != nil {
+				fmt.Printf("failed to cache role permissions for %s: %v\n", roleID, err)
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
+// InvalidateRolePermissionsCache drops the cached permissions of the role.
+// It must be called whenever the permissions of a role change.
+func InvalidateRolePermissionsCache(roleID uuid.UUID) {
+	ctx := context.Background()
+	if err := GetRedisClient().Del(ctx, constants.ROLE_PERMISSIONS_CACHE_PREFIX+roleID.String()).Err(); err != nil {
+		fmt.Printf("failed to invalidate role permissions cache for %s: %v\n", roleID, err)
+	}
+}
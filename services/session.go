@@ -1 +1,200 @@
+// Registry of the active sessions (devices) of the users
 package services
+
+import (
+	"context"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	redisCache "github.com/redis/go-redis/v9"
+)
+
+// A session is its refresh token family: the details are kept in the family hash,
+// so revoking the family (logout, refresh token reuse, logout-all) also ends the session.
+
+// the last seen time is written at most once per interval, not on every request
+const sessionTouchInterval = time.Minute
+
+// setIfExists updates the fields of a session only while it is alive,
+// a concurrent revocation must not recreate the hash without expiry.
+var setIfExists = redisCache.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("HSET", KEYS[1], unpack(ARGV))
+	return 1
+end
+return 0
+`)
+
+// RegisterSession records the device details of a session started for the user.
+// It must be called after the first refresh token of the family has been issued.
+func RegisterSession(sessionID string, client models.ClientInfo) error {
+	ctx := context.Background()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	return setIfExists.Run(ctx, GetRedisClient(), []string{constants.REFRESH_FAMILY_PREFIX + sessionID},
+		"device", DeviceName(client.UserAgent),
+		"user_agent", client.UserAgent,
+		"ip_address", client.IP,
+		"created_at", now,
+		"last_seen_at", now,
+	).Err()
+}
+
+// GetSession returns the session, or nil if it does not exist (anymore).
+func GetSession(sessionID string) (*models.Session, error) {
+	ctx := context.Background()
+
+	fields, err := GetRedisClient().HGetAll(ctx, constants.REFRESH_FAMILY_PREFIX+sessionID).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSession(sessionID, fields), nil
+}
+
+// TouchSession updates the last seen time and the IP address of the session.
+// Errors are only logged, a failed update must not fail the request.
+func TouchSession(session *models.Session, clientIP string) {
+	if time.Since(session.LastSeenAt) < sessionTouchInterval && session.IPAddress == clientIP {
+		return
+	}
+
+	ctx := context.Background()
+	err := setIfExists.Run(ctx, GetRedisClient(), []string{constants.REFRESH_FAMILY_PREFIX + session.SessionID},
+		"ip_address", clientIP,
+		"last_seen_at", time.Now().UTC().Format(time.RFC3339),
+	).Err()
+	if err != nil {
+		fmt.Printf("failed to update session %s: %v\n", session.SessionID, err)
+	}
+}
+
+// ListUserSessions returns the active sessions of the user, the most recently used first.
+func ListUserSessions(userID uuid.UUID) ([]models.Session, error) {
+	ctx := context.Background()
+	userSessionsKey := constants.USER_REFRESH_FAMILIES_PREFIX + userID.String()
+
+	sessionIDs, err := GetRedisClient().SMembers(ctx, userSessionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := GetRedisClient().Pipeline()
+	commands := make([]*redisCache.MapStringStringCmd, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		commands[i] = pipe.HGetAll(ctx, constants.REFRESH_FAMILY_PREFIX+sessionID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redisCache.Nil {
+		return nil, err
+	}
+
+	sessions := []models.Session{}
+	expired := []any{}
+	for i, sessionID := range sessionIDs {
+		session := parseSession(sessionID, commands[i].Val())
+		if session == nil || session.UserID != userID {
+			expired = append(expired, sessionID)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+
+	// the families expire on their own, their ids are dropped from the user set here
+	if len(expired) > 0 {
+		if err := GetRedisClient().SRem(ctx, userSessionsKey, expired...).Err(); err != nil {
+			fmt.Printf("failed to clean up the sessions of %s: %v\n", userID, err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// DeviceName returns a readable device name ("Chrome on Windows") for the user agent.
+func DeviceName(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "postmanruntime"):
+		browser = "Postman"
+	case strings.HasPrefix(ua, "curl/"):
+		browser = "curl"
+	case strings.HasPrefix(ua, "okhttp/"), strings.Contains(ua, "dalvik"):
+		browser = "Android app"
+	case strings.Contains(ua, "cfnetwork"):
+		browser = "iOS app"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(ua, "iphone"):
+		platform = "iPhone"
+	case strings.Contains(ua, "ipad"):
+		platform = "iPad"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os x") || strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "cros"):
+		platform = "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	if platform == "" {
+		return browser
+	}
+
+	return browser + " on " + platform
+}
+
+// parseSession builds the session from the family hash; nil for a missing family
+// or a family issued before the sessions were recorded.
+func parseSession(sessionID string, fields map[string]string) *models.Session {
+	if len(fields) == 0 || fields["created_at"] == "" {
+		return nil
+	}
+
+	userID, err := uuid.Parse(fields["user_id"])
+	if err != nil {
+		return nil
+	}
+
+	createdAt, _ := time.Parse(time.RFC3339, fields["created_at"])
+	lastSeenAt, _ := time.Parse(time.RFC3339, fields["last_seen_at"])
+
+	return &models.Session{
+		SessionID:  sessionID,
+		UserID:     userID,
+		Device:     fields["device"],
+		UserAgent:  fields["user_agent"],
+		IPAddress:  fields["ip_address"],
+		CreatedAt:  createdAt,
+		LastSeenAt: lastSeenAt,
+	}
+}
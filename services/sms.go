@@ -0,0 +1,133 @@
+// SMS notifications
+package services
+
+import (
+	"bytes"
+	"e-commerce/shared/models"
+	"e-commerce/utils/helper"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SMSSender sends a text message to a phone number in the E.164 format.
+// The message is the template filled with the arguments (fmt.Sprintf), kept apart
+// so a sender can leave the arguments, e.g. OTPs, out of its logs.
+type SMSSender interface {
+	SendSMS(phoneTo string, template string, args ...any) error
+}
+
+var SmsSender SMSSender
+
+// InitSmsSender sets up the SMS provider selected in the configuration.
+// Without a provider no SMS is sent and the phone verification is not available;
+// the log provider, which delivers nothing, is accepted in the local environment only.
+func InitSmsSender(config models.SmsConfig) error {
+	switch config.Provider {
+	case "":
+		SmsSender = nil
+	case "log":
+		if !helper.IsLocalEnv() {
+			return fmt.Errorf("the log sms provider is meant for local development only, configure the http provider")
+		}
+		SmsSender = &LogSMSSender{FilePath: config.LogFile}
+	case "http":
+		if config.URL == "" {
+			return fmt.Errorf("the url of the http sms provider is not configured")
+		}
+
+		timeout := time.Duration(config.TimeoutSec) * time.Second
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+
+		SmsSender = &HTTPSMSSender{
+			URL:    config.URL,
+			APIKey: os.Getenv(config.APIKeyEnv),
+			Sender: config.Sender,
+			client: &http.Client{Timeout: timeout},
+		}
+	default:
+		return fmt.Errorf("unknown sms provider %q, expects log or http", config.Provider)
+	}
+
+	return nil
+}
+
+// LogSMSSender writes the destination and the template of the messages to the console and,
+// if FilePath is set, appends them to the file. The arguments (OTPs) are never written.
+// Nothing is delivered; it is meant for local development and tests.
+type LogSMSSender struct {
+	FilePath string
+	mutex    sync.Mutex
+}
+
+func (s *LogSMSSender) SendSMS(phoneTo string, template string, args ...any) error {
+	line := fmt.Sprintf("%s SMS to %s, template: %q\n", time.Now().Format(time.RFC3339), phoneTo, template)
+	fmt.Print(line)
+
+	if s.FilePath == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.FilePath), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(line)
+	return err
+}
+
+// HTTPSMSSender posts the messages as JSON to an SMS gateway,
+// authenticated with the API key as a bearer token.
+type HTTPSMSSender struct {
+	URL    string
+	APIKey string
+	Sender string
+	client *http.Client
+}
+
+func (s *HTTPSMSSender) SendSMS(phoneTo string, template string, args ...any) error {
+	payload, err := json.Marshal(map[string]string{
+		"from":    s.Sender,
+		"to":      phoneTo,
+		"message": fmt.Sprintf(template, args...),
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		request.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send sms to %s: %w", phoneTo, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("failed to send sms to %s: the provider answered %s", phoneTo, response.Status)
+	}
+
+	return nil
+}
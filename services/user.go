@@ -0,0 +1,73 @@
+// Loading of the logged in user for every request
+package services
+
+import (
+	"context"
+	"e-commerce/database/connections"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	redisCache "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var userCacheTTL time.Duration
+
+// InitUserCache sets how long a loaded user is cached.
+// A short TTL keeps the cache from serving stale roles for long.
+func InitUserCache(ttlSeconds int) {
+	userCacheTTL = time.Duration(ttlSeconds) * time.Second
+}
+
+// LoadUser returns the current state of a verified, not deleted user with its Role.
+// The user is cached in Redis for a short time; nil is returned if there is no such user.
+func LoadUser(userID uuid.UUID) (*models.User, error) {
+	ctx := context.Background()
+	cacheKey := constants.USER_CACHE_PREFIX + userID.String()
+
+	cached, err := GetRedisClient().Get(ctx, cacheKey).Result()
+	if err == nil {
+		var user models.User
+		if err := json.Unmarshal([]byte(cached), &user); err == nil {
+			return &user, nil
+		}
+	} else if err != redisCache.Nil {
+		fmt.Printf("failed to read user cache for %s: %v\n", userID, err)
+	}
+
+	var user models.User
+	err = connections.GetDB().
+		Preload("Role").
+		Where("users.user_id = ? AND users.is_verified = true", userID).
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if userCacheTTL > 0 {
+		if data, err := json.Marshal(user); err == nil {
+			if err := GetRedisClient().Set(ctx, cacheKey, data, userCacheTTL).Err(); err != nil {
+				fmt.Printf("failed to cache user %s: %v\n", userID, err)
+			}
+		}
+	}
+
+	return &user, nil
+}
+
+// InvalidateUserCache drops the cached user, so the next request loads the current state.
+// It must be called whenever a user is updated or deleted.
+func InvalidateUserCache(userID uuid.UUID) {
+	ctx := context.Background()
+	if err := GetRedisClient().Del(ctx, constants.USER_CACHE_PREFIX+userID.String()).Err(); err != nil {
+		fmt.Printf("failed to invalidate user cache for %s: %v\n", userID, err)
+	}
+}
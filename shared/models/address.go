@@ -2,23 +2,25 @@ package models
 
 import (
 	"e-commerce/base"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Address struct {
 	base.BaseModel `swaggerignore:"true"`
-	AddressID      uuid.UUID   `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"address_id"`
-	UserID         uuid.UUID   `gorm:"not null"`
-	Street         string      `gorm:"size:255;not null"`
-	Street2        string      `gorm:"size:255"`
-	City           string      `gorm:"size:100;not null"`
-	State          string      `gorm:"size:100;not null"`
-	PostalCode     string      `gorm:"size:20;not null"`
-	Country        string      `gorm:"size:100;not null"`
-	AddressTypeID  uuid.UUID   `gorm:"not null"`
-	AddressType    AddressType `gorm:"foreignKey:AddressTypeID;references:AddressTypeID"`
-	IsPrimary      bool        `gorm:"default:false"`
+	AddressID      uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"address_id"`
+	// a user has at most one primary address per address type
+	UserID        uuid.UUID   `gorm:"not null;index:idx_addresses_primary,unique,where:is_primary = true AND deleted_at IS NULL"`
+	Street        string      `gorm:"size:255;not null"`
+	Street2       string      `gorm:"size:255"`
+	City          string      `gorm:"size:100;not null"`
+	State         string      `gorm:"size:100;not null"`
+	PostalCode    string      `gorm:"size:20;not null"`
+	Country       string      `gorm:"size:100;not null"`
+	AddressTypeID uuid.UUID   `gorm:"not null;index:idx_addresses_primary,unique,where:is_primary = true AND deleted_at IS NULL"`
+	AddressType   AddressType `gorm:"foreignKey:AddressTypeID;references:AddressTypeID"`
+	IsPrimary     bool        `gorm:"default:false"`
 }
 
 type AddressType struct {
@@ -28,3 +30,61 @@ type AddressType struct {
 	Code           string    `gorm:"not null;unique" json:"code"`
 	Description    string    `gorm:"size:255" json:"description"`
 }
+
+// AddressRequest creates or replaces an address of the logged in user.
+// The country is an ISO 3166-1 alpha-2 code, the postal code is checked against the format of the country.
+type AddressRequest struct {
+	AddressTypeID uuid.UUID `json:"address_type_id" validate:"required" example:"11740bb7-e585-43d4-baef-32a255d50a52"`
+	Street        string    `json:"street" validate:"required,max=255" example:"221B Baker Street"`
+	Street2       string    `json:"street2" validate:"max=255" example:"Flat 2"`
+	City          string    `json:"city" validate:"required,max=100" example:"London"`
+	State         string    `json:"state" validate:"required,max=100" example:"Greater London"`
+	PostalCode    string    `json:"postal_code" validate:"required,max=20" example:"NW1 6XE"`
+	Country       string    `json:"country" validate:"required,iso3166_1_alpha2" example:"GB"`
+	// the first address of a type is always primary
+	IsPrimary bool `json:"is_primary" example:"true"`
+} //@name AddressRequest
+
+type AddressResponse struct {
+	AddressID   uuid.UUID           `json:"address_id" example:"3f0c1a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	AddressType AddressTypeResponse `json:"address_type"`
+	Street      string              `json:"street" example:"221B Baker Street"`
+	Street2     string              `json:"street2,omitempty" example:"Flat 2"`
+	City        string              `json:"city" example:"London"`
+	State       string              `json:"state" example:"Greater London"`
+	PostalCode  string              `json:"postal_code" example:"NW1 6XE"`
+	Country     string              `json:"country" example:"GB"`
+	IsPrimary   bool                `json:"is_primary" example:"true"`
+	CreatedAt   time.Time           `json:"created_at" example:"2025-05-01T12:00:00Z"`
+	UpdatedAt   time.Time           `json:"updated_at" example:"2025-05-01T12:00:00Z"`
+} //@name AddressResponse
+
+type AddressTypeResponse struct {
+	AddressTypeID uuid.UUID `json:"address_type_id" example:"11740bb7-e585-43d4-baef-32a255d50a52"`
+	Name          string    `json:"name" example:"Home"`
+	Code          string    `json:"code" example:"HOME"`
+} //@name AddressTypeResponse
+
+func (addressType AddressType) ResponseObj() AddressTypeResponse {
+	return AddressTypeResponse{
+		AddressTypeID: addressType.AddressTypeID,
+		Name:          addressType.Name,
+		Code:          addressType.Code,
+	}
+}
+
+func (address Address) ResponseObj() AddressResponse {
+	return AddressResponse{
+		AddressID:   address.AddressID,
+		AddressType: address.AddressType.ResponseObj(),
+		Street:      address.Street,
+		Street2:     address.Street2,
+		City:        address.City,
+		State:       address.State,
+		PostalCode:  address.PostalCode,
+		Country:     address.Country,
+		IsPrimary:   address.IsPrimary,
+		CreatedAt:   address.CreatedAt,
+		UpdatedAt:   address.UpdatedAt,
+	}
+}
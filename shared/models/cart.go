@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CartConfig sets the lifetime of the guest carts and the size limits of the carts.
+type CartConfig struct {
+	// the guest carts are dropped after this time without change
+	GuestCartTTLHours int `json:"guest_cart_ttl_hours"`
+	MaxItems          int `json:"max_items"`
+	MaxItemQuantity   int `json:"max_item_quantity"`
+}
+
+// Cart is the cart of a logged-in user. The guest carts are kept in Redis under their cart token
+// and merged into the cart of the user on login.
+type Cart struct {
+	CartID    uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"cart_id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	Items     []CartItem `gorm:"foreignKey:CartID;references:CartID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartItem is a line of a cart: a quantity of a variant. The price is not stored, it is read
+// from the catalog whenever the cart is loaded.
+type CartItem struct {
+	CartID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	VariantID uuid.UUID `gorm:"type:uuid;primaryKey" json:"variant_id"`
+	Quantity  int64     `gorm:"not null;check:chk_cart_items_quantity,quantity > 0" json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CartItemRequest struct {
+	VariantID uuid.UUID `json:"variant_id" validate:"required" example:"b3d1a052-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Quantity  int64     `json:"quantity" validate:"required,gte=1" example:"2"`
+} //@name CartItemRequest
+
+type CartQuantityRequest struct {
+	Quantity int64 `json:"quantity" validate:"required,gte=1" example:"3"`
+} //@name CartQuantityRequest
+
+// CartResponse is a cart priced with the current prices of the catalog and checked against the current stock.
+type CartResponse struct {
+	// token of the guest cart, to send in the X-Cart-Token header; empty for the cart of a logged-in user
+	CartToken string             `json:"cart_token,omitempty" example:"Zk9xV2c3TjRyQmx6bUs2ZEh1cFd0Q0E"`
+	Items     []CartItemResponse `json:"items"`
+	// total quantity of the lines
+	ItemCount int64 `json:"item_count" example:"3"`
+	// sum of the available lines, in the minor unit of the currency
+	Subtotal int64  `json:"subtotal" example:"5997"`
+	Currency string `json:"currency,omitempty" example:"EUR"`
+} //@name CartResponse
+
+type CartItemResponse struct {
+	VariantID   uuid.UUID `json:"variant_id" example:"b3d1a052-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	ProductID   uuid.UUID `json:"product_id" example:"9a4e2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	SKU         string    `json:"sku" example:"TSHIRT-ORG-M-RED"`
+	ProductName string    `json:"product_name" example:"Organic Cotton T-Shirt"`
+	VariantName string    `json:"variant_name" example:"M / Red"`
+	Quantity    int64     `json:"quantity" example:"3"`
+	UnitPrice   int64     `json:"unit_price" example:"1999"`
+	Currency    string    `json:"currency" example:"EUR"`
+	LineTotal   int64     `json:"line_total" example:"5997"`
+	// stock available for the variant, across the warehouses
+	Available int64 `json:"available" example:"12"`
+	// available, insufficient_stock or unavailable (product withdrawn from sale)
+	Status string `json:"status" example:"available"`
+} //@name CartItemResponse
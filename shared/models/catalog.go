@@ -0,0 +1,290 @@
+package models
+
+import (
+	"e-commerce/base"
+	"e-commerce/utils/constants"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Category is a node of the category tree; the root categories have no parent.
+type Category struct {
+	base.BaseModel `swaggerignore:"true"`
+	CategoryID     uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"category_id"`
+	ParentID       *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	Parent         *Category  `gorm:"foreignKey:ParentID;references:CategoryID" json:"-"`
+	Name           string     `gorm:"size:100;not null" json:"name"`
+	Slug           string     `gorm:"size:120;not null;index:idx_categories_slug,unique,where:deleted_at IS NULL" json:"slug"`
+	Description    string     `gorm:"size:500" json:"description"`
+	// order of the category among its siblings
+	Position int `gorm:"not null;default:0" json:"position"`
+}
+
+// Product is sold through its variants; only the active products are visible in the public catalog.
+type Product struct {
+	base.BaseModel `swaggerignore:"true"`
+	ProductID      uuid.UUID               `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"product_id"`
+	CategoryID     uuid.UUID               `gorm:"type:uuid;not null;index" json:"category_id"`
+	Category       Category                `gorm:"foreignKey:CategoryID;references:CategoryID" json:"-"`
+	Name           string                  `gorm:"size:200;not null" json:"name"`
+	Slug           string                  `gorm:"size:220;not null;index:idx_products_slug,unique,where:deleted_at IS NULL" json:"slug"`
+	Description    string                  `gorm:"type:text" json:"description"`
+	Status         string                  `gorm:"size:20;not null;default:draft;index" json:"status"`
+	Variants       []ProductVariant        `gorm:"foreignKey:ProductID;references:ProductID" json:"-"`
+	Attributes     []ProductAttributeValue `gorm:"foreignKey:ProductID;references:ProductID" json:"-"`
+}
+
+// ProductVariant is a purchasable version of a product (e.g. size M in red) identified by its SKU.
+// The price is stored in the minor unit of the currency (e.g. cents) to avoid rounding errors.
+type ProductVariant struct {
+	base.BaseModel `swaggerignore:"true"`
+	VariantID      uuid.UUID       `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"variant_id"`
+	ProductID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"product_id"`
+	SKU            string          `gorm:"column:sku;size:64;not null;index:idx_product_variants_sku,unique,where:deleted_at IS NULL" json:"sku"`
+	Name           string          `gorm:"size:200" json:"name"`
+	Price          int64           `gorm:"not null" json:"price"`
+	Currency       string          `gorm:"size:3;not null" json:"currency"`
+	Position       int             `gorm:"not null;default:0" json:"position"`
+	Options        []VariantOption `gorm:"foreignKey:VariantID;references:VariantID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// VariantOption is an option value of a variant, e.g. size = M or colour = red.
+type VariantOption struct {
+	VariantID uuid.UUID `gorm:"type:uuid;primaryKey" json:"variant_id"`
+	Name      string    `gorm:"size:50;primaryKey" json:"name"`
+	Value     string    `gorm:"size:100;not null" json:"value"`
+}
+
+// Attribute is a typed property of the products of a category and of its subcategories (e.g. colour, weight),
+// used to describe the products and to filter the product lists. An attribute code is unique along a branch
+// of the category tree and has the same type wherever it is used.
+type Attribute struct {
+	base.BaseModel `swaggerignore:"true"`
+	AttributeID    uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"attribute_id"`
+	CategoryID     uuid.UUID `gorm:"type:uuid;not null;index:idx_attributes_code,unique,where:deleted_at IS NULL" json:"category_id"`
+	Category       Category  `gorm:"foreignKey:CategoryID;references:CategoryID" json:"-"`
+	Code           string    `gorm:"size:50;not null;index:idx_attributes_code,unique,where:deleted_at IS NULL" json:"code"`
+	Name           string    `gorm:"size:100;not null" json:"name"`
+	Type           string    `gorm:"size:20;not null" json:"type"`
+	// the allowed values of the enum attributes
+	Options      pq.StringArray `gorm:"type:text[]" json:"options"`
+	Unit         string         `gorm:"size:20" json:"unit"`
+	IsFilterable bool           `gorm:"not null" json:"is_filterable"`
+	Position     int            `gorm:"not null;default:0" json:"position"`
+}
+
+// ProductAttributeValue is the value of an attribute for a product, stored as text;
+// the values of the number attributes are also stored as numbers for the range filters and facets.
+type ProductAttributeValue struct {
+	ProductID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"product_id"`
+	AttributeID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"attribute_id"`
+	Attribute   Attribute `gorm:"foreignKey:AttributeID;references:AttributeID;constraint:OnDelete:CASCADE" json:"-"`
+	Value       string    `gorm:"size:255;not null" json:"value"`
+	NumberValue *float64  `json:"number_value"`
+}
+
+type CategoryRequest struct {
+	// empty for a root category
+	ParentID *uuid.UUID `json:"parent_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Name     string     `json:"name" validate:"required,max=100" example:"T-Shirts"`
+	// generated from the name when empty
+	Slug        string `json:"slug" validate:"omitempty,max=120" example:"t-shirts"`
+	Description string `json:"description" validate:"max=500" example:"Short and long sleeve t-shirts"`
+	Position    int    `json:"position" validate:"gte=0" example:"1"`
+} //@name CategoryRequest
+
+type ProductRequest struct {
+	CategoryID uuid.UUID `json:"category_id" validate:"required" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Name       string    `json:"name" validate:"required,max=200" example:"Organic Cotton T-Shirt"`
+	// generated from the name when empty
+	Slug        string `json:"slug" validate:"omitempty,max=220" example:"organic-cotton-t-shirt"`
+	Description string `json:"description" validate:"max=10000" example:"A soft t-shirt made of organic cotton."`
+	// draft when empty; a product needs at least one variant to be active
+	Status string `json:"status" validate:"omitempty,oneof=draft active archived" example:"draft"`
+} //@name ProductRequest
+
+type VariantRequest struct {
+	SKU  string `json:"sku" validate:"required,max=64" example:"TSHIRT-ORG-M-RED"`
+	Name string `json:"name" validate:"max=200" example:"M / Red"`
+	// in the minor unit of the currency, e.g. 1999 for 19.99 EUR
+	Price    int64             `json:"price" validate:"gte=0" example:"1999"`
+	Currency string            `json:"currency" validate:"required,iso4217" example:"EUR"`
+	Options  map[string]string `json:"options" validate:"dive,keys,required,max=50,endkeys,required,max=100" example:"size:M,colour:red"`
+	Position int               `json:"position" validate:"gte=0" example:"1"`
+} //@name VariantRequest
+
+type AttributeRequest struct {
+	CategoryID uuid.UUID `json:"category_id" validate:"required" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	// used in the filters of the product lists (attr.<code>)
+	Code string `json:"code" validate:"required,max=50" example:"colour"`
+	Name string `json:"name" validate:"required,max=100" example:"Colour"`
+	Type string `json:"type" validate:"required,oneof=enum number boolean text" example:"enum"`
+	// the allowed values, required for the enum attributes
+	Options      []string `json:"options" validate:"required_if=Type enum,dive,required,max=100" example:"red,blue"`
+	Unit         string   `json:"unit" validate:"max=20" example:""`
+	IsFilterable bool     `json:"is_filterable" example:"true"`
+	Position     int      `json:"position" validate:"gte=0" example:"1"`
+} //@name AttributeRequest
+
+// ProductAttributesRequest replaces the attribute values of a product, by attribute code.
+// The values are strings for the enum and text attributes, numbers for the number attributes
+// and booleans for the boolean attributes.
+type ProductAttributesRequest struct {
+	Attributes map[string]any `json:"attributes" swaggertype:"object" example:"colour:red"`
+} //@name ProductAttributesRequest
+
+type ProductQueryParams struct {
+	PageQuery `query:"-"`
+	// the products of the subcategories are included
+	CategoryID *uuid.UUID  `form:"category_id" query:"-"`
+	Name       *string     `form:"name" query:"ILIKE"`
+	Status     *FilterExpr `form:"status"`
+	// price of any variant of the product in the currency, in minor units (e.g. 1999 for 19.99 EUR)
+	Price *FilterExpr `form:"price" query:"-"`
+	// ISO 4217 code of the currency of the price filter and of the price facet, required by the price filter
+	Currency *string `form:"currency" json:"currency" query:"-" validate:"omitempty,iso4217"`
+	// attr.<code> filters, read from the query string by the handler
+	Attributes map[string]FilterExpr `form:"-" query:"-"`
+}
+
+type CategoryResponse struct {
+	CategoryID  uuid.UUID          `json:"category_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	ParentID    *uuid.UUID         `json:"parent_id,omitempty"`
+	Name        string             `json:"name" example:"T-Shirts"`
+	Slug        string             `json:"slug" example:"t-shirts"`
+	Description string             `json:"description" example:"Short and long sleeve t-shirts"`
+	Position    int                `json:"position" example:"1"`
+	Children    []CategoryResponse `json:"children,omitempty"`
+} //@name CategoryResponse
+
+type ProductResponse struct {
+	ProductID   uuid.UUID                  `json:"product_id" example:"9a4e2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	CategoryID  uuid.UUID                  `json:"category_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Name        string                     `json:"name" example:"Organic Cotton T-Shirt"`
+	Slug        string                     `json:"slug" example:"organic-cotton-t-shirt"`
+	Description string                     `json:"description" example:"A soft t-shirt made of organic cotton."`
+	Status      string                     `json:"status" example:"active"`
+	Variants    []VariantResponse          `json:"variants"`
+	Attributes  []ProductAttributeResponse `json:"attributes"`
+	CreatedAt   time.Time                  `json:"created_at" example:"2025-05-01T12:00:00Z"`
+	UpdatedAt   time.Time                  `json:"updated_at" example:"2025-05-01T12:00:00Z"`
+} //@name ProductResponse
+
+type VariantResponse struct {
+	VariantID uuid.UUID         `json:"variant_id" example:"b3d1a052-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	SKU       string            `json:"sku" example:"TSHIRT-ORG-M-RED"`
+	Name      string            `json:"name" example:"M / Red"`
+	Price     int64             `json:"price" example:"1999"`
+	Currency  string            `json:"currency" example:"EUR"`
+	Options   map[string]string `json:"options"`
+	Position  int               `json:"position" example:"1"`
+} //@name VariantResponse
+
+type AttributeResponse struct {
+	AttributeID  uuid.UUID `json:"attribute_id" example:"c5e2a052-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	CategoryID   uuid.UUID `json:"category_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Code         string    `json:"code" example:"colour"`
+	Name         string    `json:"name" example:"Colour"`
+	Type         string    `json:"type" example:"enum"`
+	Options      []string  `json:"options,omitempty" example:"red,blue"`
+	Unit         string    `json:"unit,omitempty" example:""`
+	IsFilterable bool      `json:"is_filterable" example:"true"`
+	Position     int       `json:"position" example:"1"`
+} //@name AttributeResponse
+
+type ProductAttributeResponse struct {
+	Code string `json:"code" example:"colour"`
+	Name string `json:"name" example:"Colour"`
+	Type string `json:"type" example:"enum"`
+	// a string, a number or a boolean depending on the type
+	Value any    `json:"value" swaggertype:"string" example:"red"`
+	Unit  string `json:"unit,omitempty" example:""`
+} //@name ProductAttributeResponse
+
+func (attribute Attribute) ResponseObj() AttributeResponse {
+	return AttributeResponse{
+		AttributeID:  attribute.AttributeID,
+		CategoryID:   attribute.CategoryID,
+		Code:         attribute.Code,
+		Name:         attribute.Name,
+		Type:         attribute.Type,
+		Options:      attribute.Options,
+		Unit:         attribute.Unit,
+		IsFilterable: attribute.IsFilterable,
+		Position:     attribute.Position,
+	}
+}
+
+func (value ProductAttributeValue) ResponseObj() ProductAttributeResponse {
+	response := ProductAttributeResponse{
+		Code:  value.Attribute.Code,
+		Name:  value.Attribute.Name,
+		Type:  value.Attribute.Type,
+		Value: value.Value,
+		Unit:  value.Attribute.Unit,
+	}
+
+	switch {
+	case value.NumberValue != nil:
+		response.Value = *value.NumberValue
+	case value.Attribute.Type == constants.ATTRIBUTE_TYPE_BOOLEAN:
+		response.Value = value.Value == "true"
+	}
+
+	return response
+}
+
+func (category Category) ResponseObj() CategoryResponse {
+	return CategoryResponse{
+		CategoryID:  category.CategoryID,
+		ParentID:    category.ParentID,
+		Name:        category.Name,
+		Slug:        category.Slug,
+		Description: category.Description,
+		Position:    category.Position,
+	}
+}
+
+func (product Product) ResponseObj() ProductResponse {
+	variants := make([]VariantResponse, 0, len(product.Variants))
+	for _, variant := range product.Variants {
+		variants = append(variants, variant.ResponseObj())
+	}
+
+	attributes := make([]ProductAttributeResponse, 0, len(product.Attributes))
+	for _, value := range product.Attributes {
+		attributes = append(attributes, value.ResponseObj())
+	}
+
+	return ProductResponse{
+		ProductID:   product.ProductID,
+		CategoryID:  product.CategoryID,
+		Name:        product.Name,
+		Slug:        product.Slug,
+		Description: product.Description,
+		Status:      product.Status,
+		Variants:    variants,
+		Attributes:  attributes,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+	}
+}
+
+func (variant ProductVariant) ResponseObj() VariantResponse {
+	options := make(map[string]string, len(variant.Options))
+	for _, option := range variant.Options {
+		options[option.Name] = option.Value
+	}
+
+	return VariantResponse{
+		VariantID: variant.VariantID,
+		SKU:       variant.SKU,
+		Name:      variant.Name,
+		Price:     variant.Price,
+		Currency:  variant.Currency,
+		Options:   options,
+		Position:  variant.Position,
+	}
+}
@@ -5,18 +5,35 @@ import (
 )
 
 type ConfigData struct {
-	DBConnection      DBConnection `json:"db_connection"`
-	Server            Server       `json:"server"`
-	RedisConnection   RedisConn    `json:"redis_connection"`
-	Logger            Logger       `json:"logger"`
-	RateLimit         RateLimit    `json:"rate_limit"`
-	SessionTimeOutmin int          `json:"session_timeout_min"`
-	OtpExpMin         int          `json:"otp_exp_min"`
-	PasswordLength    int          `json:"password_length"`
-	OTPLength         int          `json:"otp_length"`
-	SmtpServer        SmtpServer   `json:"smtp_server"`
-	AllowedOrigins    []string     `json:"allowed_origins"`
-	AllowedMethods    []string     `json:"allowed_methods"`
+	DBConnection             DBConnection     `json:"db_connection"`
+	Server                   Server           `json:"server"`
+	RedisConnection          RedisConn        `json:"redis_connection"`
+	Logger                   Logger           `json:"logger"`
+	RateLimit                RateLimit        `json:"rate_limit"`
+	SessionTimeOutmin        int              `json:"session_timeout_min"`
+	RefreshTokenExpMin       int              `json:"refresh_token_exp_min"`
+	UserCacheTTLSec          int              `json:"user_cache_ttl_sec"`
+	Jwt                      JwtConfig        `json:"jwt"`
+	OtpExpMin                int              `json:"otp_exp_min"`
+	PasswordHashCost         int              `json:"password_hash_cost"`
+	PasswordPolicy           PasswordPolicy   `json:"password_policy"`
+	SetPasswordTokenExpMin   int              `json:"set_password_token_exp_min"`
+	ResetPasswordMaxAttempts int              `json:"reset_password_max_attempts"`
+	AccountDeletionGraceDays int              `json:"account_deletion_grace_days"`
+	LoginProtection          LoginProtection  `json:"login_protection"`
+	TwoFactor                TwoFactor        `json:"two_factor"`
+	OIDCProviders            []OIDCProvider   `json:"oidc_providers"`
+	AppBaseURL               string           `json:"app_base_url"`
+	DefaultPhoneCountryCode  string           `json:"default_phone_country_code"`
+	Sms                      SmsConfig        `json:"sms"`
+	Pagination               PaginationConfig `json:"pagination"`
+	Media                    MediaConfig      `json:"media"`
+	Inventory                InventoryConfig  `json:"inventory"`
+	Cart                     CartConfig       `json:"cart"`
+	OTPLength                int              `json:"otp_length"`
+	SmtpServer               SmtpServer       `json:"smtp_server"`
+	AllowedOrigins           []string         `json:"allowed_origins"`
+	AllowedMethods           []string         `json:"allowed_methods"`
 }
 
 func (dbConnection *DBConnection) GetDBConnectionString() string {
@@ -62,11 +79,87 @@ type system struct {
 	FilenamePrefix string `json:"filename_prefix"`
 }
 
+// LoginProtection configures the brute force protection of the login and OTP endpoints.
+type LoginProtection struct {
+	// failed attempts of one email before the account is locked
+	MaxFailedAttempts int `json:"max_failed_attempts"`
+	// failed attempts from one IP (any email) before the IP is blocked
+	MaxFailedAttemptsPerIP int `json:"max_failed_attempts_per_ip"`
+	// how long the failures are remembered
+	FailureWindowMin int `json:"failure_window_min"`
+	// how long a locked account or a blocked IP stays locked
+	LockoutMin int `json:"lockout_min"`
+	// the delay before the next attempt doubles after every failure, starting from the base
+	BackoffBaseSec int `json:"backoff_base_sec"`
+	BackoffMaxSec  int `json:"backoff_max_sec"`
+	// wrong guesses of an OTP before the OTP is invalidated
+	OtpMaxAttempts int `json:"otp_max_attempts"`
+	// minimum time between two verification OTP emails
+	OtpResendCooldownSec int `json:"otp_resend_cooldown_sec"`
+}
+
+// TwoFactor configures the TOTP based two-factor authentication.
+type TwoFactor struct {
+	// issuer shown by the authenticator apps
+	Issuer string `json:"issuer"`
+	// lifetime of the challenge token returned by the login when 2FA is enabled
+	ChallengeExpMin   int `json:"challenge_exp_min"`
+	RecoveryCodeCount int `json:"recovery_code_count"`
+	// file holding the base64 AES-256 key used to encrypt the TOTP secrets,
+	// used when the TWO_FACTOR_ENCRYPTION_KEY environment variable is not set
+	EncryptionKeyFile string `json:"encryption_key_file"`
+}
+
+// PaginationConfig sets the page sizes of the list endpoints.
+type PaginationConfig struct {
+	DefaultLimit int `json:"default_limit"`
+	// larger limits requested by the clients are capped to this value
+	MaxLimit int `json:"max_limit"`
+}
+
+// OIDCProvider configures an OpenID Connect provider users can login with.
+type OIDCProvider struct {
+	// name used in the login URLs (/auth/oidc/<name>/login)
+	Name string `json:"name"`
+	// issuer URL, the discovery document is read from <issuer>/.well-known/openid-configuration
+	IssuerURL string `json:"issuer_url"`
+	ClientID  string `json:"client_id"`
+	// environment variable holding the client secret
+	ClientSecretEnv string   `json:"client_secret_env"`
+	RedirectURL     string   `json:"redirect_url"`
+	Scopes          []string `json:"scopes"`
+}
+
 type RateLimit struct {
 	MaxRequest int `json:"max_requests"`
 	Duration   int `json:"duration_in_minute"`
 }
 
+type JwtConfig struct {
+	Issuer             string   `json:"issuer"`
+	RotationOverlapMin int      `json:"rotation_overlap_min"`
+	Keys               []JwtKey `json:"keys"`
+}
+
+// JwtKey is a signing key; it becomes the signing key at ActiveFrom (RFC3339)
+// and the previous key keeps verifying tokens for the rotation overlap window.
+type JwtKey struct {
+	KeyID          string `json:"kid"`
+	Algorithm      string `json:"algorithm"`
+	PrivateKeyFile string `json:"private_key_file"`
+	ActiveFrom     string `json:"active_from"`
+}
+
+type PasswordPolicy struct {
+	MinLength      int    `json:"min_length"`
+	MaxLength      int    `json:"max_length"`
+	RequireUpper   bool   `json:"require_upper"`
+	RequireLower   bool   `json:"require_lower"`
+	RequireDigit   bool   `json:"require_digit"`
+	RequireSpecial bool   `json:"require_special"`
+	BlocklistFile  string `json:"blocklist_file"`
+}
+
 type RedisConn struct {
 	Address string `json:"address"`
 	DB      int    `json:"db"`
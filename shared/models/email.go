@@ -1,5 +1,7 @@
 package models
 
+import "github.com/google/uuid"
+
 type EmailOTPRequest struct {
 	Email string `json:"email" validate:"required,email" example:"john.doe@gmail.com"`
 	OTP   string `json:"otp" validate:"required" example:"123456"`
@@ -11,5 +13,21 @@ type ResendEmailRequest struct {
 
 type EmailVerificationResponse struct {
 	Success bool   `json:"success" example:"true"`
-	Message string `json:"message" example:"Your email has been successfully verified! We've sent your login credentials to your registered email address. Please check your inbox to proceed."`
+	Message string `json:"message" example:"Your email has been successfully verified! You can now login with your email and password."`
 } //@name EmailVerificationResponse
+
+// ChangeEmailRequest starts the change of the email of the logged in user.
+// The password is required; users signing in only with an external provider
+// confirm with the OTP sent by POST /user/me/reauthentication instead.
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email" example:"john.new@gmail.com"`
+	Password string `json:"password" example:"S3cure#Passw0rd"`
+	OTP      string `json:"otp" example:"123456"`
+} //@name ChangeEmailRequest
+
+// PendingEmailChange is an email change waiting for the OTP sent to the new address.
+type PendingEmailChange struct {
+	UserID   uuid.UUID `json:"user_id"`
+	OldEmail string    `json:"old_email"`
+	OTP      string    `json:"otp"`
+}
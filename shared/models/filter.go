@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterOperators are the operators of the filter expressions with the number of values they take (0 for one or more).
+var filterOperators = map[string]int{
+	"eq":      1,
+	"ne":      1,
+	"gt":      1,
+	"gte":     1,
+	"lt":      1,
+	"lte":     1,
+	"like":    1,
+	"in":      0,
+	"between": 2,
+}
+
+// maxFilterValues caps the number of values of an "in" filter.
+const maxFilterValues = 100
+
+// FilterExpr is a filter given in the query string as "<operator>:<values>", e.g. "in:red,blue",
+// "between:10,50" or "gte:5"; a value without a known operator is an equality ("red" is "eq:red").
+// The expression only carries the values: the column it applies to is always chosen by the code.
+type FilterExpr struct {
+	Op     string
+	Values []string
+}
+
+// ParseFilterExpr parses a filter expression of the query string.
+func ParseFilterExpr(raw string) (FilterExpr, error) {
+	expr := FilterExpr{Op: "eq", Values: []string{raw}}
+
+	if op, values, found := strings.Cut(raw, ":"); found {
+		if _, ok := filterOperators[strings.ToLower(op)]; ok {
+			expr.Op = strings.ToLower(op)
+			expr.Values = []string{values}
+
+			if expr.Op == "in" || expr.Op == "between" {
+				expr.Values = strings.Split(values, ",")
+			}
+		}
+	}
+
+	for i, value := range expr.Values {
+		expr.Values[i] = strings.TrimSpace(value)
+		if expr.Values[i] == "" {
+			return FilterExpr{}, fmt.Errorf("the filter %q has an empty value", raw)
+		}
+	}
+
+	if count := filterOperators[expr.Op]; count > 0 && len(expr.Values) != count {
+		return FilterExpr{}, fmt.Errorf("the %s filter expects %d values, got %q", expr.Op, count, raw)
+	}
+
+	if len(expr.Values) > maxFilterValues {
+		return FilterExpr{}, fmt.Errorf("the %s filter accepts at most %d values", expr.Op, maxFilterValues)
+	}
+
+	return expr, nil
+}
+
+// UnmarshalParam binds the filter expressions of the query parameters (gin binding).
+func (expr *FilterExpr) UnmarshalParam(param string) error {
+	parsed, err := ParseFilterExpr(param)
+	if err != nil {
+		return err
+	}
+
+	*expr = parsed
+	return nil
+}
+
+// Args returns the values as query arguments.
+func (expr FilterExpr) Args() []any {
+	args := make([]any, 0, len(expr.Values))
+	for _, value := range expr.Values {
+		args = append(args, value)
+	}
+	return args
+}
+
+// Numbers returns the values as numbers, for the filters on the numeric columns.
+func (expr FilterExpr) Numbers() ([]any, error) {
+	args := make([]any, 0, len(expr.Values))
+	for _, value := range expr.Values {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		args = append(args, number)
+	}
+	return args, nil
+}
+
+// Integers returns the values as integers, for the filters on the integer columns (e.g. the prices in minor units).
+func (expr FilterExpr) Integers() ([]any, error) {
+	args := make([]any, 0, len(expr.Values))
+	for _, value := range expr.Values {
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		args = append(args, number)
+	}
+	return args, nil
+}
+
+// Facet counts the products of a list by the values of an attribute (enum and boolean attributes)
+// or gives the range of the values (number attributes and the price).
+type Facet struct {
+	Code string `json:"code" example:"colour"`
+	Name string `json:"name" example:"Colour"`
+	Type string `json:"type" example:"enum"`
+	Unit string `json:"unit,omitempty" example:"kg"`
+	// currency of a price facet, whose range is in the minor unit of the currency
+	Currency string       `json:"currency,omitempty" example:"EUR"`
+	Values   []FacetValue `json:"values,omitempty"`
+	Min      *float64     `json:"min,omitempty" example:"10"`
+	Max      *float64     `json:"max,omitempty" example:"50"`
+} //@name Facet
+
+type FacetValue struct {
+	Value string `json:"value" example:"red"`
+	Count int64  `json:"count" example:"12"`
+} //@name FacetValue
+
+// ResponseWithFacets is a page of a list with the facets of the whole list.
+type ResponseWithFacets struct {
+	ResponseWithPagination
+	Facets []Facet `json:"facets"`
+}
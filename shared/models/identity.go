@@ -0,0 +1,37 @@
+package models
+
+import (
+	"e-commerce/base"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links an external (OIDC) identity to a user.
+// The identity is identified by the provider name and the subject (sub) of its ID tokens.
+type UserIdentity struct {
+	base.BaseModel `swaggerignore:"true"`
+	UserIdentityID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;unique" json:"user_identity_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User           User      `gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Provider       string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject        string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+	Email          string    `json:"email"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCLoginResponse carries the provider URL the user has to be redirected to.
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.google.com/o/oauth2/v2/auth?client_id=..."`
+} //@name OIDCLoginResponse
+
+// OIDCState is the pending OIDC login stored in Redis under its state.
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	// token of the guest cart, merged into the cart of the user once logged in
+	CartToken string `json:"cart_token,omitempty"`
+}
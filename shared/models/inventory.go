@@ -0,0 +1,223 @@
+package models
+
+import (
+	"e-commerce/base"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InventoryConfig sets the lifetime of the stock reservations and the default low stock threshold.
+type InventoryConfig struct {
+	// how long a reservation holds the stock when no other lifetime is requested
+	ReservationTTLMin int `json:"reservation_ttl_min"`
+	// the reservations can not hold the stock longer than this
+	MaxReservationTTLMin int `json:"max_reservation_ttl_min"`
+	// stock levels at or below this available quantity are low, unless they have their own threshold
+	LowStockThreshold int `json:"low_stock_threshold"`
+}
+
+// Warehouse is a location holding stock; the inactive warehouses take no new reservation.
+type Warehouse struct {
+	base.BaseModel `swaggerignore:"true"`
+	WarehouseID    uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"warehouse_id"`
+	Code           string    `gorm:"size:20;not null;index:idx_warehouses_code,unique,where:deleted_at IS NULL" json:"code"`
+	Name           string    `gorm:"size:100;not null" json:"name"`
+	IsActive       bool      `gorm:"not null" json:"is_active"`
+}
+
+// StockLevel is the stock of a variant (SKU) in a warehouse. The reserved quantity is held by the active
+// reservations; the available quantity is the stock on hand minus the reserved quantity.
+type StockLevel struct {
+	StockLevelID uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"stock_level_id"`
+	VariantID    uuid.UUID      `gorm:"type:uuid;not null;index:idx_stock_levels_variant_warehouse,unique" json:"variant_id"`
+	Variant      ProductVariant `gorm:"foreignKey:VariantID;references:VariantID" json:"-"`
+	WarehouseID  uuid.UUID      `gorm:"type:uuid;not null;index:idx_stock_levels_variant_warehouse,unique;index" json:"warehouse_id"`
+	Warehouse    Warehouse      `gorm:"foreignKey:WarehouseID;references:WarehouseID" json:"-"`
+	OnHand       int64          `gorm:"not null;default:0;check:chk_stock_levels_on_hand,on_hand >= 0" json:"on_hand"`
+	Reserved     int64          `gorm:"not null;default:0;check:chk_stock_levels_reserved,reserved >= 0 AND reserved <= on_hand" json:"reserved"`
+	// overrides the default low stock threshold of the configuration
+	LowStockThreshold *int64    `json:"low_stock_threshold"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// StockReservation holds a quantity of a variant in a warehouse, during a checkout, until it is committed
+// (the order is placed and the stock leaves the warehouse), released or expired.
+// A reference (e.g. the id of the checkout) groups the reservations of the items of a checkout.
+type StockReservation struct {
+	ReservationID uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"reservation_id"`
+	Reference     string         `gorm:"size:100;not null;index" json:"reference"`
+	VariantID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"variant_id"`
+	Variant       ProductVariant `gorm:"foreignKey:VariantID;references:VariantID" json:"-"`
+	WarehouseID   uuid.UUID      `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Quantity      int64          `gorm:"not null;check:chk_stock_reservations_quantity,quantity > 0" json:"quantity"`
+	Status        string         `gorm:"size:20;not null;index" json:"status"`
+	ExpiresAt     time.Time      `gorm:"not null;index" json:"expires_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// StockMovement is an entry of the append-only stock ledger: a change of the stock on hand of a variant
+// in a warehouse (receipt, adjustment, sale or return), with the stock on hand after the change.
+// The entries are never updated nor deleted.
+type StockMovement struct {
+	MovementID  uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"movement_id"`
+	VariantID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"variant_id"`
+	Variant     ProductVariant `gorm:"foreignKey:VariantID;references:VariantID" json:"-"`
+	WarehouseID uuid.UUID      `gorm:"type:uuid;not null;index" json:"warehouse_id"`
+	Type        string         `gorm:"size:20;not null" json:"type"`
+	// signed change of the stock on hand
+	Quantity    int64      `gorm:"not null" json:"quantity"`
+	OnHandAfter int64      `gorm:"not null" json:"on_hand_after"`
+	Reference   string     `gorm:"size:100;index" json:"reference"`
+	Note        string     `gorm:"size:255" json:"note"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+}
+
+type WarehouseRequest struct {
+	Code     string `json:"code" validate:"required,max=20" example:"PAR-1"`
+	Name     string `json:"name" validate:"required,max=100" example:"Paris North"`
+	IsActive bool   `json:"is_active" example:"true"`
+} //@name WarehouseRequest
+
+// StockAdjustmentRequest changes the stock on hand of a SKU in a warehouse. The quantity of a receipt or a return
+// is added to the stock; the quantity of an adjustment is signed (e.g. -2 for two damaged items).
+type StockAdjustmentRequest struct {
+	SKU         string    `json:"sku" validate:"required,max=64" example:"TSHIRT-RED-M"`
+	WarehouseID uuid.UUID `json:"warehouse_id" validate:"required" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Type        string    `json:"type" validate:"required,oneof=receipt adjustment return" example:"receipt"`
+	Quantity    int64     `json:"quantity" validate:"required" example:"25"`
+	Reference   string    `json:"reference" validate:"max=100" example:"PO-2025-0042"`
+	Note        string    `json:"note" validate:"max=255" example:"Spring delivery"`
+} //@name StockAdjustmentRequest
+
+// StockThresholdRequest sets the low stock threshold of a SKU in a warehouse; null restores the default threshold.
+type StockThresholdRequest struct {
+	SKU               string    `json:"sku" validate:"required,max=64" example:"TSHIRT-RED-M"`
+	WarehouseID       uuid.UUID `json:"warehouse_id" validate:"required" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	LowStockThreshold *int64    `json:"low_stock_threshold" validate:"omitempty,gte=0" example:"10"`
+} //@name StockThresholdRequest
+
+// ReservationRequest reserves the items of a checkout; either all the items are reserved or none.
+type ReservationRequest struct {
+	Reference string            `json:"reference" validate:"required,max=100" example:"checkout-7c1b2a52"`
+	Items     []ReservationItem `json:"items" validate:"required,min=1,max=100,dive"`
+	// lifetime of the reservations in minutes, the configured default when not given
+	TTLMin int `json:"ttl_min" validate:"gte=0" example:"15"`
+} //@name ReservationRequest
+
+type ReservationItem struct {
+	SKU      string `json:"sku" validate:"required,max=64" example:"TSHIRT-RED-M"`
+	Quantity int64  `json:"quantity" validate:"required,gte=1" example:"2"`
+} //@name ReservationItem
+
+type LowStockQueryParams struct {
+	PageQuery   `query:"-"`
+	WarehouseID *uuid.UUID `form:"warehouse_id" query:"-"`
+}
+
+type StockMovementQueryParams struct {
+	PageQuery   `query:"-"`
+	SKU         *string    `form:"sku" query:"-"`
+	WarehouseID *uuid.UUID `form:"warehouse_id"`
+	Type        *string    `form:"type"`
+	Reference   *string    `form:"reference"`
+}
+
+type WarehouseResponse struct {
+	WarehouseID uuid.UUID `json:"warehouse_id" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Code        string    `json:"code" example:"PAR-1"`
+	Name        string    `json:"name" example:"Paris North"`
+	IsActive    bool      `json:"is_active" example:"true"`
+} //@name WarehouseResponse
+
+type StockLevelResponse struct {
+	SKU           string    `json:"sku" example:"TSHIRT-RED-M"`
+	VariantID     uuid.UUID `json:"variant_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	WarehouseID   uuid.UUID `json:"warehouse_id" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	WarehouseCode string    `json:"warehouse_code" example:"PAR-1"`
+	OnHand        int64     `json:"on_hand" example:"25"`
+	Reserved      int64     `json:"reserved" example:"3"`
+	Available     int64     `json:"available" example:"22"`
+	// the threshold of the stock level, null when the default threshold applies
+	LowStockThreshold *int64    `json:"low_stock_threshold" example:"10"`
+	UpdatedAt         time.Time `json:"updated_at" example:"2025-01-01T12:00:00Z"`
+} //@name StockLevelResponse
+
+type StockMovementResponse struct {
+	MovementID  uuid.UUID  `json:"movement_id" example:"9a1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	SKU         string     `json:"sku" example:"TSHIRT-RED-M"`
+	VariantID   uuid.UUID  `json:"variant_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	WarehouseID uuid.UUID  `json:"warehouse_id" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Type        string     `json:"type" example:"receipt"`
+	Quantity    int64      `json:"quantity" example:"25"`
+	OnHandAfter int64      `json:"on_hand_after" example:"25"`
+	Reference   string     `json:"reference" example:"PO-2025-0042"`
+	Note        string     `json:"note" example:"Spring delivery"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-01-01T12:00:00Z"`
+} //@name StockMovementResponse
+
+type ReservationResponse struct {
+	ReservationID uuid.UUID `json:"reservation_id" example:"8d1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Reference     string    `json:"reference" example:"checkout-7c1b2a52"`
+	SKU           string    `json:"sku" example:"TSHIRT-RED-M"`
+	VariantID     uuid.UUID `json:"variant_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	WarehouseID   uuid.UUID `json:"warehouse_id" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	Quantity      int64     `json:"quantity" example:"2"`
+	Status        string    `json:"status" example:"active"`
+	ExpiresAt     time.Time `json:"expires_at" example:"2025-01-01T12:15:00Z"`
+} //@name ReservationResponse
+
+func (warehouse Warehouse) ResponseObj() WarehouseResponse {
+	return WarehouseResponse{
+		WarehouseID: warehouse.WarehouseID,
+		Code:        warehouse.Code,
+		Name:        warehouse.Name,
+		IsActive:    warehouse.IsActive,
+	}
+}
+
+func (level StockLevel) ResponseObj() StockLevelResponse {
+	return StockLevelResponse{
+		SKU:               level.Variant.SKU,
+		VariantID:         level.VariantID,
+		WarehouseID:       level.WarehouseID,
+		WarehouseCode:     level.Warehouse.Code,
+		OnHand:            level.OnHand,
+		Reserved:          level.Reserved,
+		Available:         level.OnHand - level.Reserved,
+		LowStockThreshold: level.LowStockThreshold,
+		UpdatedAt:         level.UpdatedAt,
+	}
+}
+
+func (movement StockMovement) ResponseObj() StockMovementResponse {
+	return StockMovementResponse{
+		MovementID:  movement.MovementID,
+		SKU:         movement.Variant.SKU,
+		VariantID:   movement.VariantID,
+		WarehouseID: movement.WarehouseID,
+		Type:        movement.Type,
+		Quantity:    movement.Quantity,
+		OnHandAfter: movement.OnHandAfter,
+		Reference:   movement.Reference,
+		Note:        movement.Note,
+		CreatedBy:   movement.CreatedBy,
+		CreatedAt:   movement.CreatedAt,
+	}
+}
+
+func (reservation StockReservation) ResponseObj() ReservationResponse {
+	return ReservationResponse{
+		ReservationID: reservation.ReservationID,
+		Reference:     reservation.Reference,
+		SKU:           reservation.Variant.SKU,
+		VariantID:     reservation.VariantID,
+		WarehouseID:   reservation.WarehouseID,
+		Quantity:      reservation.Quantity,
+		Status:        reservation.Status,
+		ExpiresAt:     reservation.ExpiresAt,
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"e-commerce/base"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// MediaConfig selects the object store of the uploaded files and sets the limits of the image uploads.
+type MediaConfig struct {
+	// "local" (local development and tests, the files are written to LocalDir) or "s3"
+	Provider string `json:"provider"`
+	LocalDir string `json:"local_dir"`
+	// local store: base URL of this service, the signed URLs point to <base_url>/media/files/<key>
+	PublicBaseURL string `json:"public_base_url"`
+	// local store: name of the environment variable holding the key signing the URLs
+	// (a random key is used when it is not set, so the URLs do not survive a restart)
+	SigningKeyEnv string `json:"signing_key_env"`
+	// s3 store: the bucket should not be public, the files are served with presigned URLs
+	Bucket          string `json:"bucket"`
+	Region          string `json:"region"`
+	SignedURLExpMin int    `json:"signed_url_exp_min"`
+	MaxUploadMB     int    `json:"max_upload_mb"`
+	// larger images are refused before being decoded (width x height)
+	MaxPixels int `json:"max_pixels"`
+	// the thumbnails fit in squares of these sizes, in pixels
+	ThumbnailSizes []int `json:"thumbnail_sizes"`
+}
+
+// ProductImage is an image of a product, stored in the object store with its thumbnails.
+type ProductImage struct {
+	base.BaseModel `swaggerignore:"true"`
+	ImageID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"image_id"`
+	ProductID      uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	// key of the original image in the object store, the thumbnails are stored next to it
+	ObjectKey   string `gorm:"size:255;not null" json:"-"`
+	ContentType string `gorm:"size:50;not null" json:"content_type"`
+	Size        int64  `gorm:"not null" json:"size"`
+	Width       int    `gorm:"not null" json:"width"`
+	Height      int    `gorm:"not null" json:"height"`
+	AltText     string `gorm:"size:255" json:"alt_text"`
+	// order of the image among the images of the product, the first one is the main image
+	Position int `gorm:"not null;default:0" json:"position"`
+	// sizes of the generated thumbnails (the sizes larger than the image are not generated)
+	ThumbnailSizes pq.Int64Array `gorm:"type:integer[]" json:"-"`
+}
+
+// ImageOrderRequest sets the order of the images of a product; all the images must be listed.
+type ImageOrderRequest struct {
+	ImageIDs []uuid.UUID `json:"image_ids" validate:"required,min=1,unique" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01,7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+} //@name ImageOrderRequest
+
+type ProductImageResponse struct {
+	ImageID     uuid.UUID `json:"image_id" example:"5b0d3a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	ProductID   uuid.UUID `json:"product_id" example:"7c1b2a52-1a7e-4d0e-9d0c-3f1b6f6a8e01"`
+	ContentType string    `json:"content_type" example:"image/jpeg"`
+	Size        int64     `json:"size" example:"482113"`
+	Width       int       `json:"width" example:"2000"`
+	Height      int       `json:"height" example:"1500"`
+	AltText     string    `json:"alt_text" example:"Red t-shirt, front"`
+	Position    int       `json:"position" example:"0"`
+	// signed URL of the original image, valid until URLExpiresAt
+	URL          string              `json:"url" example:"https://bucket.s3.amazonaws.com/products/7c1b2a52/5b0d3a52/original.jpg?X-Amz-Signature=..."`
+	Thumbnails   []ThumbnailResponse `json:"thumbnails"`
+	URLExpiresAt time.Time           `json:"url_expires_at" example:"2025-01-01T12:00:00Z"`
+} //@name ProductImageResponse
+
+type ThumbnailResponse struct {
+	Size int    `json:"size" example:"480"`
+	URL  string `json:"url" example:"https://bucket.s3.amazonaws.com/products/7c1b2a52/5b0d3a52/480.jpg?X-Amz-Signature=..."`
+} //@name ThumbnailResponse
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalDataExport is everything the application holds about a user (GDPR data export),
+// by section (e.g. "account", "addresses"), as collected from the registered providers.
+type PersonalDataExport struct {
+	UserID      uuid.UUID      `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	GeneratedAt time.Time      `json:"generated_at" example:"2025-05-01T12:00:00Z"`
+	Data        map[string]any `json:"data"`
+} //@name PersonalDataExport
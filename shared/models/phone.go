@@ -0,0 +1,24 @@
+package models
+
+type PhoneOTPRequest struct {
+	OTP string `json:"otp" validate:"required" example:"123456"`
+} //@name PhoneOTPRequest
+
+// PendingPhoneVerification is the OTP sent by SMS, bound to the phone number it was sent to.
+type PendingPhoneVerification struct {
+	Phone string `json:"phone"`
+	OTP   string `json:"otp"`
+}
+
+// SmsConfig selects and configures the SMS provider.
+type SmsConfig struct {
+	// "log" (local development, the messages are written to LogFile) or "http"
+	Provider string `json:"provider"`
+	LogFile  string `json:"log_file"`
+	// HTTP provider: the messages are posted as JSON {"from", "to", "message"} to URL
+	URL string `json:"url"`
+	// name of the environment variable holding the API key, sent as a bearer token
+	APIKeyEnv  string `json:"api_key_env"`
+	Sender     string `json:"sender"`
+	TimeoutSec int    `json:"timeout_sec"`
+}
@@ -13,3 +13,52 @@ type Role struct {
 	Code           string    `gorm:"unique; not null" json:"code"`
 	Description    string    `json:"description"`
 }
+
+type Permission struct {
+	base.BaseModel `swaggerignore:"true"`
+	PermissionID   uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"permission_id"`
+	Name           string    `gorm:"not null" json:"name"`
+	Code           string    `gorm:"unique; not null" json:"code"`
+	Description    string    `json:"description"`
+}
+
+// RolePermission grants a permission to a role.
+type RolePermission struct {
+	RoleID       uuid.UUID  `gorm:"type:uuid;primaryKey" json:"role_id"`
+	Role         Role       `gorm:"foreignKey:RoleID;references:RoleID;constraint:OnDelete:CASCADE" json:"-"`
+	PermissionID uuid.UUID  `gorm:"type:uuid;primaryKey" json:"permission_id"`
+	Permission   Permission `gorm:"foreignKey:PermissionID;references:PermissionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
+
+type RoleRequest struct {
+	Name        string `json:"name" validate:"required,min=2,max=50" example:"Support"`
+	Code        string `json:"code" validate:"required,min=2,max=50" example:"SUPPORT"`
+	Description string `json:"description" validate:"max=255" example:"Customer support agents"`
+} //@name RoleRequest
+
+// RolePermissionsRequest replaces the permissions granted to a role.
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"required,dive,required" example:"user.read"`
+} //@name RolePermissionsRequest
+
+type RoleResponse struct {
+	RoleID      uuid.UUID    `json:"role_id" example:"97d699c0-24ff-48dc-b64a-c29353fa8865"`
+	Name        string       `json:"name" example:"Admin"`
+	Code        string       `json:"code" example:"ADMIN"`
+	Description string       `json:"description" example:"This is a Admin role"`
+	Permissions []Permission `json:"permissions,omitempty"`
+} //@name RoleResponse
+
+func (role Role) ResponseObj(permissions []Permission) RoleResponse {
+	return RoleResponse{
+		RoleID:      role.RoleID,
+		Name:        role.Name,
+		Code:        role.Code,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo describes the client a request comes from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginEvent is a successful login of a user, kept as the login history of the account.
+type LoginEvent struct {
+	LoginEventID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"login_event_id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	// password, or oidc:<provider> for a social login
+	Method string `gorm:"not null" json:"method" example:"password"`
+	// whether a 2FA code has been verified as well
+	TwoFactor bool      `gorm:"not null;default:false" json:"two_factor" example:"false"`
+	IPAddress string    `json:"ip_address" example:"203.0.113.7"`
+	UserAgent string    `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"`
+	CreatedAt time.Time `gorm:"index" json:"created_at" example:"2025-05-01T12:00:00Z"`
+}
+
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
+
+// Session is a login of a user on a device. It lives as long as its refresh token family,
+// the session id is the family id carried by the access tokens as "sid".
+type Session struct {
+	SessionID  string    `json:"session_id" example:"4b3c2d1e-5f6a-4b7c-8d9e-0f1a2b3c4d5e"`
+	UserID     uuid.UUID `json:"-"`
+	Device     string    `json:"device" example:"Chrome on Windows"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"`
+	IPAddress  string    `json:"ip_address" example:"203.0.113.7"`
+	CreatedAt  time.Time `json:"created_at" example:"2025-05-01T12:00:00Z"`
+	LastSeenAt time.Time `json:"last_seen_at" example:"2025-05-01T12:30:00Z"`
+	// true for the session of the access token used for the request
+	Current bool `json:"current" example:"true"`
+} //@name Session
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"e-commerce/base"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTwoFactor holds the TOTP secret of a user.
+// The secret is stored encrypted (AES-GCM); it is pending until the user confirms it with a first code.
+type UserTwoFactor struct {
+	base.BaseModel  `swaggerignore:"true"`
+	UserTwoFactorID uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;unique" json:"user_two_factor_id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	User            User       `gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	SecretEncrypted string     `gorm:"not null" json:"-"`
+	IsEnabled       bool       `gorm:"default:false" json:"is_enabled"`
+	EnabledAt       *time.Time `json:"enabled_at"`
+	// time step of the last accepted code, a code can not be replayed
+	LastUsedStep int64 `gorm:"default:0" json:"-"`
+}
+
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
+
+// UserRecoveryCode is a single-use recovery code, stored as a SHA-256 hash.
+type UserRecoveryCode struct {
+	base.BaseModel `swaggerignore:"true"`
+	RecoveryCodeID uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;unique" json:"recovery_code_id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	User           User       `gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	CodeHash       string     `gorm:"not null;uniqueIndex" json:"-"`
+	UsedAt         *time.Time `json:"used_at"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OtpAuthURI string `json:"otpauth_uri" example:"otpauth://totp/E-Commerce:john@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=E-Commerce&algorithm=SHA1&digits=6&period=30"`
+} //@name TwoFactorEnrollResponse
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7d2m-q9x4p"`
+} //@name RecoveryCodesResponse
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required" example:"123456"`
+} //@name TwoFactorCodeRequest
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required" example:"S3cure#Passw0rd"`
+	Code     string `json:"code" validate:"required" example:"123456"`
+} //@name TwoFactorDisableRequest
+
+// TwoFactorLoginRequest completes a login of a user with 2FA enabled.
+// The code is either a TOTP code or one of the recovery codes.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required" example:"123456"`
+	// token of the guest cart, merged into the cart of the user once logged in
+	CartToken string `json:"cart_token,omitempty"`
+} //@name TwoFactorLoginRequest
+
+// TwoFactorChallengeResponse is returned by the login instead of the tokens when 2FA is enabled.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required" example:"true"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ChallengeExpiry   time.Time `json:"challenge_expiry" example:"2025-05-01T12:05:00Z"`
+} //@name TwoFactorChallengeResponse
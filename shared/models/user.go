@@ -3,6 +3,7 @@ package models
 import (
 	"e-commerce/base"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,15 +12,19 @@ import (
 // @Description User model
 type User struct {
 	base.BaseModel `swaggerignore:"true"`
-	UserID         uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;unique" json:"user_id"`
-	FirstName      string       `gorm:"not null" json:"first_name" validate:"required,alpha,min=2,max=50"`
-	LastName       string       `gorm:"not null" json:"last_name" validate:"required,alpha,min=2,max=50"`
-	Email          string       `gorm:"unique;index;not null" json:"email" validate:"required,email"`
-	Phone          string       `gorm:"not null" json:"phone" validate:"required,numeric,len=10"`
-	RoleID         uuid.UUID    `gorm:"not null" json:"role_id"`
-	Role           Role         `gorm:"foreignKey:RoleID;references:RoleID"`
-	IsVerified     bool         `gorm:"default:false" json:"is_verified"`
-	UserPassword   UserPassword `gorm:"foreignKey:UserID;references:UserID" json:"user_passwords"`
+	UserID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;unique" json:"user_id"`
+	FirstName      string    `gorm:"not null" json:"first_name" validate:"required,alpha,min=2,max=50"`
+	LastName       string    `gorm:"not null" json:"last_name" validate:"required,alpha,min=2,max=50"`
+	Email          string    `gorm:"unique;index;not null" json:"email" validate:"required,email"`
+	Phone          string    `gorm:"not null" json:"phone" validate:"required,phone"`
+	RoleID         uuid.UUID `gorm:"not null" json:"role_id"`
+	Role           Role      `gorm:"foreignKey:RoleID;references:RoleID"`
+	IsVerified     bool      `gorm:"default:false" json:"is_verified"`
+	// the phone (E.164) has been confirmed with an OTP sent by SMS
+	IsPhoneVerified bool         `gorm:"default:false" json:"is_phone_verified"`
+	UserPassword    UserPassword `gorm:"foreignKey:UserID;references:UserID" json:"user_passwords"`
+	// set when the user asked to delete the account, the account is deleted once the grace period is over
+	DeletionRequestedAt *time.Time `gorm:"index" json:"deletion_requested_at"`
 }
 
 func (User) TableName() string {
@@ -29,29 +34,63 @@ func (User) TableName() string {
 type UserList []User
 
 type UserResponse struct {
-	UserID    uuid.UUID `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	FirstName string    `json:"first_name" example:"John"`
-	FullName  string    `json:"full_name" example:"John Doe"`
-	LastName  string    `json:"last_name" example:"Doe"`
-	Email     string    `json:"email" example:"john.doe@gmail.com"`
-	Phone     string    `json:"phone" example:"1234567890"`
-	RoleID    uuid.UUID `json:"role_id" example:"97d699c0-24ff-48dc-b64a-c29353fa8865"`
+	UserID          uuid.UUID `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	FirstName       string    `json:"first_name" example:"John"`
+	FullName        string    `json:"full_name" example:"John Doe"`
+	LastName        string    `json:"last_name" example:"Doe"`
+	Email           string    `json:"email" example:"john.doe@gmail.com"`
+	Phone           string    `json:"phone" example:"+919876543210"`
+	RoleID          uuid.UUID `json:"role_id" example:"97d699c0-24ff-48dc-b64a-c29353fa8865"`
+	IsPhoneVerified bool      `json:"is_phone_verified" example:"true"`
 } //@name UserResponse
 
 func (user User) ResponseObj() UserResponse {
 	result := UserResponse{
-		UserID:    user.UserID,
-		FullName:  user.FullName(),
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Phone:     user.Phone,
-		RoleID:    user.RoleID,
+		UserID:          user.UserID,
+		FullName:        user.FullName(),
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		RoleID:          user.RoleID,
+		IsPhoneVerified: user.IsPhoneVerified,
 	}
 
 	return result
 }
 
+// AdminUserResponse is a user with the account status, as listed to the admins.
+type AdminUserResponse struct {
+	UserResponse
+	IsVerified          bool       `json:"is_verified" example:"false"`
+	CreatedAt           time.Time  `json:"created_at" example:"2025-05-01T12:00:00Z"`
+	DeletedAt           *time.Time `json:"deleted_at,omitempty" example:"2025-06-01T12:00:00Z"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty" example:"2025-05-15T12:00:00Z"`
+} //@name AdminUserResponse
+
+func (user User) AdminResponseObj() AdminUserResponse {
+	result := AdminUserResponse{
+		UserResponse:        user.ResponseObj(),
+		IsVerified:          user.IsVerified,
+		CreatedAt:           user.CreatedAt,
+		DeletionRequestedAt: user.DeletionRequestedAt,
+	}
+
+	if user.DeletedAt.Valid {
+		result.DeletedAt = &user.DeletedAt.Time
+	}
+
+	return result
+}
+
+func (userList UserList) AdminResponseList() []AdminUserResponse {
+	var result []AdminUserResponse
+	for _, obj := range userList {
+		result = append(result, obj.AdminResponseObj())
+	}
+	return result
+}
+
 func (userList UserList) ResponseList() []UserResponse {
 	var result []UserResponse
 	for _, obj := range userList {
@@ -77,19 +116,43 @@ func (UserPassword) TableName() string {
 
 // ===========================================
 
+// UserRequest is the self registration payload; registered users always get the customer role.
 type UserRequest struct {
+	FirstName string `json:"first_name" validate:"required,alpha,min=2,max=50"`
+	LastName  string `json:"last_name" validate:"required,alpha,min=2,max=50"`
+	Email     string `json:"email" validate:"required,email"`
+	Phone     string `json:"phone" validate:"required,phone"`
+	Password  string `json:"password" validate:"required" example:"S3cure#Passw0rd"`
+} //@name UserRequest
+
+type CreateUserRequest struct {
 	FirstName string    `json:"first_name" validate:"required,alpha,min=2,max=50"`
 	LastName  string    `json:"last_name" validate:"required,alpha,min=2,max=50"`
 	Email     string    `json:"email" validate:"required,email"`
-	Phone     string    `json:"phone" validate:"required,numeric,len=10"`
+	Phone     string    `json:"phone" validate:"required,phone"`
 	RoleID    uuid.UUID `json:"role_id" validate:"required"`
-} //@name UserRequest
+} //@name CreateUserRequest
+
+type SetPasswordRequest struct {
+	Token    string `json:"token" validate:"required" example:"q7JdXo0b1m0T2gk4YV7v3qgq9q8b1wJxk2f0m3s4e5Q"`
+	Password string `json:"password" validate:"required" example:"S3cure#Passw0rd"`
+} //@name SetPasswordRequest
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john.doe@gmail.com"`
+} //@name ForgotPasswordRequest
+
+type ResetPasswordRequest struct {
+	Email    string `json:"email" validate:"required,email" example:"john.doe@gmail.com"`
+	OTP      string `json:"otp" validate:"required" example:"123456"`
+	Password string `json:"password" validate:"required" example:"S3cure#Passw0rd"`
+} //@name ResetPasswordRequest
 
 type UpdateUserRequest struct {
 	FirstName string    `json:"first_name" validate:"required,alpha,min=2,max=50"`
 	LastName  string    `json:"last_name" validate:"required,alpha,min=2,max=50"`
 	Email     string    `json:"email" validate:"required,email"`
-	Phone     string    `json:"phone" validate:"required,numeric,len=10"`
+	Phone     string    `json:"phone" validate:"required,phone"`
 	RoleID    uuid.UUID `json:"role_id" validate:"required"`
 } //@name UserRequest
 
@@ -97,16 +160,46 @@ type PatchUserRequest struct {
 	FirstName string    `json:"first_name" validate:"alpha,min=2,max=50"`
 	LastName  string    `json:"last_name" validate:"alpha,min=2,max=50"`
 	Email     string    `json:"email" validate:"email"`
-	Phone     string    `json:"phone" validate:"numeric,len=10"`
+	Phone     string    `json:"phone" validate:"omitempty,phone"`
 	RoleID    uuid.UUID `json:"role_id"`
 } //@name UserRequest
 
+// UpdateProfileRequest replaces the profile of the logged in user.
+// The email and the role can not be changed through the profile.
+type UpdateProfileRequest struct {
+	FirstName string `json:"first_name" validate:"required,alpha,min=2,max=50" example:"John"`
+	LastName  string `json:"last_name" validate:"required,alpha,min=2,max=50" example:"Doe"`
+	Phone     string `json:"phone" validate:"required,phone" example:"+919876543210"`
+} //@name UpdateProfileRequest
+
+// PatchProfileRequest updates the given fields of the profile of the logged in user.
+type PatchProfileRequest struct {
+	FirstName string `json:"first_name" validate:"omitempty,alpha,min=2,max=50" example:"John"`
+	LastName  string `json:"last_name" validate:"omitempty,alpha,min=2,max=50" example:"Doe"`
+	Phone     string `json:"phone" validate:"omitempty,phone" example:"+919876543210"`
+} //@name PatchProfileRequest
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required" example:"S3cure#Passw0rd"`
+	NewPassword     string `json:"new_password" validate:"required" example:"N3w#S3cure#Passw0rd"`
+} //@name ChangePasswordRequest
+
+// DeleteAccountRequest confirms the deletion of the account of the logged in user.
+// The password is required; users signing in only with an external provider
+// confirm with the OTP sent by POST /user/me/reauthentication instead.
+type DeleteAccountRequest struct {
+	Password string `json:"password" example:"S3cure#Passw0rd"`
+	OTP      string `json:"otp" example:"123456"`
+} //@name DeleteAccountRequest
+
 type UserQueryParams struct {
-	FirstName  *string    `form:"first_name" query:"ILIKE"`
-	LastName   *string    `form:"last_name" query:"ILIKE"`
-	Email      *string    `form:"email"`
-	Phone      *string    `form:"phone"`
-	RoleID     *uuid.UUID `form:"role_id"`
-	IsDeleted  *bool      `form:"is_deleted"`
-	IsVerified bool       `form:"is_verified"`
+	PageQuery `query:"-"`
+	FirstName *string    `form:"first_name" query:"ILIKE"`
+	LastName  *string    `form:"last_name" query:"ILIKE"`
+	Email     *string    `form:"email"`
+	Phone     *string    `form:"phone"`
+	RoleID    *uuid.UUID `form:"role_id"`
+	// soft-deleted users are not a column filter, they are only listed to the admins
+	IsDeleted  *bool `form:"is_deleted" query:"-"`
+	IsVerified *bool `form:"is_verified"`
 }
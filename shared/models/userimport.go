@@ -0,0 +1,17 @@
+package models
+
+// UserImportRowError is a row of an imported CSV file that has not been imported.
+type UserImportRowError struct {
+	// line of the row in the file (the header is line 1)
+	Line  int    `json:"line" example:"4"`
+	Email string `json:"email,omitempty" example:"john.doe@gmail.com"`
+	Error string `json:"error" example:"the email is already registered"`
+} //@name UserImportRowError
+
+// UserImportResult reports the outcome of a CSV import.
+type UserImportResult struct {
+	Total   int                  `json:"total" example:"120"`
+	Created int                  `json:"created" example:"118"`
+	Failed  int                  `json:"failed" example:"2"`
+	Errors  []UserImportRowError `json:"errors"`
+} //@name UserImportResult
@@ -27,14 +27,18 @@ func PreLoadDataHandler(context *gin.Context) {
 
 	fileNames := []string{
 		"address_type",
+		"permission",
 		"user_role",
+		"role_permission",
 		"user",
 	}
 
 	fileNameModels := map[string]any{
-		"address_type": models.AddressType{},
-		"user_role":    models.Role{},
-		"user":         models.User{},
+		"address_type":    models.AddressType{},
+		"permission":      models.Permission{},
+		"user_role":       models.Role{},
+		"role_permission": models.RolePermission{},
+		"user":            models.User{},
 	}
 
 	db := connections.GetDB()
@@ -43,6 +47,8 @@ func PreLoadDataHandler(context *gin.Context) {
 	_ = db.Unscoped().Where("1 = 1").Delete(&models.Address{}).Error
 	_ = db.Unscoped().Where("1 = 1").Delete(&models.User{}).Error
 	_ = db.Unscoped().Where("1 = 1").Delete(&models.AddressType{}).Error
+	_ = db.Unscoped().Where("1 = 1").Delete(&models.RolePermission{}).Error
+	_ = db.Unscoped().Where("1 = 1").Delete(&models.Permission{}).Error
 	_ = db.Unscoped().Where("1 = 1").Delete(&models.Role{}).Error
 
 	for _, fileName := range fileNames {
@@ -17,14 +17,27 @@ const SMTP_USER = "EMAIL_USER"
 const SMTP_PASSWORD = "EMAIL_PASSWORD"
 const EMAIL_FROM = "EMAIL_FROM"
 const COMPANY_NAME = "COMPANY_NAME"
+const TWO_FACTOR_ENCRYPTION_KEY = "TWO_FACTOR_ENCRYPTION_KEY"
 
 // const DB_USER = "DB_USER"
 
-const SECRETE_KEY = "JWT_SECRET"
+// supported JWT signing algorithms
+const JWT_ALGORITHM_RS256 = "RS256"
+const JWT_ALGORITHM_EDDSA = "EdDSA"
+
+// scope of the access tokens; tokens with any other scope are not accepted by the auth middleware
+const ACCESS_TOKEN_SCOPE = "access"
+
+// scope of the short-lived token returned by the login when the user has 2FA enabled
+const TWO_FACTOR_CHALLENGE_SCOPE = "2fa_challenge"
+
+// login methods recorded in the login history
+const LOGIN_METHOD_PASSWORD = "password"
+const LOGIN_METHOD_OIDC_PREFIX = "oidc:"
 
-const USER_JWT_CLAIM_KEY = "user_details"
 const USER_DATA_CONTEXT_KEY = "logged_in_user_data"
 const USER_DATA_OF_SESSION = "user_data_session"
+const TOKEN_DATA_CONTEXT_KEY = "access_token_data"
 
 // key for setting response in the gin context
 const RESPONSE_DATA_KEY = "response_data"
@@ -32,6 +45,114 @@ const RESPONSE_STATUS_KEY = "response_status"
 
 const RATE_LIMIT_PREFIX = "rate_limit_"
 
+// redis key prefix for the users loaded by the auth middleware
+const USER_CACHE_PREFIX = "user_cache_"
+const ROLE_PERMISSIONS_CACHE_PREFIX = "role_permissions_"
+
+// role codes
+const ROLE_ADMIN = "ADMIN"
+const ROLE_SELLER = "SELLER"
+const ROLE_CUSTOMER = "CUSTOMER"
+
+// permission codes
+const PERMISSION_USER_READ = "user.read"
+const PERMISSION_USER_MANAGE = "user.manage"
+const PERMISSION_ROLE_MANAGE = "role.manage"
+const PERMISSION_USER_ADMIN = "user.admin"
+const PERMISSION_CATALOG_MANAGE = "catalog.manage"
+const PERMISSION_INVENTORY_MANAGE = "inventory.manage"
+
+// product statuses, only the active products are visible in the public catalog
+const PRODUCT_STATUS_DRAFT = "draft"
+const PRODUCT_STATUS_ACTIVE = "active"
+const PRODUCT_STATUS_ARCHIVED = "archived"
+
+// attribute types of the catalog
+const ATTRIBUTE_TYPE_ENUM = "enum"
+const ATTRIBUTE_TYPE_NUMBER = "number"
+const ATTRIBUTE_TYPE_BOOLEAN = "boolean"
+const ATTRIBUTE_TYPE_TEXT = "text"
+
+// stock movement types of the inventory ledger
+const STOCK_MOVEMENT_RECEIPT = "receipt"
+const STOCK_MOVEMENT_ADJUSTMENT = "adjustment"
+const STOCK_MOVEMENT_SALE = "sale"
+const STOCK_MOVEMENT_RETURN = "return"
+
+// stock reservation statuses, only the active reservations hold stock
+const RESERVATION_STATUS_ACTIVE = "active"
+const RESERVATION_STATUS_COMMITTED = "committed"
+const RESERVATION_STATUS_RELEASED = "released"
+const RESERVATION_STATUS_EXPIRED = "expired"
+
+// statuses of the cart lines, only the available lines count in the cart subtotal
+const CART_ITEM_STATUS_AVAILABLE = "available"
+const CART_ITEM_STATUS_INSUFFICIENT_STOCK = "insufficient_stock"
+const CART_ITEM_STATUS_UNAVAILABLE = "unavailable"
+
+// header carrying the token of the guest cart
+const CART_TOKEN_HEADER = "X-Cart-Token"
+
+// redis key prefixes for the password flows
+const SET_PASSWORD_TOKEN_PREFIX = "set_password_token_"
+const FORGOT_PASSWORD_OTP_PREFIX = "forgot_password_otp_"
+const FORGOT_PASSWORD_ATTEMPTS_PREFIX = "forgot_password_attempts_"
+const FORGOT_PASSWORD_COOLDOWN_PREFIX = "forgot_password_cooldown_"
+
+// redis key prefix holding the time before which all tokens of a user are rejected
+const TOKEN_VALID_AFTER_PREFIX = "token_valid_after_"
+
+// redis key prefixes for access token revocation and refresh token rotation
+const JWT_DENYLIST_PREFIX = "jwt_denylist_"
+const REFRESH_TOKEN_PREFIX = "refresh_token_"
+const REFRESH_TOKEN_USED_PREFIX = "refresh_token_used_"
+const REFRESH_FAMILY_PREFIX = "refresh_family_"
+const USER_REFRESH_FAMILIES_PREFIX = "user_refresh_families_"
+
+// redis key prefix counting the wrong codes sent for a 2FA challenge token (jti)
+const TWO_FACTOR_CHALLENGE_ATTEMPTS_PREFIX = "two_factor_challenge_attempts_"
+
+// redis key prefix of the pending OIDC logins (state -> nonce and PKCE verifier)
+const OIDC_STATE_PREFIX = "oidc_state_"
+
+// redis key prefixes of the pending email changes: new email -> user and OTP, user -> new email
+const EMAIL_CHANGE_PREFIX = "email_change_"
+const EMAIL_CHANGE_USER_PREFIX = "email_change_user_"
+const EMAIL_CHANGE_ATTEMPTS_PREFIX = "email_change_attempts_"
+const EMAIL_CHANGE_COOLDOWN_PREFIX = "email_change_cooldown_"
+
+// redis key prefixes of the re-authentication OTP of the users without a password (per user)
+const REAUTHENTICATION_OTP_PREFIX = "reauthentication_otp_"
+const REAUTHENTICATION_OTP_ATTEMPTS_PREFIX = "reauthentication_otp_attempts_"
+const REAUTHENTICATION_OTP_COOLDOWN_PREFIX = "reauthentication_otp_cooldown_"
+
+// redis key prefixes of the phone verification OTP (per user)
+const PHONE_OTP_PREFIX = "phone_otp_"
+const PHONE_OTP_ATTEMPTS_PREFIX = "phone_otp_attempts_"
+const PHONE_OTP_COOLDOWN_PREFIX = "phone_otp_cooldown_"
+
+// sms sent with the phone verification OTP (company name, otp, validity in minutes)
+const PHONE_VERIFICATION_SMS = "%s: %s is your verification code. It is valid for %d minutes, do not share it with anyone."
+
+// redis key prefix of the lock making sure a cron job runs on one instance only
+const CRON_LOCK_PREFIX = "cron_lock_"
+
+// redis key prefix of the guest carts (hash of the cart token)
+const GUEST_CART_PREFIX = "guest_cart_"
+
+// redis key prefixes for the brute force protection of login and OTP verification
+const LOGIN_FAILURES_EMAIL_PREFIX = "login_failures_email_"
+const LOGIN_FAILURES_IP_PREFIX = "login_failures_ip_"
+const LOGIN_BACKOFF_EMAIL_PREFIX = "login_backoff_email_"
+const LOGIN_BACKOFF_IP_PREFIX = "login_backoff_ip_"
+const ACCOUNT_LOCK_PREFIX = "account_lock_"
+const IP_BLOCK_PREFIX = "ip_block_"
+const VERIFICATION_OTP_ATTEMPTS_PREFIX = "verification_otp_attempts_"
+const VERIFICATION_OTP_COOLDOWN_PREFIX = "verification_otp_cooldown_"
+
+// path of the frontend page which consumes the set-password token
+const SET_PASSWORD_PATH = "/set-password"
+
 const OTP_VERIFICATION_EMAIL_SUBJECT = `%s | Email Verification OTP`
 
 const OTP_VERIFICATION_EMAIL_FORMAT_HTML = `
@@ -127,9 +248,9 @@ Best regards,
 This email and any attachments are confidential and intended solely for the recipient. If you are not the intended recipient, please notify us immediately and delete this email.
 `
 
-const SHARE_CREDENTIAL_EMAIL_SUBJECT = `Welcome to %s - Your Login Credentials`
+const SET_PASSWORD_EMAIL_SUBJECT = `Welcome to %s - Set Your Password`
 
-const SHARE_CREDENTIAL_EMAIL_FORMAT_HTML = `
+const SET_PASSWORD_EMAIL_FORMAT_HTML = `
 <!DOCTYPE html>
 <html>
   <head>
@@ -161,6 +282,15 @@ const SHARE_CREDENTIAL_EMAIL_FORMAT_HTML = `
         color: #007BFF;
         text-decoration: none;
       }
+      .button {
+        display: inline-block;
+        padding: 10px 20px;
+        background-color: #007bff;
+        color: #ffffff;
+        text-decoration: none;
+        border-radius: 4px;
+        margin: 20px 0;
+      }
       .highlight {
         font-weight: bold;
         color: #333;
@@ -173,15 +303,10 @@ const SHARE_CREDENTIAL_EMAIL_FORMAT_HTML = `
         <h2>Welcome to <span style="color: #007BFF;">%s</span></h2>
       </div>
       <p>Dear <span class="highlight">%s</span>,</p>
-      <p>Congratulations! Your account has been successfully created.</p>
-      <p>Here are your login details:</p>
-      <ul>
-        <li><strong>Login ID:</strong> <span class="highlight">%s</span></li>
-        <li><strong>Password:</strong> <span class="highlight">%s</span></li>
-      </ul>
-      
-      <p>For security reasons, we recommend changing your password after your first login.</p>
-      <p>If you have any questions or need assistance, please contact us at <a href="mailto:support@shopify.com">support@shopify.com</a> or visit our Help Center.</p>
+      <p>An account has been created for you. Please use the link below to choose your password:</p>
+      <p style="text-align: center;"><a class="button" style="color: #ffffff;" href="%s">Set Password</a></p>
+      <p>This link can be used only once and is valid for the next %d minutes.</p>
+      <p>If you did not expect this email, please ignore it or contact our support team at <a href="mailto:support@shopify.com">support@shopify.com</a>.</p>
       <p>Thank you for choosing <span class="highlight">%s</span>!</p>
       <div class="footer">
         <p>&copy; %d Shopify Pvt Ltd. All rights reserved.</p>
@@ -191,24 +316,361 @@ const SHARE_CREDENTIAL_EMAIL_FORMAT_HTML = `
 </html>
 `
 
-const SHARE_CREDENTIAL_EMAIL_FORMAT_TXT = `
+const SET_PASSWORD_EMAIL_FORMAT_TXT = `
 Dear %s,
 
-Congratulations! Your account has been successfully created on %s.
-
-Here are your login details:
+An account has been created for you on %s.
 
-Login ID: %s  
-Password: %s
+Please use the link below to choose your password:
 
-You can now log in to your account using the credentials provided above.
+%s
 
-For security purposes, we recommend changing your password after your first login.
+This link can be used only once and is valid for the next %d minutes.
 
-If you have any questions or need further assistance, feel free to contact us at support@shopify.com or visit our Help Center.
+If you did not expect this email, please ignore it or contact our support team at support@shopify.com.
 
 Thank you for choosing %s!
 
 Best regards,  
 %s
 `
+
+const PASSWORD_RESET_EMAIL_SUBJECT = `%s | Password Reset OTP`
+
+const PASSWORD_RESET_EMAIL_FORMAT_HTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            background-color: #f9f9f9;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 20px auto;
+            background-color: #ffffff;
+            border: 1px solid #ddd;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+        }
+        .header {
+            background-color: #007bff;
+            color: #ffffff;
+            padding: 10px 0;
+            border-radius: 8px 8px 0 0;
+        }
+        .otp {
+            font-size: 24px;
+            font-weight: bold;
+            color: #007bff;
+            margin: 20px 0;
+        }
+        .footer {
+            font-size: 12px;
+            color: #777;
+            margin-top: 20px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <p>Hi %s,</p>
+        <p>We received a request to reset the password of your account. Please use the One-Time Password (OTP) below to reset it:</p>
+        <div class="otp">%s</div>
+        <p>This OTP is valid for the next %d minutes. Please do not share this code with anyone.</p>
+        <p>If you did not request a password reset, please ignore this email or contact our support team at <a href="mailto:support@shopify.com">support@shopify.com</a>.</p>
+        <div class="footer">
+            © %d Shopify Pvt Ltd. All rights reserved.
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const PASSWORD_RESET_EMAIL_FORMAT_TXT = `
+Dear %s,
+
+We received a request to reset the password of your %s account. Please use the One-Time Password (OTP) provided below:
+
+### **Your OTP: %s**
+
+This OTP is valid for the next %d minutes. Please do not share this code with anyone.
+
+If you did not request a password reset, please ignore this email or contact our support team at support@shopify.com.
+
+Best regards,  
+%s
+`
+
+const ACCOUNT_LOCKED_EMAIL_SUBJECT = `%s | Your account has been temporarily locked`
+
+const ACCOUNT_LOCKED_EMAIL_FORMAT_HTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            background-color: #f9f9f9;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 20px auto;
+            background-color: #ffffff;
+            border: 1px solid #ddd;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+        }
+        .header {
+            background-color: #dc3545;
+            color: #ffffff;
+            padding: 10px 0;
+            border-radius: 8px 8px 0 0;
+        }
+        .footer {
+            font-size: 12px;
+            color: #777;
+            margin-top: 20px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <p>Hi %s,</p>
+        <p>We noticed several failed attempts to sign in to your account, so we have locked it for the next %d minutes to keep it safe.</p>
+        <p>If it was you, please wait and try again later, or reset your password using the "Forgot password" option.</p>
+        <p>If it was not you, we recommend resetting your password as soon as the lock expires and contacting our support team at <a href="mailto:support@shopify.com">support@shopify.com</a>.</p>
+        <div class="footer">
+            © %d Shopify Pvt Ltd. All rights reserved.
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const ACCOUNT_LOCKED_EMAIL_FORMAT_TXT = `
+Dear %s,
+
+We noticed several failed attempts to sign in to your %s account, so we have locked it for the next %d minutes to keep it safe.
+
+If it was you, please wait and try again later, or reset your password using the "Forgot password" option.
+
+If it was not you, we recommend resetting your password as soon as the lock expires and contacting our support team at support@shopify.com.
+
+Best regards,  
+%s
+`
+
+const ACCOUNT_DELETION_EMAIL_SUBJECT = `%s | Your account is scheduled for deletion`
+
+const ACCOUNT_DELETION_EMAIL_FORMAT_HTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            background-color: #f9f9f9;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 20px auto;
+            background-color: #ffffff;
+            border: 1px solid #ddd;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+        }
+        .header {
+            background-color: #dc3545;
+            color: #ffffff;
+            padding: 10px 0;
+            border-radius: 8px 8px 0 0;
+        }
+        .footer {
+            font-size: 12px;
+            color: #777;
+            margin-top: 20px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <p>Hi %s,</p>
+        <p>As requested, your account will be deleted on <strong>%s</strong> and you have been logged out from all your devices.</p>
+        <p>Changed your mind? Simply log in again before that date and the deletion will be cancelled.</p>
+        <p>If you did not request this, log in to cancel the deletion, reset your password and contact our support team at <a href="mailto:support@shopify.com">support@shopify.com</a>.</p>
+        <div class="footer">
+            © %d Shopify Pvt Ltd. All rights reserved.
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const ACCOUNT_DELETION_EMAIL_FORMAT_TXT = `
+Dear %s,
+
+As requested, your %s account will be deleted on %s and you have been logged out from all your devices.
+
+Changed your mind? Simply log in again before that date and the deletion will be cancelled.
+
+If you did not request this, log in to cancel the deletion, reset your password and contact our support team at support@shopify.com.
+
+Best regards,  
+%s
+`
+
+const EMAIL_CHANGE_NOTICE_EMAIL_SUBJECT = `%s | A change of your email address was requested`
+
+const EMAIL_CHANGE_NOTICE_EMAIL_FORMAT_HTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            background-color: #f9f9f9;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 20px auto;
+            background-color: #ffffff;
+            border: 1px solid #ddd;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+        }
+        .header {
+            background-color: #dc3545;
+            color: #ffffff;
+            padding: 10px 0;
+            border-radius: 8px 8px 0 0;
+        }
+        .footer {
+            font-size: 12px;
+            color: #777;
+            margin-top: 20px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <p>Hi %s,</p>
+        <p>A change of the email address of your account to <strong>%s</strong> was requested. The new address will be used once it is confirmed with the code we sent to it.</p>
+        <p>If you did not request this, please reset your password right away and contact our support team at <a href="mailto:support@shopify.com">support@shopify.com</a>.</p>
+        <div class="footer">
+            © %d Shopify Pvt Ltd. All rights reserved.
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const EMAIL_CHANGE_NOTICE_EMAIL_FORMAT_TXT = `
+Dear %s,
+
+A change of the email address of your %s account to %s was requested. The new address will be used once it is confirmed with the code we sent to it.
+
+If you did not request this, please reset your password right away and contact our support team at support@shopify.com.
+
+Best regards,  
+%s
+`
+
+const REAUTHENTICATION_EMAIL_SUBJECT = `%s | Re-authentication OTP`
+
+const REAUTHENTICATION_EMAIL_FORMAT_HTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            background-color: #f9f9f9;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 20px auto;
+            background-color: #ffffff;
+            border: 1px solid #ddd;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+        }
+        .header {
+            background-color: #007bff;
+            color: #ffffff;
+            padding: 10px 0;
+            border-radius: 8px 8px 0 0;
+        }
+        .otp {
+            font-size: 24px;
+            font-weight: bold;
+            color: #007bff;
+            margin: 20px 0;
+        }
+        .footer {
+            font-size: 12px;
+            color: #777;
+            margin-top: 20px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <p>Hi %s,</p>
+        <p>Please use the One-Time Password (OTP) below to confirm it is you before changing the email address of your account or deleting it:</p>
+        <div class="otp">%s</div>
+        <p>This OTP is valid for the next %d minutes. Please do not share this code with anyone.</p>
+        <p>If you did not request this code, someone may be using your account: please log out of all your devices and contact our support team at <a href="mailto:support@shopify.com">support@shopify.com</a>.</p>
+        <div class="footer">
+            © %d Shopify Pvt Ltd. All rights reserved.
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const REAUTHENTICATION_EMAIL_FORMAT_TXT = `
+Dear %s,
+
+Please use the One-Time Password (OTP) provided below to confirm it is you before changing the email address of your %s account or deleting it:
+
+### **Your OTP: %s**
+
+This OTP is valid for the next %d minutes. Please do not share this code with anyone.
+
+If you did not request this code, someone may be using your account: please log out of all your devices and contact our support team at support@shopify.com.
+
+Best regards,  
+%s
+`
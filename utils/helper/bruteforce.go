@@ -0,0 +1,279 @@
+package helper
+
+import (
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ThrottleError is returned when an attempt is refused because of too many failed attempts.
+// RetryAfter tells the client when it can try again.
+type ThrottleError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (err *ThrottleError) Error() string {
+	return err.Message
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, as used by the Retry-After header.
+func (err *ThrottleError) RetryAfterSeconds() int {
+	return int(math.Ceil(err.RetryAfter.Seconds()))
+}
+
+var loginProtection models.LoginProtection
+
+// initLoginProtection sets the brute force protection settings.
+// Missing values fall back to safe defaults, so the protection can not be disabled by omission.
+func initLoginProtection(config models.LoginProtection) {
+	defaults := models.LoginProtection{
+		MaxFailedAttempts:      5,
+		MaxFailedAttemptsPerIP: 20,
+		FailureWindowMin:       15,
+		LockoutMin:             15,
+		BackoffBaseSec:         1,
+		BackoffMaxSec:          60,
+		OtpMaxAttempts:         5,
+		OtpResendCooldownSec:   60,
+	}
+
+	withDefault := func(value int, defaultValue int) int {
+		if value <= 0 {
+			return defaultValue
+		}
+		return value
+	}
+
+	loginProtection = models.LoginProtection{
+		MaxFailedAttempts:      withDefault(config.MaxFailedAttempts, defaults.MaxFailedAttempts),
+		MaxFailedAttemptsPerIP: withDefault(config.MaxFailedAttemptsPerIP, defaults.MaxFailedAttemptsPerIP),
+		FailureWindowMin:       withDefault(config.FailureWindowMin, defaults.FailureWindowMin),
+		LockoutMin:             withDefault(config.LockoutMin, defaults.LockoutMin),
+		BackoffBaseSec:         withDefault(config.BackoffBaseSec, defaults.BackoffBaseSec),
+		BackoffMaxSec:          withDefault(config.BackoffMaxSec, defaults.BackoffMaxSec),
+		OtpMaxAttempts:         withDefault(config.OtpMaxAttempts, defaults.OtpMaxAttempts),
+		OtpResendCooldownSec:   withDefault(config.OtpResendCooldownSec, defaults.OtpResendCooldownSec),
+	}
+}
+
+// CheckLoginAttempt refuses the attempt with a *ThrottleError if the account is locked,
+// the client IP is blocked, or either of them is still waiting for its backoff delay.
+// Redis errors are logged and the attempt is allowed, so an outage does not lock everybody out.
+func CheckLoginAttempt(email string, clientIP string) error {
+	email = normalizeEmail(email)
+
+	if ttl := remainingTTL(constants.ACCOUNT_LOCK_PREFIX + email); ttl > 0 {
+		return &ThrottleError{
+			Message:    "your account is temporarily locked because of too many failed attempts, please try again later",
+			RetryAfter: ttl,
+		}
+	}
+
+	if err := CheckClientIP(clientIP); err != nil {
+		return err
+	}
+
+	if ttl := remainingTTL(constants.LOGIN_BACKOFF_EMAIL_PREFIX + email); ttl > 0 {
+		return backoffError(ttl)
+	}
+
+	return nil
+}
+
+// CheckClientIP refuses the attempt with a *ThrottleError if the client IP is blocked
+// or still waiting for its backoff delay.
+func CheckClientIP(clientIP string) error {
+	if ttl := remainingTTL(constants.IP_BLOCK_PREFIX + clientIP); ttl > 0 {
+		return &ThrottleError{
+			Message:    "too many failed attempts from your network, please try again later",
+			RetryAfter: ttl,
+		}
+	}
+
+	if ttl := remainingTTL(constants.LOGIN_BACKOFF_IP_PREFIX + clientIP); ttl > 0 {
+		return backoffError(ttl)
+	}
+
+	return nil
+}
+
+// RecordLoginFailure counts a failed login of the email from the client IP.
+// Every failure doubles the delay before the next attempt; once the email reaches
+// the configured number of failures the account is locked for the lockout time.
+// It returns true only for the failure which locked the account,
+// so the caller notifies the user once.
+func RecordLoginFailure(email string, clientIP string) bool {
+	email = normalizeEmail(email)
+	window := time.Duration(loginProtection.FailureWindowMin) * time.Minute
+
+	RecordIPFailure(clientIP)
+
+	failures, err := IncrementCache(constants.LOGIN_FAILURES_EMAIL_PREFIX+email, window)
+	if err != nil {
+		fmt.Printf("failed to count login failure for %s: %v\n", email, err)
+		return false
+	}
+
+	if failures < int64(loginProtection.MaxFailedAttempts) {
+		setBackoff(constants.LOGIN_BACKOFF_EMAIL_PREFIX+email, failures)
+		return false
+	}
+
+	lockout := time.Duration(loginProtection.LockoutMin) * time.Minute
+	locked, err := SetCacheIfNotExists(constants.ACCOUNT_LOCK_PREFIX+email, 1, lockout)
+	if err != nil {
+		fmt.Printf("failed to lock account %s: %v\n", email, err)
+		return false
+	}
+
+	// the lock replaces the counters, the user gets a fresh start once it expires
+	if err := DeleteCache(constants.LOGIN_FAILURES_EMAIL_PREFIX+email, constants.LOGIN_BACKOFF_EMAIL_PREFIX+email); err != nil {
+		fmt.Printf("failed to reset login failures for %s: %v\n", email, err)
+	}
+
+	return locked
+}
+
+// RecordIPFailure counts a failed attempt (login or OTP) from the client IP,
+// applies the backoff delay and blocks the IP once it reaches the configured number of failures.
+func RecordIPFailure(clientIP string) {
+	window := time.Duration(loginProtection.FailureWindowMin) * time.Minute
+
+	failures, err := IncrementCache(constants.LOGIN_FAILURES_IP_PREFIX+clientIP, window)
+	if err != nil {
+		fmt.Printf("failed to count failure for ip %s: %v\n", clientIP, err)
+		return
+	}
+
+	if failures < int64(loginProtection.MaxFailedAttemptsPerIP) {
+		setBackoff(constants.LOGIN_BACKOFF_IP_PREFIX+clientIP, failures)
+		return
+	}
+
+	lockout := time.Duration(loginProtection.LockoutMin) * time.Minute
+	if _, err := SetCache(constants.IP_BLOCK_PREFIX+clientIP, 1, lockout); err != nil {
+		fmt.Printf("failed to block ip %s: %v\n", clientIP, err)
+		return
+	}
+
+	if err := DeleteCache(constants.LOGIN_FAILURES_IP_PREFIX+clientIP, constants.LOGIN_BACKOFF_IP_PREFIX+clientIP); err != nil {
+		fmt.Printf("failed to reset failures for ip %s: %v\n", clientIP, err)
+	}
+}
+
+// ResetLoginFailures clears the failure counter and backoff of the email after a successful login.
+// The IP counters are left alone, one valid account must not reset the counters of an attacker's IP.
+func ResetLoginFailures(email string) {
+	email = normalizeEmail(email)
+	if err := DeleteCache(constants.LOGIN_FAILURES_EMAIL_PREFIX+email, constants.LOGIN_BACKOFF_EMAIL_PREFIX+email); err != nil {
+		fmt.Printf("failed to reset login failures for %s: %v\n", email, err)
+	}
+}
+
+// RecordOtpFailure counts a wrong guess of the OTP stored at otpKey and
+// invalidates the OTP once the configured number of wrong guesses is reached.
+// It returns true if the OTP has been invalidated.
+func RecordOtpFailure(attemptsKey string, otpKey string, clientIP string) bool {
+	RecordIPFailure(clientIP)
+
+	attempts, err := IncrementCache(attemptsKey, time.Duration(OtpExpTime)*time.Minute)
+	if err != nil {
+		fmt.Printf("failed to count otp attempt for %s: %v\n", otpKey, err)
+		return false
+	}
+
+	if attempts < int64(loginProtection.OtpMaxAttempts) {
+		return false
+	}
+
+	if err := DeleteCache(otpKey, attemptsKey); err != nil {
+		fmt.Printf("failed to invalidate otp %s: %v\n", otpKey, err)
+	}
+
+	return true
+}
+
+// StartOtpResendCooldown starts the cooldown between two OTP emails for the key.
+// It returns a *ThrottleError if the previous cooldown has not expired yet.
+func StartOtpResendCooldown(cooldownKey string) error {
+	cooldown := time.Duration(loginProtection.OtpResendCooldownSec) * time.Second
+
+	started, err := SetCacheIfNotExists(cooldownKey, 1, cooldown)
+	if err != nil {
+		fmt.Printf("failed to start otp resend cooldown for %s: %v\n", cooldownKey, err)
+		return nil
+	}
+
+	if started {
+		return nil
+	}
+
+	ttl := remainingTTL(cooldownKey)
+	return &ThrottleError{
+		Message:    fmt.Sprintf("please wait %d seconds before requesting a new OTP", int(math.Ceil(ttl.Seconds()))),
+		RetryAfter: ttl,
+	}
+}
+
+// setBackoff delays the next attempt by base * 2^(failures-1), capped at the configured maximum.
+func setBackoff(backoffKey string, failures int64) {
+	delay := time.Duration(loginProtection.BackoffMaxSec) * time.Second
+	if failures <= 30 {
+		exponential := time.Duration(loginProtection.BackoffBaseSec) * time.Second << (failures - 1)
+		if exponential < delay {
+			delay = exponential
+		}
+	}
+
+	if _, err := SetCache(backoffKey, 1, delay); err != nil {
+		fmt.Printf("failed to set backoff %s: %v\n", backoffKey, err)
+	}
+}
+
+func backoffError(ttl time.Duration) *ThrottleError {
+	return &ThrottleError{
+		Message:    fmt.Sprintf("too many failed attempts, please wait %d seconds before trying again", int(math.Ceil(ttl.Seconds()))),
+		RetryAfter: ttl,
+	}
+}
+
+func remainingTTL(cacheKey string) time.Duration {
+	ttl, err := GetCacheTTL(cacheKey)
+	if err != nil {
+		fmt.Printf("failed to read %s: %v\n", cacheKey, err)
+		return 0
+	}
+	return ttl
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// RecordTwoFactorFailure counts a wrong code sent for the 2FA challenge token (jti)
+// and revokes the challenge once the configured number of wrong codes is reached,
+// the user then has to login with the password again.
+// It returns true if the challenge has been revoked.
+func RecordTwoFactorFailure(challengeID string, expiresAt time.Time, clientIP string) bool {
+	RecordIPFailure(clientIP)
+
+	attempts, err := IncrementCache(constants.TWO_FACTOR_CHALLENGE_ATTEMPTS_PREFIX+challengeID, time.Until(expiresAt))
+	if err != nil {
+		fmt.Printf("failed to count 2fa attempt for %s: %v\n", challengeID, err)
+		return false
+	}
+
+	if attempts < int64(loginProtection.OtpMaxAttempts) {
+		return false
+	}
+
+	if err := DenyAccessToken(challengeID, expiresAt); err != nil {
+		fmt.Printf("failed to revoke 2fa challenge %s: %v\n", challengeID, err)
+	}
+
+	return true
+}
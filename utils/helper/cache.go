@@ -30,3 +30,47 @@ func SetCache(cacheKey string, value any, expiry time.Duration) (string, error)
 
 	return data, nil
 }
+
+// PopCache returns the cached value and deletes the key atomically,
+// so the value can be consumed only once.
+func PopCache(cacheKey string) (string, error) {
+	ctx := context.Background()
+	return redisClient.GetDel(ctx, cacheKey).Result()
+}
+
+// IncrementCache increments the counter stored at the key and returns the new value.
+// The expiry is set when the counter is created, so the counter resets after it.
+func IncrementCache(cacheKey string, expiry time.Duration) (int64, error) {
+	ctx := context.Background()
+	count, err := redisClient.Incr(ctx, cacheKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 1 {
+		if err := redisClient.Expire(ctx, cacheKey, expiry).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+// GetCacheTTL returns the remaining time to live of the key,
+// or zero if the key does not exist or has no expiry.
+func GetCacheTTL(cacheKey string) (time.Duration, error) {
+	ctx := context.Background()
+	ttl, err := redisClient.PTTL(ctx, cacheKey).Result()
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+
+	return ttl, nil
+}
+
+// SetCacheIfNotExists sets the key only if it does not exist yet.
+// It returns whether the key was set.
+func SetCacheIfNotExists(cacheKey string, value any, expiry time.Duration) (bool, error) {
+	ctx := context.Background()
+	return redisClient.SetNX(ctx, cacheKey, value, expiry).Result()
+}
@@ -0,0 +1,28 @@
+package helper
+
+import (
+	"e-commerce/shared/models"
+	"time"
+)
+
+// GuestCartTTL is how long a guest cart is kept without change.
+var GuestCartTTL = 7 * 24 * time.Hour
+
+// MaxCartItems is the largest number of lines of a cart.
+var MaxCartItems = 50
+
+// MaxCartItemQuantity is the largest quantity of a line of a cart.
+var MaxCartItemQuantity int64 = 99
+
+// initCart sets the lifetime of the guest carts and the size limits of the carts.
+func initCart(config models.CartConfig) {
+	if config.GuestCartTTLHours > 0 {
+		GuestCartTTL = time.Duration(config.GuestCartTTLHours) * time.Hour
+	}
+	if config.MaxItems > 0 {
+		MaxCartItems = config.MaxItems
+	}
+	if config.MaxItemQuantity > 0 {
+		MaxCartItemQuantity = int64(config.MaxItemQuantity)
+	}
+}
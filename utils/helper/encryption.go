@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	cryptRand "crypto/rand"
+	"e-commerce/utils/constants"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var secretEncryptionKey []byte
+
+// InitSecretEncryption loads the AES-256 key used to encrypt secrets stored in the database
+// (e.g. the TOTP secrets). The key is read from the TWO_FACTOR_ENCRYPTION_KEY environment
+// variable (base64), or from the key file. In the local environment a missing key file is generated.
+func InitSecretEncryption(keyFile string) error {
+	encodedKey := strings.TrimSpace(os.Getenv(constants.TWO_FACTOR_ENCRYPTION_KEY))
+
+	if encodedKey == "" && keyFile != "" {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		keyPath := filepath.Join(currentDir, keyFile)
+
+		data, err := os.ReadFile(keyPath)
+		if errors.Is(err, os.ErrNotExist) && IsLocalEnv() {
+			fmt.Printf("encryption key file (%s) not found, generating a new key\n", keyFile)
+			data, err = generateEncryptionKeyFile(keyPath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load encryption key: %w", err)
+		}
+		encodedKey = strings.TrimSpace(string(data))
+	}
+
+	if encodedKey == "" {
+		return fmt.Errorf("no encryption key configured, set %s", constants.TWO_FACTOR_ENCRYPTION_KEY)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return fmt.Errorf("invalid encryption key, expects base64: %w", err)
+	}
+
+	if len(key) != 32 {
+		return fmt.Errorf("invalid encryption key, expects 32 bytes (AES-256), got %d", len(key))
+	}
+
+	secretEncryptionKey = key
+	return nil
+}
+
+// EncryptSecret encrypts the value with AES-256-GCM.
+// It returns base64(nonce || ciphertext).
+func EncryptSecret(value string) (string, error) {
+	gcm, err := newSecretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := cryptRand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a value encrypted by EncryptSecret.
+func DecryptSecret(encrypted string) (string, error) {
+	gcm, err := newSecretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret")
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted secret")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	value, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret")
+	}
+
+	return string(value), nil
+}
+
+func newSecretCipher() (cipher.AEAD, error) {
+	if len(secretEncryptionKey) == 0 {
+		return nil, fmt.Errorf("secret encryption is not initialised")
+	}
+
+	block, err := aes.NewCipher(secretEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// generateEncryptionKeyFile writes a new random base64 encoded AES-256 key.
+func generateEncryptionKeyFile(keyPath string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := cryptRand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return nil, err
+	}
+
+	data := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err := os.WriteFile(keyPath, data, 0600); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -6,23 +6,35 @@ import (
 	"e-commerce/utils/constants"
 
 	cryptRand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
-	mathRand "math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
 var ExpiryTime int
+var RefreshTokenExpTime int
 var OtpExpTime int
-var passwordLength int
+var SetPasswordTokenExpTime int
+var ResetPasswordMaxAttempts int
+var AccountDeletionGraceDays int
+var TwoFactorIssuer string
+var TwoFactorChallengeExpTime int
+var RecoveryCodeCount int
+var AppBaseURL string
 var otpLength int
 var redisClient *redis.Client
 
@@ -30,8 +42,35 @@ var redisClient *redis.Client
 // and sets up the Redis client.
 func InitiateHelper(config models.ConfigData) {
 	ExpiryTime = config.SessionTimeOutmin
+	RefreshTokenExpTime = config.RefreshTokenExpMin
 	OtpExpTime = config.OtpExpMin
-	passwordLength = config.PasswordLength
+	SetPasswordTokenExpTime = config.SetPasswordTokenExpMin
+	ResetPasswordMaxAttempts = config.ResetPasswordMaxAttempts
+	AccountDeletionGraceDays = config.AccountDeletionGraceDays
+	if AccountDeletionGraceDays <= 0 {
+		AccountDeletionGraceDays = 30
+	}
+	initLoginProtection(config.LoginProtection)
+	TwoFactorIssuer = config.TwoFactor.Issuer
+	if TwoFactorIssuer == "" {
+		TwoFactorIssuer = os.Getenv(constants.COMPANY_NAME)
+	}
+	TwoFactorChallengeExpTime = config.TwoFactor.ChallengeExpMin
+	if TwoFactorChallengeExpTime <= 0 {
+		TwoFactorChallengeExpTime = 5
+	}
+	RecoveryCodeCount = config.TwoFactor.RecoveryCodeCount
+	if RecoveryCodeCount <= 0 {
+		RecoveryCodeCount = 10
+	}
+	AppBaseURL = strings.TrimRight(config.AppBaseURL, "/")
+	initPasswordHashing(config.PasswordHashCost)
+	initPasswordPolicy(config.PasswordPolicy)
+	initPhone(config.DefaultPhoneCountryCode)
+	initPagination(config.Pagination)
+	initMedia(config.Media)
+	initInventory(config.Inventory)
+	initCart(config.Cart)
 	otpLength = config.OTPLength
 	redisClient = connections.GetRedisClient()
 }
@@ -106,18 +145,27 @@ func CustomRecovery(context *gin.Context) {
 	}
 }
 
-// it is used to create a JWT token with claims.
-// It takes the data as input and returns the JWT token and a boolean indicating success or failure.
-func CreateJwtWithClaims(data any) (string, bool) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims[constants.USER_JWT_CLAIM_KEY] = data
+// it is used to create a JWT access token for the user.
+// It takes the user (with its Role loaded) and the session (refresh token family) id as input
+// and returns the JWT token and a boolean indicating success or failure.
+// Every token gets a unique id (jti) so it can be revoked individually.
+func CreateJwtWithClaims(user models.User, sessionID string) (string, bool) {
+	issuedAt := time.Now()
+	expirationTime := issuedAt.Add(time.Duration(ExpiryTime) * time.Minute)
 
-	// Set token expiration time (e.g., 1 hour from now)
-	expirationTime := time.Now().Add(time.Duration(ExpiryTime) * time.Minute)
-	claims["exp"] = expirationTime.Unix()
+	claims := &models.JWTClaims{
+		Role:      user.Role.Code,
+		Scope:     constants.ACCESS_TOKEN_SCOPE,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   user.UserID.String(),
+			Id:        uuid.New().String(),
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expirationTime.Unix(),
+		},
+	}
 
-	jwtToken, err := token.SignedString([]byte(os.Getenv(constants.SECRETE_KEY)))
+	jwtToken, err := SignJwt(claims)
 	if err != nil {
 		return "Failed to generate auth token", false
 	}
@@ -125,6 +173,44 @@ func CreateJwtWithClaims(data any) (string, bool) {
 	return jwtToken, true
 }
 
+// CreateTwoFactorChallenge creates the short-lived token returned by the login
+// when the user has 2FA enabled. Its scope is not accepted by the auth middleware,
+// it can only be exchanged for the access token on /login/2fa.
+// The login method of the first factor is kept in the token for the login history.
+func CreateTwoFactorChallenge(user models.User, loginMethod string) (string, time.Time, error) {
+	issuedAt := time.Now()
+	expirationTime := issuedAt.Add(time.Duration(TwoFactorChallengeExpTime) * time.Minute)
+
+	claims := &models.JWTClaims{
+		Scope:       constants.TWO_FACTOR_CHALLENGE_SCOPE,
+		LoginMethod: loginMethod,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   user.UserID.String(),
+			Id:        uuid.New().String(),
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expirationTime.Unix(),
+		},
+	}
+
+	token, err := SignJwt(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expirationTime, nil
+}
+
+// ParseTwoFactorChallenge verifies a token created by CreateTwoFactorChallenge
+// which has not been used yet.
+func ParseTwoFactorChallenge(token string) (*models.JWTClaims, error) {
+	claims, err := ParseJwt(token)
+	if err != nil || claims.Scope != constants.TWO_FACTOR_CHALLENGE_SCOPE || IsAccessTokenDenied(claims.Id) {
+		return nil, fmt.Errorf("the login session is invalid or has expired, please login again")
+	}
+
+	return claims, nil
+}
+
 // it is used to write the response to the client.
 // It takes the context, status code, and data as input.
 func ResponseWriter[T any](cxt *gin.Context, status int, data T) {
@@ -143,23 +229,43 @@ func ResponseWriter[T any](cxt *gin.Context, status int, data T) {
 	cxt.JSON(status, response)
 }
 
-// GeneratePassword generates a random password of the specified length.
-// It includes uppercase letters, lowercase letters, digits, and special characters.
-func GeneratePassword() string {
-	const charset = "abcdefghijklmnopqrstuvwxyz" +
-		"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"0123456789" +
-		"!@#$%^&*()-_=+[]{}<>?/"
+// PaginatedResponseWriter writes a successful response with a page of a list
+// and its pagination metadata (total, next cursor).
+func PaginatedResponseWriter[T any](cxt *gin.Context, data []T, pagination models.Pagination) {
+	if data == nil {
+		data = []T{}
+	}
+
+	cxt.JSON(http.StatusOK, models.ResponseWithPagination{
+		Response: models.Response{
+			Success: true,
+			Data:    data,
+		},
+		Pagination: pagination,
+	})
+}
 
-	// Create a new random generator with its own seed
-	r := mathRand.New(mathRand.NewSource(time.Now().UnixNano()))
+// FacetedResponseWriter writes a successful response with a page of a list,
+// its pagination metadata and the facets of the whole list.
+func FacetedResponseWriter[T any](cxt *gin.Context, data []T, pagination models.Pagination, facets []models.Facet) {
+	if data == nil {
+		data = []T{}
+	}
 
-	// Create the password
-	password := make([]byte, passwordLength)
-	for i := range password {
-		password[i] = charset[r.Intn(len(charset))]
+	if facets == nil {
+		facets = []models.Facet{}
 	}
-	return string(password)
+
+	cxt.JSON(http.StatusOK, models.ResponseWithFacets{
+		ResponseWithPagination: models.ResponseWithPagination{
+			Response: models.Response{
+				Success: true,
+				Data:    data,
+			},
+			Pagination: pagination,
+		},
+		Facets: facets,
+	})
 }
 
 // get email verification email format (subject, emailbody)
@@ -174,17 +280,81 @@ func GetEmailVerificationFormat(emailToName string, otp string, isHtml bool) (st
 	}
 }
 
-// get login credential email format (subject, emailbody)
-func GetCredentialEmailFormat(emailToName string, userID string, password string, isHtml bool) (string, string) {
+// get password reset email format (subject, emailbody)
+func GetPasswordResetEmailFormat(emailToName string, otp string, isHtml bool) (string, string) {
+	companyName := os.Getenv(constants.COMPANY_NAME)
+	subject := fmt.Sprintf(constants.PASSWORD_RESET_EMAIL_SUBJECT, companyName)
+	if isHtml {
+		currentYear := time.Now().Year()
+		return subject, fmt.Sprintf(constants.PASSWORD_RESET_EMAIL_FORMAT_HTML, companyName, emailToName, otp, OtpExpTime, currentYear)
+	} else {
+		return subject, fmt.Sprintf(constants.PASSWORD_RESET_EMAIL_FORMAT_TXT, emailToName, companyName, otp, OtpExpTime, companyName)
+	}
+}
+
+// get re-authentication email format (subject, emailbody)
+func GetReauthenticationEmailFormat(emailToName string, otp string, isHtml bool) (string, string) {
+	companyName := os.Getenv(constants.COMPANY_NAME)
+	subject := fmt.Sprintf(constants.REAUTHENTICATION_EMAIL_SUBJECT, companyName)
+	if isHtml {
+		currentYear := time.Now().Year()
+		return subject, fmt.Sprintf(constants.REAUTHENTICATION_EMAIL_FORMAT_HTML, companyName, emailToName, otp, OtpExpTime, currentYear)
+	} else {
+		return subject, fmt.Sprintf(constants.REAUTHENTICATION_EMAIL_FORMAT_TXT, emailToName, companyName, otp, OtpExpTime, companyName)
+	}
+}
+
+// get account locked email format (subject, emailbody)
+func GetAccountLockedEmailFormat(emailToName string, isHtml bool) (string, string) {
 	companyName := os.Getenv(constants.COMPANY_NAME)
-	subject := fmt.Sprintf(constants.SHARE_CREDENTIAL_EMAIL_SUBJECT, companyName)
+	subject := fmt.Sprintf(constants.ACCOUNT_LOCKED_EMAIL_SUBJECT, companyName)
 	if isHtml {
 		currentYear := time.Now().Year()
-		return subject, fmt.Sprintf(constants.SHARE_CREDENTIAL_EMAIL_FORMAT_HTML, companyName, emailToName, userID, password, companyName, currentYear)
+		return subject, fmt.Sprintf(constants.ACCOUNT_LOCKED_EMAIL_FORMAT_HTML, companyName, emailToName, loginProtection.LockoutMin, currentYear)
 	} else {
-		return subject, fmt.Sprintf(constants.SHARE_CREDENTIAL_EMAIL_FORMAT_TXT, emailToName, companyName, userID, password, companyName, companyName)
+		return subject, fmt.Sprintf(constants.ACCOUNT_LOCKED_EMAIL_FORMAT_TXT, emailToName, companyName, loginProtection.LockoutMin, companyName)
 	}
+}
+
+// get account deletion email format (subject, emailbody)
+func GetAccountDeletionEmailFormat(emailToName string, deletionDate string, isHtml bool) (string, string) {
+	companyName := os.Getenv(constants.COMPANY_NAME)
+	subject := fmt.Sprintf(constants.ACCOUNT_DELETION_EMAIL_SUBJECT, companyName)
+	if isHtml {
+		currentYear := time.Now().Year()
+		return subject, fmt.Sprintf(constants.ACCOUNT_DELETION_EMAIL_FORMAT_HTML, companyName, emailToName, deletionDate, currentYear)
+	} else {
+		return subject, fmt.Sprintf(constants.ACCOUNT_DELETION_EMAIL_FORMAT_TXT, emailToName, companyName, deletionDate, companyName)
+	}
+}
+
+// get email change notice format (subject, emailbody), sent to the old address
+func GetEmailChangeNoticeFormat(emailToName string, newEmail string, isHtml bool) (string, string) {
+	companyName := os.Getenv(constants.COMPANY_NAME)
+	subject := fmt.Sprintf(constants.EMAIL_CHANGE_NOTICE_EMAIL_SUBJECT, companyName)
+	if isHtml {
+		currentYear := time.Now().Year()
+		return subject, fmt.Sprintf(constants.EMAIL_CHANGE_NOTICE_EMAIL_FORMAT_HTML, companyName, emailToName, newEmail, currentYear)
+	} else {
+		return subject, fmt.Sprintf(constants.EMAIL_CHANGE_NOTICE_EMAIL_FORMAT_TXT, emailToName, companyName, newEmail, companyName)
+	}
+}
+
+// get set password email format (subject, emailbody)
+func GetSetPasswordEmailFormat(emailToName string, link string, isHtml bool) (string, string) {
+	companyName := os.Getenv(constants.COMPANY_NAME)
+	subject := fmt.Sprintf(constants.SET_PASSWORD_EMAIL_SUBJECT, companyName)
+	if isHtml {
+		currentYear := time.Now().Year()
+		return subject, fmt.Sprintf(constants.SET_PASSWORD_EMAIL_FORMAT_HTML, companyName, emailToName, link, SetPasswordTokenExpTime, companyName, currentYear)
+	} else {
+		return subject, fmt.Sprintf(constants.SET_PASSWORD_EMAIL_FORMAT_TXT, emailToName, companyName, link, SetPasswordTokenExpTime, companyName, companyName)
+	}
+}
 
+// GetSetPasswordLink builds the frontend link used to set the password with the given token.
+func GetSetPasswordLink(token string) string {
+	return fmt.Sprintf("%s%s?token=%s", AppBaseURL, constants.SET_PASSWORD_PATH, url.QueryEscape(token))
 }
 
 // GenerateOTP generates a numeric OTP of the specified length
@@ -205,3 +375,50 @@ func GenerateSecureOTP() string {
 
 	return string(otp)
 }
+
+// GenerateSecureToken generates a random, URL safe token
+// suitable for single-use links (set password, reset password etc.)
+func GenerateSecureToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := cryptRand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// HashToken returns the SHA-256 hex digest of the token.
+// Only the digest is stored, so a leaked cache does not leak usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetLoggedInUser returns the user set in the context by the auth middleware.
+func GetLoggedInUser(context *gin.Context) (models.User, bool) {
+	userDetails, exists := context.Get(constants.USER_DATA_CONTEXT_KEY)
+	if !exists {
+		return models.User{}, false
+	}
+
+	user, ok := userDetails.(models.User)
+	return user, ok
+}
+
+// GetClientInfo returns the IP address and the user agent of the client of the request.
+func GetClientInfo(context *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		IP:        context.ClientIP(),
+		UserAgent: context.Request.UserAgent(),
+	}
+}
+
+// GetAccessTokenData returns the details of the access token set in the context by the auth middleware.
+func GetAccessTokenData(context *gin.Context) (models.AccessTokenData, bool) {
+	tokenData, exists := context.Get(constants.TOKEN_DATA_CONTEXT_KEY)
+	if !exists {
+		return models.AccessTokenData{}, false
+	}
+
+	data, ok := tokenData.(models.AccessTokenData)
+	return data, ok
+}
@@ -0,0 +1,112 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"e-commerce/shared/models"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	// the decoders of the accepted image formats
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageExtensions are the accepted image formats, by content type, with the extension of their files.
+var ImageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// MaxImageUploadSize is the largest image file accepted, in bytes.
+var MaxImageUploadSize int64 = 10 << 20
+
+// MaxImagePixels is the largest image accepted (width x height), checked before the image is decoded.
+var MaxImagePixels = 40_000_000
+
+// ThumbnailSizes are the sizes of the squares the thumbnails of the images fit in, in pixels.
+var ThumbnailSizes = []int{160, 480, 1024}
+
+// SignedURLExpiry is how long the signed URLs of the stored files stay valid.
+var SignedURLExpiry = time.Hour
+
+// initMedia sets the limits of the image uploads and the thumbnail sizes.
+func initMedia(config models.MediaConfig) {
+	if config.MaxUploadMB > 0 {
+		MaxImageUploadSize = int64(config.MaxUploadMB) << 20
+	}
+	if config.MaxPixels > 0 {
+		MaxImagePixels = config.MaxPixels
+	}
+	if len(config.ThumbnailSizes) > 0 {
+		ThumbnailSizes = slices.Clone(config.ThumbnailSizes)
+		slices.Sort(ThumbnailSizes)
+	}
+	if config.SignedURLExpMin > 0 {
+		SignedURLExpiry = time.Duration(config.SignedURLExpMin) * time.Minute
+	}
+}
+
+// DetectImageType sniffs the content type of an image from its first bytes, whatever the type
+// declared by the client, without consuming them. Only the formats of ImageExtensions are accepted.
+func DetectImageType(reader *bufio.Reader) (string, error) {
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(head)
+	if _, ok := ImageExtensions[contentType]; !ok {
+		return "", fmt.Errorf("unsupported file type %s, expects a JPEG, PNG, GIF or WebP image", contentType)
+	}
+
+	return contentType, nil
+}
+
+// Thumbnail scales the image down to fit in a size x size square, keeping its aspect ratio.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Src, nil)
+	return thumbnail
+}
+
+// ThumbnailType returns the content type and the extension of the thumbnails of an image:
+// JPEG for the JPEG images and PNG for the other formats, which may be transparent.
+func ThumbnailType(contentType string) (string, string) {
+	if contentType == "image/jpeg" {
+		return "image/jpeg", "jpg"
+	}
+	return "image/png", "png"
+}
+
+// EncodeThumbnail encodes a thumbnail in the format given by ThumbnailType.
+func EncodeThumbnail(thumbnail image.Image, contentType string) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+
+	if thumbnailType, _ := ThumbnailType(contentType); thumbnailType == "image/jpeg" {
+		err = jpeg.Encode(&buffer, thumbnail, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buffer, thumbnail)
+	}
+
+	return buffer.Bytes(), err
+}
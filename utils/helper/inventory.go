@@ -0,0 +1,28 @@
+package helper
+
+import (
+	"e-commerce/shared/models"
+	"time"
+)
+
+// ReservationTTL is how long a stock reservation holds the stock when no other lifetime is requested.
+var ReservationTTL = 15 * time.Minute
+
+// MaxReservationTTL is the longest lifetime a stock reservation can be given.
+var MaxReservationTTL = 2 * time.Hour
+
+// LowStockThreshold is the available quantity at or below which a stock level without its own threshold is low.
+var LowStockThreshold int64 = 5
+
+// initInventory sets the lifetimes of the stock reservations and the default low stock threshold.
+func initInventory(config models.InventoryConfig) {
+	if config.ReservationTTLMin > 0 {
+		ReservationTTL = time.Duration(config.ReservationTTLMin) * time.Minute
+	}
+	if config.MaxReservationTTLMin > 0 {
+		MaxReservationTTL = time.Duration(config.MaxReservationTTLMin) * time.Minute
+	}
+	if config.LowStockThreshold > 0 {
+		LowStockThreshold = int64(config.LowStockThreshold)
+	}
+}
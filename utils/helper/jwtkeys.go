@@ -0,0 +1,293 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	cryptRand "crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"e-commerce/shared/models"
+	"e-commerce/utils/constants"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// signingKey is a JWT signing key loaded from a PEM file.
+// A key signs new tokens from ActiveFrom until the next key becomes active,
+// and keeps verifying tokens for the overlap window after that.
+type signingKey struct {
+	KeyID      string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+	ActiveFrom time.Time
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served on the JWKS endpoint.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var jwtIssuer string
+var rotationOverlap time.Duration
+
+// signingKeys are sorted by ActiveFrom (oldest first)
+var signingKeys []signingKey
+
+// InitJwtKeys loads the JWT signing keys from the configured PEM files.
+// In the local environment a missing key file is generated, so the application
+// can be started without any setup; everywhere else it is an error.
+func InitJwtKeys(config models.JwtConfig) error {
+	jwtIssuer = config.Issuer
+	rotationOverlap = time.Duration(config.RotationOverlapMin) * time.Minute
+
+	// tokens signed just before a rotation must stay verifiable until they expire
+	if minOverlap := time.Duration(ExpiryTime) * time.Minute; rotationOverlap < minOverlap {
+		rotationOverlap = minOverlap
+	}
+
+	if len(config.Keys) == 0 {
+		return fmt.Errorf("no jwt signing keys configured")
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]signingKey, 0, len(config.Keys))
+	keyIDs := map[string]bool{}
+
+	for _, keyConfig := range config.Keys {
+		if keyConfig.KeyID == "" || keyIDs[keyConfig.KeyID] {
+			return fmt.Errorf("jwt keys must have a unique kid")
+		}
+		keyIDs[keyConfig.KeyID] = true
+
+		activeFrom, err := time.Parse(time.RFC3339, keyConfig.ActiveFrom)
+		if err != nil {
+			return fmt.Errorf("invalid active_from for jwt key (%s): %w", keyConfig.KeyID, err)
+		}
+
+		keyFile := filepath.Join(currentDir, keyConfig.PrivateKeyFile)
+		key, err := loadSigningKey(keyFile, keyConfig.Algorithm)
+		if errors.Is(err, os.ErrNotExist) && IsLocalEnv() {
+			fmt.Printf("jwt key file (%s) not found, generating a new %s key\n", keyConfig.PrivateKeyFile, keyConfig.Algorithm)
+			err = generateSigningKeyFile(keyFile, keyConfig.Algorithm)
+			if err == nil {
+				key, err = loadSigningKey(keyFile, keyConfig.Algorithm)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load jwt key (%s): %w", keyConfig.KeyID, err)
+		}
+
+		key.KeyID = keyConfig.KeyID
+		key.ActiveFrom = activeFrom
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ActiveFrom.Before(keys[j].ActiveFrom)
+	})
+
+	if keys[0].ActiveFrom.After(time.Now()) {
+		return fmt.Errorf("none of the jwt keys is active yet")
+	}
+
+	signingKeys = keys
+	return nil
+}
+
+// SignJwt signs the claims with the currently active key.
+// The key id is set in the kid header, so verifiers can pick the right public key.
+func SignJwt(claims *models.JWTClaims) (string, error) {
+	key, err := currentSigningKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	claims.Issuer = jwtIssuer
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KeyID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// ParseJwt verifies the token signature with the key referenced by its kid header
+// and validates the standard time based claims and the issuer.
+func ParseJwt(tokenString string) (*models.JWTClaims, error) {
+	now := time.Now()
+
+	jwtToken, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, func(jwtToken *jwt.Token) (any, error) {
+		keyID, _ := jwtToken.Header["kid"].(string)
+
+		for _, key := range verificationKeys(now) {
+			if key.KeyID != keyID {
+				continue
+			}
+
+			// the algorithm must be the one of the key, never the one chosen by the token
+			if jwtToken.Method.Alg() != key.Method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %s", jwtToken.Method.Alg())
+			}
+
+			return key.PublicKey, nil
+		}
+
+		return nil, fmt.Errorf("unknown signing key: %s", keyID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := jwtToken.Claims.(*models.JWTClaims)
+	if !ok || !jwtToken.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if jwtIssuer != "" && !claims.VerifyIssuer(jwtIssuer, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+
+	return claims, nil
+}
+
+// GetJWKS returns the public keys which can verify tokens right now,
+// including the keys scheduled to become active, so verifiers can cache them in advance.
+func GetJWKS() JWKSet {
+	keySet := JWKSet{Keys: []JWK{}}
+
+	for _, key := range verificationKeys(time.Now()) {
+		jwk := JWK{
+			KeyID:     key.KeyID,
+			Use:       "sig",
+			Algorithm: key.Method.Alg(),
+		}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		keySet.Keys = append(keySet.Keys, jwk)
+	}
+
+	return keySet
+}
+
+// currentSigningKey returns the most recently activated key.
+func currentSigningKey(now time.Time) (signingKey, error) {
+	for i := len(signingKeys) - 1; i >= 0; i-- {
+		if !signingKeys[i].ActiveFrom.After(now) {
+			return signingKeys[i], nil
+		}
+	}
+	return signingKey{}, fmt.Errorf("no active jwt signing key")
+}
+
+// verificationKeys returns the keys accepted for verification: the active key,
+// the keys scheduled for the future and the previous keys still inside the overlap window.
+func verificationKeys(now time.Time) []signingKey {
+	var keys []signingKey
+	for i, key := range signingKeys {
+		if i+1 < len(signingKeys) && signingKeys[i+1].ActiveFrom.Add(rotationOverlap).Before(now) {
+			// retired: the next key is active for longer than the overlap window
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// loadSigningKey reads the PEM encoded private key and derives its public key.
+func loadSigningKey(keyFile string, algorithm string) (signingKey, error) {
+	pemData, err := os.ReadFile(keyFile)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	switch algorithm {
+	case constants.JWT_ALGORITHM_RS256:
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return signingKey{}, err
+		}
+		return signingKey{Method: jwt.SigningMethodRS256, PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}, nil
+	case constants.JWT_ALGORITHM_EDDSA:
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return signingKey{}, err
+		}
+		edKey := privateKey.(ed25519.PrivateKey)
+		return signingKey{Method: jwt.SigningMethodEdDSA, PrivateKey: edKey, PublicKey: edKey.Public()}, nil
+	default:
+		return signingKey{}, fmt.Errorf("unsupported jwt algorithm (%s), expects %s or %s", algorithm, constants.JWT_ALGORITHM_RS256, constants.JWT_ALGORITHM_EDDSA)
+	}
+}
+
+// generateSigningKeyFile creates a new PKCS8 PEM encoded private key for the algorithm.
+func generateSigningKeyFile(keyFile string, algorithm string) error {
+	var privateKey any
+	var err error
+
+	switch algorithm {
+	case constants.JWT_ALGORITHM_RS256:
+		privateKey, err = rsa.GenerateKey(cryptRand.Reader, 2048)
+	case constants.JWT_ALGORITHM_EDDSA:
+		_, privateKey, err = ed25519.GenerateKey(cryptRand.Reader)
+	default:
+		return fmt.Errorf("unsupported jwt algorithm (%s), expects %s or %s", algorithm, constants.JWT_ALGORITHM_RS256, constants.JWT_ALGORITHM_EDDSA)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+}
+
+// IsLocalEnv reports whether the application runs in the local (development) environment.
+func IsLocalEnv() bool {
+	env := strings.ToLower(os.Getenv(constants.APP_ENV))
+	return env == "" || env == constants.LOCAL_ENV
+}
@@ -0,0 +1,257 @@
+package helper
+
+import (
+	"bytes"
+	"e-commerce/shared/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultPageSize is the page size of the list endpoints when no limit is given.
+var DefaultPageSize = 20
+
+// MaxPageSize is the largest page size, larger limits are capped to it.
+var MaxPageSize = 100
+
+// initPagination sets the default and the maximum page size of the list endpoints.
+func initPagination(config models.PaginationConfig) {
+	if config.MaxLimit > 0 {
+		MaxPageSize = config.MaxLimit
+	}
+	if config.DefaultLimit > 0 {
+		DefaultPageSize = min(config.DefaultLimit, MaxPageSize)
+	}
+}
+
+// SortField is one column of the sort order of a list.
+type SortField struct {
+	// name of the column in the sort parameter
+	Key    string
+	Column string
+	Desc   bool
+}
+
+// PageRequest is a validated page of a list: its size, its sort order and
+// either its number or the sort values of the last row of the previous page (cursor).
+type PageRequest struct {
+	Page  int
+	Limit int
+	Sort  []SortField
+	// sort values of the last row of the previous page, nil without cursor
+	After []any
+}
+
+// pageCursor is the content of the opaque cursors of the list endpoints.
+type pageCursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+}
+
+// ParsePageQuery validates the pagination parameters of a list endpoint.
+//
+// Parameters:
+//
+//	query (models.PageQuery): The pagination query parameters.
+//	columns (map[string]string): The sortable columns, by the name used in the sort parameter.
+//	tiebreaker (string): A unique sort key (e.g. the id) appended to every sort order,
+//	                     so the order is stable and the cursors point to a single row.
+//
+// Returns:
+//
+//	PageRequest: The requested page.
+//	error: An error if a sort column is not allowed or the cursor is invalid.
+func ParsePageQuery(query models.PageQuery, columns map[string]string, tiebreaker string) (PageRequest, error) {
+	request := PageRequest{Page: max(query.Page, 1), Limit: query.Limit}
+
+	if request.Limit <= 0 {
+		request.Limit = DefaultPageSize
+	}
+	request.Limit = min(request.Limit, MaxPageSize)
+
+	for _, key := range strings.Split(query.Sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		field := SortField{Key: strings.TrimLeft(key, "+-"), Desc: strings.HasPrefix(key, "-")}
+
+		column, ok := columns[field.Key]
+		if !ok {
+			allowed := make([]string, 0, len(columns))
+			for name := range columns {
+				allowed = append(allowed, name)
+			}
+			slices.Sort(allowed)
+			return PageRequest{}, fmt.Errorf("can not sort by %q, allowed columns are: %s", field.Key, strings.Join(allowed, ", "))
+		}
+
+		if slices.ContainsFunc(request.Sort, func(sorted SortField) bool { return sorted.Key == field.Key }) {
+			return PageRequest{}, fmt.Errorf("the column %q is given more than once in sort", field.Key)
+		}
+
+		field.Column = column
+		request.Sort = append(request.Sort, field)
+	}
+
+	if !slices.ContainsFunc(request.Sort, func(sorted SortField) bool { return sorted.Key == tiebreaker }) {
+		request.Sort = append(request.Sort, SortField{Key: tiebreaker, Column: columns[tiebreaker]})
+	}
+
+	if query.Cursor == "" {
+		return request, nil
+	}
+
+	if query.Page > 1 {
+		return PageRequest{}, fmt.Errorf("please provide either a page or a cursor, not both")
+	}
+
+	after, err := request.decodeCursor(query.Cursor)
+	if err != nil {
+		return PageRequest{}, err
+	}
+
+	request.Page = 0
+	request.After = after
+
+	return request, nil
+}
+
+// Offset returns the number of rows before the page (0 when a cursor is used).
+func (request PageRequest) Offset() int {
+	if request.After != nil {
+		return 0
+	}
+	return (request.Page - 1) * request.Limit
+}
+
+// OrderBy returns the ORDER BY clause of the sort order.
+func (request PageRequest) OrderBy() string {
+	columns := make([]string, 0, len(request.Sort))
+	for _, field := range request.Sort {
+		if field.Desc {
+			columns = append(columns, field.Column+" DESC")
+		} else {
+			columns = append(columns, field.Column)
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// KeysetCondition returns the WHERE condition selecting the rows after the cursor,
+// or an empty condition when no cursor is given.
+func (request PageRequest) KeysetCondition() (string, []any) {
+	if request.After == nil {
+		return "", nil
+	}
+
+	// (a > ?) OR (a = ? AND b < ?) OR ... for the sort order "a,-b,..."
+	var conditions []string
+	var args []any
+
+	for i, field := range request.Sort {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, request.Sort[j].Column+" = ?")
+			args = append(args, request.After[j])
+		}
+
+		operator := ">"
+		if field.Desc {
+			operator = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", field.Column, operator))
+		args = append(args, request.After[i])
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// NextCursor returns the cursor of the page following a full page.
+//
+// Parameters:
+//
+//	count (int): The number of rows in the current page.
+//	values (map[string]any): The sort values of the last row of the page, by sort key.
+//
+// Returns:
+//
+//	string: The opaque cursor, empty if the current page is the last one.
+func (request PageRequest) NextCursor(count int, values map[string]any) string {
+	if count < request.Limit {
+		return ""
+	}
+
+	cursor := pageCursor{Sort: request.sortKey()}
+	for _, field := range request.Sort {
+		cursor.Values = append(cursor.Values, values[field.Key])
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Pagination returns the pagination metadata of the page for the response.
+func (request PageRequest) Pagination(total int64, nextCursor string) models.Pagination {
+	return models.Pagination{
+		Page:       request.Page,
+		Limit:      request.Limit,
+		Total:      int(total),
+		NextCursor: nextCursor,
+	}
+}
+
+// decodeCursor returns the sort values stored in the cursor.
+// A cursor is only valid for the sort order it was created with.
+func (request PageRequest) decodeCursor(encoded string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("the cursor is invalid")
+	}
+
+	var cursor pageCursor
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&cursor); err != nil || len(cursor.Values) != len(request.Sort) {
+		return nil, fmt.Errorf("the cursor is invalid")
+	}
+
+	if cursor.Sort != request.sortKey() {
+		return nil, fmt.Errorf("the cursor does not match the sort order, please start again from the first page")
+	}
+
+	for i, value := range cursor.Values {
+		if value == nil {
+			return nil, fmt.Errorf("the cursor is invalid")
+		}
+		if number, ok := value.(json.Number); ok {
+			cursor.Values[i] = number.String()
+		}
+	}
+
+	return cursor.Values, nil
+}
+
+// sortKey returns the sort order in the format of the sort parameter (e.g. "-created_at,user_id").
+func (request PageRequest) sortKey() string {
+	keys := make([]string, 0, len(request.Sort))
+	for _, field := range request.Sort {
+		if field.Desc {
+			keys = append(keys, "-"+field.Key)
+		} else {
+			keys = append(keys, field.Key)
+		}
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,170 @@
+package helper
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"e-commerce/shared/models"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var passwordHashCost int
+var passwordPolicy models.PasswordPolicy
+var passwordBlocklist = map[string]bool{}
+
+// dummyPasswordHash is compared against when the user does not exist,
+// so a failed login takes the same time whether or not the email is registered.
+var dummyPasswordHash []byte
+
+// initPasswordHashing sets the bcrypt cost and prepares the dummy hash with the same cost.
+func initPasswordHashing(cost int) {
+	passwordHashCost = cost
+	dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), getPasswordHashCost())
+}
+
+// HashPassword hashes the plain text password using bcrypt with the configured cost.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), getPasswordHashCost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// VerifyPassword compares the stored password with the provided one in constant time.
+// Stored values which are not bcrypt hashes are treated as legacy plain text passwords.
+// It returns whether the password matches and whether the stored value should be rehashed
+// (legacy plain text or hashed with a different cost than the configured one).
+func VerifyPassword(storedPassword string, password string) (bool, bool) {
+	// users created by an admin have no password until they set one
+	if storedPassword == "" {
+		return false, false
+	}
+
+	if !IsPasswordHashed(storedPassword) {
+		match := subtle.ConstantTimeCompare([]byte(storedPassword), []byte(password)) == 1
+		return match, match
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password)); err != nil {
+		return false, false
+	}
+
+	cost, err := bcrypt.Cost([]byte(storedPassword))
+	return true, err != nil || cost != getPasswordHashCost()
+}
+
+// FakePasswordCheck burns the same amount of time as a real password verification.
+// It is used when the user is not found, to avoid leaking registered emails by timing.
+func FakePasswordCheck(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// IsPasswordHashed reports whether the stored value is a bcrypt hash.
+func IsPasswordHashed(storedPassword string) bool {
+	if !strings.HasPrefix(storedPassword, "$2") {
+		return false
+	}
+	_, err := bcrypt.Cost([]byte(storedPassword))
+	return err == nil
+}
+
+func getPasswordHashCost() int {
+	if passwordHashCost < bcrypt.MinCost || passwordHashCost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return passwordHashCost
+}
+
+// initPasswordPolicy sets the password policy and loads the breached password blocklist.
+// A missing blocklist file is reported but does not stop the application.
+func initPasswordPolicy(policy models.PasswordPolicy) {
+	passwordPolicy = policy
+	passwordBlocklist = map[string]bool{}
+
+	if policy.BlocklistFile == "" {
+		return
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("failed to get current working directory: %v\n", err)
+		return
+	}
+
+	file, err := os.Open(filepath.Join(currentDir, policy.BlocklistFile))
+	if err != nil {
+		fmt.Printf("failed to open password blocklist file (%s): %v\n", policy.BlocklistFile, err)
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwordBlocklist[strings.ToLower(line)] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("failed to read password blocklist file (%s): %v\n", policy.BlocklistFile, err)
+	}
+}
+
+// ValidatePassword checks the password against the configured password policy.
+// It returns an error describing the first rule the password does not satisfy.
+func ValidatePassword(password string) error {
+	length := len([]rune(password))
+
+	if passwordPolicy.MinLength > 0 && length < passwordPolicy.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", passwordPolicy.MinLength)
+	}
+
+	// bcrypt only uses the first 72 bytes of the password
+	if (passwordPolicy.MaxLength > 0 && length > passwordPolicy.MaxLength) || len(password) > 72 {
+		return fmt.Errorf("password is too long")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSpecial = true
+		}
+	}
+
+	if passwordPolicy.RequireUpper && !hasUpper {
+		return fmt.Errorf("password must contain at least one uppercase letter")
+	}
+
+	if passwordPolicy.RequireLower && !hasLower {
+		return fmt.Errorf("password must contain at least one lowercase letter")
+	}
+
+	if passwordPolicy.RequireDigit && !hasDigit {
+		return fmt.Errorf("password must contain at least one digit")
+	}
+
+	if passwordPolicy.RequireSpecial && !hasSpecial {
+		return fmt.Errorf("password must contain at least one special character")
+	}
+
+	if passwordBlocklist[strings.ToLower(password)] {
+		return fmt.Errorf("this password is too common or has appeared in a data breach, please choose another one")
+	}
+
+	return nil
+}
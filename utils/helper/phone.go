@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPhoneCountryCode is prefixed to the phone numbers given without a country code.
+var DefaultPhoneCountryCode = "+91"
+
+// initPhone sets the country code of the numbers given without one (e.g. "+91").
+func initPhone(countryCode string) {
+	countryCode = strings.TrimSpace(countryCode)
+	if countryCode == "" {
+		return
+	}
+	if !strings.HasPrefix(countryCode, "+") {
+		countryCode = "+" + countryCode
+	}
+	DefaultPhoneCountryCode = countryCode
+}
+
+// NormalizePhone returns the phone number in the E.164 format (+<country code><number>).
+// Spaces, dashes, dots and parentheses are ignored; an international "00" prefix is read as "+",
+// and a number without country code gets DefaultPhoneCountryCode (dropping the trunk prefix 0).
+func NormalizePhone(phone string) (string, error) {
+	cleaned := strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(cleaned, "+"):
+	case strings.HasPrefix(cleaned, "00"):
+		cleaned = "+" + strings.TrimPrefix(cleaned, "00")
+	default:
+		cleaned = DefaultPhoneCountryCode + strings.TrimLeft(cleaned, "0")
+	}
+
+	digits := cleaned[1:]
+	for _, char := range digits {
+		if char < '0' || char > '9' {
+			return "", fmt.Errorf("the phone number (%s) is invalid, only digits are allowed", phone)
+		}
+	}
+
+	// E.164: at most 15 digits, the country code does not start with 0
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", fmt.Errorf("the phone number (%s) is invalid, please provide it with the country code (e.g. +919876543210)", phone)
+	}
+
+	return cleaned, nil
+}
+
+// MaskPhone hides all but the last digits of the phone number, e.g. "+91******3210".
+func MaskPhone(phone string) string {
+	if len(phone) <= 7 {
+		return phone
+	}
+	return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+}
@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// postalCodeFormats are the postal code formats of the countries (ISO 3166-1 alpha-2),
+// after normalisation by NormalizePostalCode.
+var postalCodeFormats = map[string]*regexp.Regexp{
+	"AU": regexp.MustCompile(`^[0-9]{4}$`),
+	"BR": regexp.MustCompile(`^[0-9]{5}-[0-9]{3}$`),
+	"CA": regexp.MustCompile(`^[A-Z][0-9][A-Z] [0-9][A-Z][0-9]$`),
+	"CN": regexp.MustCompile(`^[0-9]{6}$`),
+	"DE": regexp.MustCompile(`^[0-9]{5}$`),
+	"ES": regexp.MustCompile(`^[0-9]{5}$`),
+	"FR": regexp.MustCompile(`^[0-9]{5}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? [0-9][A-Z]{2}$`),
+	"IN": regexp.MustCompile(`^[1-9][0-9]{5}$`),
+	"IT": regexp.MustCompile(`^[0-9]{5}$`),
+	"JP": regexp.MustCompile(`^[0-9]{3}-[0-9]{4}$`),
+	"NL": regexp.MustCompile(`^[1-9][0-9]{3} [A-Z]{2}$`),
+	"SG": regexp.MustCompile(`^[0-9]{6}$`),
+	"US": regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`),
+}
+
+// genericPostalCode is accepted for the countries without a known format.
+var genericPostalCode = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+
+// NormalizePostalCode checks the postal code against the format of the country and returns it
+// in its canonical form: upper case, single spaces, and the separator of the country
+// (e.g. "nw16xe" becomes "NW1 6XE" in GB, "1000001" becomes "100-0001" in JP).
+func NormalizePostalCode(country string, postalCode string) (string, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	postalCode = strings.Join(strings.Fields(strings.ToUpper(postalCode)), " ")
+
+	compact := strings.NewReplacer(" ", "", "-", "").Replace(postalCode)
+
+	switch country {
+	case "GB", "CA", "NL":
+		// the inward code is always the last 3 (GB, CA) or 2 (NL) characters
+		inward := 3
+		if country == "NL" {
+			inward = 2
+		}
+		if len(compact) > inward {
+			postalCode = compact[:len(compact)-inward] + " " + compact[len(compact)-inward:]
+		}
+	case "JP":
+		if len(compact) == 7 {
+			postalCode = compact[:3] + "-" + compact[3:]
+		}
+	case "BR":
+		if len(compact) == 8 {
+			postalCode = compact[:5] + "-" + compact[5:]
+		}
+	case "US":
+		if len(compact) == 9 {
+			postalCode = compact[:5] + "-" + compact[5:]
+		}
+	case "AU", "CN", "DE", "ES", "FR", "IN", "IT", "SG":
+		postalCode = compact
+	}
+
+	format, ok := postalCodeFormats[country]
+	if !ok {
+		format = genericPostalCode
+	}
+
+	if !format.MatchString(postalCode) {
+		return "", fmt.Errorf("the postal code %q is not valid for the country %s", postalCode, country)
+	}
+
+	return postalCode, nil
+}
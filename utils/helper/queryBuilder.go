@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"e-commerce/shared/models"
 	"fmt"
 	"reflect"
 	"strings"
@@ -9,7 +10,9 @@ import (
 )
 
 // BuildQuery builds a GORM query based on the provided filter struct.
-// It uses struct tags to determine the column names and query operators.
+// It uses struct tags to determine the column names and query operators;
+// fields tagged with query:"-" (e.g. the pagination parameters) are skipped,
+// and the models.FilterExpr fields use the operator given in the request (e.g. status=in:draft,active).
 func BuildQuery(db *gorm.DB, filter any) *gorm.DB {
 	v := reflect.ValueOf(filter)
 	t := reflect.TypeOf(filter)
@@ -23,7 +26,7 @@ func BuildQuery(db *gorm.DB, filter any) *gorm.DB {
 		field := t.Field(i)
 		valueField := v.Field(i)
 
-		if !valueField.CanInterface() {
+		if !valueField.CanInterface() || field.Tag.Get("query") == "-" {
 			continue
 		}
 
@@ -44,6 +47,13 @@ func BuildQuery(db *gorm.DB, filter any) *gorm.DB {
 			continue
 		}
 
+		// the operator of a filter expression comes from the request, the column still comes from the tag
+		if expr, ok := value.(models.FilterExpr); ok {
+			condition, args := FilterCondition(column, expr, expr.Args())
+			db = db.Where(condition, args...)
+			continue
+		}
+
 		switch op {
 		case "LIKE":
 			db = db.Where(fmt.Sprintf("%s LIKE ?", column), fmt.Sprintf("%%%v%%", value))
@@ -74,6 +84,35 @@ func BuildQuery(db *gorm.DB, filter any) *gorm.DB {
 	return db
 }
 
+// likeEscaper escapes the wildcards of the LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FilterCondition translates a filter expression into a WHERE clause on the column.
+// The column always comes from the code and the values are only passed as arguments;
+// args are the values of the expression converted to the type of the column (see models.FilterExpr).
+func FilterCondition(column string, expr models.FilterExpr, args []any) (string, []any) {
+	switch expr.Op {
+	case "ne":
+		return column + " <> ?", args
+	case "gt":
+		return column + " > ?", args
+	case "gte":
+		return column + " >= ?", args
+	case "lt":
+		return column + " < ?", args
+	case "lte":
+		return column + " <= ?", args
+	case "like":
+		return column + " ILIKE ?", []any{"%" + likeEscaper.Replace(fmt.Sprint(args[0])) + "%"}
+	case "in":
+		return column + " IN ?", []any{args}
+	case "between":
+		return column + " BETWEEN ? AND ?", args
+	default:
+		return column + " = ?", args
+	}
+}
+
 // isZero checks if the value is considered "zero" for its type.
 func isZero(v any) bool {
 	rv := reflect.ValueOf(v)
@@ -0,0 +1,36 @@
+package helper
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// slugLetters are the letters without a decomposed form and their ASCII spelling.
+var slugLetters = strings.NewReplacer("ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d")
+
+// Slugify turns a name into the lower case, dash separated form used in the URLs,
+// e.g. "Crème Brûlée & Co." becomes "creme-brulee-co".
+func Slugify(name string) string {
+	var slug strings.Builder
+	dash := false
+
+	// the accents are split from their letters (NFD) and dropped
+	for _, r := range norm.NFD.String(slugLetters.Replace(strings.ToLower(name))) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	return slug.String()
+}
@@ -0,0 +1,200 @@
+package helper
+
+import (
+	"context"
+	"e-commerce/utils/constants"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+// The whole token family is revoked in that case, since the token has probably been stolen.
+var ErrRefreshTokenReused = fmt.Errorf("refresh token reuse detected, please login again")
+
+// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens.
+var ErrInvalidRefreshToken = fmt.Errorf("invalid or expired refresh token")
+
+// InvalidateUserTokens rejects every access token issued to the user until now.
+func InvalidateUserTokens(userID string) error {
+	validAfter := strconv.FormatInt(time.Now().Unix(), 10)
+	// the marker only has to live as long as the longest living access token
+	_, err := SetCache(constants.TOKEN_VALID_AFTER_PREFIX+userID, validAfter, time.Duration(ExpiryTime)*time.Minute)
+	return err
+}
+
+// IsTokenRevoked reports whether a token issued to the user at issuedAt (unix seconds)
+// was invalidated by InvalidateUserTokens.
+func IsTokenRevoked(userID string, issuedAt int64) bool {
+	validAfter, err := GetCache(constants.TOKEN_VALID_AFTER_PREFIX + userID)
+	if err != nil {
+		return false
+	}
+	return issuedAt < int64(StringToInt(validAfter))
+}
+
+// DenyAccessToken puts the access token id (jti) on the denylist until the token expires.
+func DenyAccessToken(tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := SetCache(constants.JWT_DENYLIST_PREFIX+tokenID, 1, ttl)
+	return err
+}
+
+// IsAccessTokenDenied reports whether the access token id (jti) is on the denylist.
+func IsAccessTokenDenied(tokenID string) bool {
+	_, err := GetCache(constants.JWT_DENYLIST_PREFIX + tokenID)
+	return err == nil
+}
+
+// IssueRefreshToken creates a new refresh token in the given token family for the user.
+// The family remembers its current token; only that token can be rotated.
+// It returns the refresh token and its expiry.
+func IssueRefreshToken(userID string, familyID string) (string, time.Time, error) {
+	ctx := context.Background()
+
+	token, err := GenerateSecureToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	tokenHash := HashToken(token)
+	ttl := time.Duration(RefreshTokenExpTime) * time.Minute
+	familyKey := constants.REFRESH_FAMILY_PREFIX + familyID
+	userFamiliesKey := constants.USER_REFRESH_FAMILIES_PREFIX + userID
+
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, constants.REFRESH_TOKEN_PREFIX+tokenHash, familyID, ttl)
+	pipe.HSet(ctx, familyKey, "user_id", userID, "current", tokenHash)
+	pipe.Expire(ctx, familyKey, ttl)
+	pipe.SAdd(ctx, userFamiliesKey, familyID)
+	pipe.Expire(ctx, userFamiliesKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, time.Now().Add(ttl), nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one of the same family.
+// A refresh token can be rotated only once; presenting it again revokes the whole family.
+// It returns the user id, the family id, the new refresh token and its expiry.
+func RotateRefreshToken(token string) (string, string, string, time.Time, error) {
+	ctx := context.Background()
+	tokenHash := HashToken(token)
+
+	familyID, err := GetCache(constants.REFRESH_TOKEN_PREFIX + tokenHash)
+	if err != nil {
+		return "", "", "", time.Time{}, ErrInvalidRefreshToken
+	}
+
+	// claim the token atomically, so two concurrent rotations can not both succeed
+	ttl := time.Duration(RefreshTokenExpTime) * time.Minute
+	claimed, err := redisClient.SetNX(ctx, constants.REFRESH_TOKEN_USED_PREFIX+tokenHash, 1, ttl).Result()
+	if err != nil {
+		return "", "", "", time.Time{}, err
+	}
+
+	family, err := redisClient.HGetAll(ctx, constants.REFRESH_FAMILY_PREFIX+familyID).Result()
+	if err != nil {
+		return "", "", "", time.Time{}, err
+	}
+
+	if len(family) == 0 {
+		return "", "", "", time.Time{}, ErrInvalidRefreshToken
+	}
+
+	if !claimed || family["current"] != tokenHash {
+		if err := RevokeTokenFamily(family["user_id"], familyID); err != nil {
+			fmt.Printf("failed to revoke refresh token family %s: %v", familyID, err)
+		}
+		return "", "", "", time.Time{}, ErrRefreshTokenReused
+	}
+
+	newToken, expiry, err := IssueRefreshToken(family["user_id"], familyID)
+	if err != nil {
+		return "", "", "", time.Time{}, err
+	}
+
+	return family["user_id"], familyID, newToken, expiry, nil
+}
+
+// GetRefreshTokenFamily returns the family id of the refresh token
+// if the token is the current, not yet rotated token of a live family.
+func GetRefreshTokenFamily(token string) (string, error) {
+	ctx := context.Background()
+	tokenHash := HashToken(token)
+
+	familyID, err := GetCache(constants.REFRESH_TOKEN_PREFIX + tokenHash)
+	if err != nil {
+		return "", ErrInvalidRefreshToken
+	}
+
+	current, err := redisClient.HGet(ctx, constants.REFRESH_FAMILY_PREFIX+familyID, "current").Result()
+	if err != nil || current != tokenHash {
+		return "", ErrInvalidRefreshToken
+	}
+
+	return familyID, nil
+}
+
+// RevokeTokenFamily revokes all the refresh tokens of the family.
+func RevokeTokenFamily(userID string, familyID string) error {
+	ctx := context.Background()
+
+	pipe := redisClient.TxPipeline()
+	pipe.Del(ctx, constants.REFRESH_FAMILY_PREFIX+familyID)
+	if userID != "" {
+		pipe.SRem(ctx, constants.USER_REFRESH_FAMILIES_PREFIX+userID, familyID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeAllUserTokens logs the user out everywhere: all refresh token families
+// are revoked and all the access tokens issued until now are rejected.
+func RevokeAllUserTokens(userID string) error {
+	ctx := context.Background()
+	userFamiliesKey := constants.USER_REFRESH_FAMILIES_PREFIX + userID
+
+	familyIDs, err := redisClient.SMembers(ctx, userFamiliesKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userFamiliesKey}
+	for _, familyID := range familyIDs {
+		keys = append(keys, constants.REFRESH_FAMILY_PREFIX+familyID)
+	}
+
+	if err := DeleteCache(keys...); err != nil {
+		return err
+	}
+
+	return InvalidateUserTokens(userID)
+}
+
+// RevokeOtherTokenFamilies logs the user out on every device but the current session:
+// all the refresh token families except keepFamilyID are revoked.
+func RevokeOtherTokenFamilies(userID string, keepFamilyID string) error {
+	ctx := context.Background()
+	userFamiliesKey := constants.USER_REFRESH_FAMILIES_PREFIX + userID
+
+	familyIDs, err := redisClient.SMembers(ctx, userFamiliesKey).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := redisClient.TxPipeline()
+	for _, familyID := range familyIDs {
+		if familyID == keepFamilyID {
+			continue
+		}
+		pipe.Del(ctx, constants.REFRESH_FAMILY_PREFIX+familyID)
+		pipe.SRem(ctx, userFamiliesKey, familyID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,139 @@
+package helper
+
+import (
+	"crypto/hmac"
+	cryptRand "crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, supported by all the authenticator apps)
+const (
+	totpDigits     = 6
+	totpPeriod     = 30
+	totpSecretSize = 20
+	// accepted clock drift between the server and the device, in time steps
+	totpSkew = 1
+)
+
+// TOTPClock returns the current time used to generate and validate TOTP codes.
+// Tests replace it with a fixed clock to get deterministic codes.
+var TOTPClock = time.Now
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := cryptRand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI the authenticator apps read from a QR code.
+func TOTPProvisioningURI(secret string, issuer string, accountName string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// TOTPStep returns the TOTP time step of the given time.
+func TOTPStep(at time.Time) int64 {
+	return at.Unix() / totpPeriod
+}
+
+// GenerateTOTPCode returns the code of the secret for the given time step (RFC 4226 / RFC 6238).
+func GenerateTOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret")
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo), nil
+}
+
+// ValidateTOTPCode checks the code against the secret at the given time, accepting
+// the adjacent time steps for clock drift. Steps up to lastUsedStep are rejected,
+// so an accepted code can not be replayed.
+// It returns the matched time step and whether the code is valid.
+func ValidateTOTPCode(secret string, code string, at time.Time, lastUsedStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(at)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		expected, err := GenerateTOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns count random single-use recovery codes formatted as "xxxxx-xxxxx".
+// Each code carries 50 random bits, enough to be stored as a plain SHA-256 hash (see HashToken).
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for len(codes) < count {
+		random := make([]byte, 7)
+		if _, err := cryptRand.Read(random); err != nil {
+			return nil, err
+		}
+
+		encoded := strings.ToLower(totpEncoding.EncodeToString(random))[:10]
+		codes = append(codes, encoded[:5]+"-"+encoded[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode strips the separators and spaces users tend to type differently,
+// so the code can be hashed and compared with the stored hashes.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
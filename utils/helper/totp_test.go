@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors ("12345678901234567890") in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, truncated to the 6 digits used by the authenticator apps
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := GenerateTOTPCode(rfc6238Secret, TOTPStep(time.Unix(test.unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode(%d): %v", test.unix, err)
+		}
+		if code != test.code {
+			t.Errorf("GenerateTOTPCode(%d) = %s, want %s", test.unix, code, test.code)
+		}
+	}
+}
+
+func TestGenerateTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := GenerateTOTPCode("not base32!", 1); err == nil {
+		t.Fatal("an invalid secret was accepted")
+	}
+}
+
+func TestValidateTOTPCodeSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+
+	tests := []struct {
+		name  string
+		step  int64
+		valid bool
+	}{
+		{name: "two steps behind", step: current - 2, valid: false},
+		{name: "one step behind", step: current - 1, valid: true},
+		{name: "current step", step: current, valid: true},
+		{name: "one step ahead", step: current + 1, valid: true},
+		{name: "two steps ahead", step: current + 2, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := GenerateTOTPCode(rfc6238Secret, test.step)
+			if err != nil {
+				t.Fatalf("GenerateTOTPCode: %v", err)
+			}
+
+			step, ok := ValidateTOTPCode(rfc6238Secret, code, now, 0)
+			if ok != test.valid {
+				t.Fatalf("ValidateTOTPCode = %v, want %v", ok, test.valid)
+			}
+			if ok && step != test.step {
+				t.Fatalf("matched step %d, want %d", step, test.step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPCodeReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+
+	code, err := GenerateTOTPCode(rfc6238Secret, current)
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode: %v", err)
+	}
+
+	step, ok := ValidateTOTPCode(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("a valid code was rejected")
+	}
+
+	// the step of the accepted code is stored as the last used step
+	if _, ok := ValidateTOTPCode(rfc6238Secret, code, now, step); ok {
+		t.Fatal("a used code was accepted again")
+	}
+
+	// neither can an older code within the skew window be used after a newer one
+	previous, err := GenerateTOTPCode(rfc6238Secret, current-1)
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode: %v", err)
+	}
+	if _, ok := ValidateTOTPCode(rfc6238Secret, previous, now, step); ok {
+		t.Fatal("a code older than the last used step was accepted")
+	}
+
+	next, err := GenerateTOTPCode(rfc6238Secret, current+1)
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode: %v", err)
+	}
+	if _, ok := ValidateTOTPCode(rfc6238Secret, next, now, step); !ok {
+		t.Fatal("the code of the next step was rejected")
+	}
+}
+
+func TestValidateTOTPCodeFormat(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	code, err := GenerateTOTPCode(rfc6238Secret, TOTPStep(now))
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode: %v", err)
+	}
+
+	if _, ok := ValidateTOTPCode(rfc6238Secret, " "+code[:3]+" "+code[3:]+" ", now, 0); !ok {
+		t.Fatal("a code typed with spaces was rejected")
+	}
+
+	for _, invalid := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := ValidateTOTPCode(rfc6238Secret, invalid, now, 0); ok {
+			t.Fatalf("the code %q was accepted", invalid)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected code format: %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code: %q", code)
+		}
+		seen[code] = true
+
+		// the code is found again however the user types it
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		if NormalizeRecoveryCode(typed) != code {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", typed, NormalizeRecoveryCode(typed), code)
+		}
+	}
+}